@@ -0,0 +1,414 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// bisect holds the repository struct and the prompt pointer.
+type bisect struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	bisect     *git.Bisect // nil until the first commit is marked
+	command    string      // the command of the last run
+	running    bool        // the command is testing a commit in the background
+}
+
+// BisectPrompt configures a prompt to pick the bad and the good commits from
+// the log and to test the commits that a bisect checks out. A bisect in
+// progress is continued.
+func BisectPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
+	bs, err := r.Bisect()
+	if err != nil && err != git.ErrNotBisecting {
+		return nil, fmt.Errorf("could not load bisect: %v", err)
+	}
+	b := &bisect{repository: r}
+	if err == nil {
+		b.bisect = bs
+	}
+	list, err := b.commitList(opts.LineSize)
+	if err != nil {
+		return nil, err
+	}
+	b.prompt = prompt.Create("Bisect", opts, list,
+		prompt.WithSelectionHandler(b.onSelect),
+		prompt.WithItemRenderer(b.renderItem),
+		prompt.WithInformation(b.info),
+	)
+	if err := b.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return b.prompt, nil
+}
+
+// commitList lists the commits that are left to test, or the log of HEAD
+// to pick the bad and the good commits from
+func (b *bisect) commitList(size int) (prompt.List, error) {
+	if b.bisect != nil && len(b.bisect.Remaining) > 0 {
+		list, err := prompt.NewList(b.bisect.Remaining, size)
+		if err != nil {
+			return nil, fmt.Errorf("could not create list: %v", err)
+		}
+		for i, c := range b.bisect.Remaining {
+			if c == b.bisect.Current {
+				list.SetCursor(i)
+			}
+		}
+		return list, nil
+	}
+	// the list ends where the walk fails, there is nothing else to show then
+	commits, _, err := b.repository.Log(&git.LogOptions{}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not load commits: %v", err)
+	}
+	items := make(chan interface{})
+	go func() {
+		for c := range commits {
+			items <- c
+		}
+		close(items)
+	}()
+	list, err := prompt.NewAsyncList(items, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	return list, nil
+}
+
+// onSelect shows the diff of the commit
+func (b *bisect) onSelect(item interface{}) error {
+	commit, ok := item.(*git.Commit)
+	if !ok {
+		return nil
+	}
+	diff, err := commit.Diff()
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0)
+	for _, d := range diff.Deltas() {
+		files = append(files, deltaFile(d))
+	}
+	showDiff(b.prompt, "Diff of "+commit.Hash[:7], commitPreface(commit), files, 0)
+	return nil
+}
+
+func (b *bisect) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'g',
+			Display: "g",
+			Desc:    "mark good",
+			Handler: b.markHandler(git.BisectGood),
+		},
+		&prompt.KeyBinding{
+			Key:     'b',
+			Display: "b",
+			Desc:    "mark bad",
+			Handler: b.markHandler(git.BisectBad),
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "skip",
+			Handler: b.markHandler(git.BisectSkip),
+		},
+		&prompt.KeyBinding{
+			Key:     'x',
+			Display: "x",
+			Desc:    "run command",
+			Handler: b.runCommand,
+		},
+		&prompt.KeyBinding{
+			Key:     'w',
+			Display: "w",
+			Desc:    "write bisect log",
+			Handler: b.writeLog,
+		},
+		&prompt.KeyBinding{
+			Key:     'R',
+			Display: "R",
+			Desc:    "reset bisect",
+			Handler: b.reset,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: b.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := b.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bisect) markHandler(t git.BisectTerm) func(interface{}) error {
+	return func(item interface{}) error {
+		commit, ok := item.(*git.Commit)
+		if !ok || b.waitRun() {
+			return nil
+		}
+		if err := b.mark(commit, t); err != nil {
+			b.prompt.SetMessage(errorText(err))
+		}
+		return nil
+	}
+}
+
+// mark starts the bisect on the first mark and lists the commits that are
+// left to test once a bad and a good commit are marked
+func (b *bisect) mark(commit *git.Commit, t git.BisectTerm) error {
+	if b.bisect == nil {
+		bs, err := b.repository.StartBisect()
+		if err != nil {
+			return err
+		}
+		b.bisect = bs
+	}
+	if err := b.bisect.Mark(commit, t); err != nil {
+		if _, ok := err.(*git.CheckoutError); ok {
+			err = fmt.Errorf("%v, commit or stash them to continue", err)
+		}
+		return err
+	}
+	if err := b.reloadCommits(); err != nil {
+		return err
+	}
+	bs := b.bisect
+	switch {
+	case bs.FirstBad != nil:
+		b.prompt.SetMessage(term.Cprint(bs.FirstBad.Hash[:7]+" is the first bad commit.", color.FgRed))
+	case bs.Current != nil:
+		b.prompt.SetMessage(term.Cprint("Testing "+bs.Current.Hash[:7]+", "+
+			strconv.Itoa(bs.Steps())+" step(s) left.", color.Faint))
+	case len(bs.Remaining) > 0:
+		b.prompt.SetMessage(term.Cprint("Only skipped commits are left to test.", color.FgYellow))
+	case bs.Bad == nil:
+		b.prompt.SetMessage(term.Cprint("Marked "+commit.Hash[:7]+" "+t.String()+", mark a bad commit with b.", color.Faint))
+	default:
+		b.prompt.SetMessage(term.Cprint("Marked "+commit.Hash[:7]+" "+t.String()+", mark a good commit with g.", color.Faint))
+	}
+	return nil
+}
+
+// runCommand asks for a command and tests the commits with it until the
+// first bad commit is found
+func (b *bisect) runCommand(item interface{}) error {
+	if b.waitRun() {
+		return nil
+	}
+	if b.bisect == nil || b.bisect.Current == nil {
+		b.prompt.SetMessage(term.Cprint("Mark a bad and a good commit before running a command.", color.Faint))
+		return nil
+	}
+	b.prompt.ReadInput("Command:", b.command, func(command string) error {
+		command = strings.TrimSpace(command)
+		if len(command) == 0 {
+			return nil
+		}
+		b.command = command
+		b.run()
+		return nil
+	})
+	return nil
+}
+
+// run tests the commit to test in the background and marks it by the exit
+// code of the command, the next commit is tested after
+func (b *bisect) run() {
+	bs := b.bisect
+	current := bs.Current
+	if current == nil {
+		return
+	}
+	b.prompt.SetMessage(term.Cprint("Running "+b.command+" on "+current.Hash[:7]+"...", color.Faint))
+	b.running = true
+	go func() {
+		t, err := bs.Test(b.command)
+		b.prompt.Post(func() error {
+			b.running = false
+			if err != nil {
+				b.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			if err := b.mark(current, t); err != nil {
+				b.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			b.run()
+			return nil
+		})
+	}()
+}
+
+// waitRun tells to wait if the command is running, the work tree and the
+// bisect refs cannot change until the commit under test is marked
+func (b *bisect) waitRun() bool {
+	if b.running {
+		b.prompt.SetMessage(term.Cprint("Wait for "+b.command+" to finish.", color.FgYellow))
+	}
+	return b.running
+}
+
+// writeLog writes the log to a file that "git bisect replay" can consume
+func (b *bisect) writeLog(item interface{}) error {
+	if b.bisect == nil {
+		return nil
+	}
+	b.prompt.ReadInput("Write log to:", "bisect.log", func(path string) error {
+		path = strings.TrimSpace(path)
+		if len(path) == 0 {
+			return nil
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(b.repository.Path(), path)
+		}
+		log, err := b.bisect.Log()
+		if err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		b.prompt.SetMessage(term.Cprint("Written to "+path+", replay it with git bisect replay.", color.Faint))
+		return nil
+	})
+	return nil
+}
+
+// reset ends the bisect and checks out the branch that was checked out
+// before it
+func (b *bisect) reset(item interface{}) error {
+	if b.bisect == nil || b.waitRun() {
+		return nil
+	}
+	if err := b.bisect.Reset(); err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	b.prompt.Stop()
+	exit := term.Cprint("Bisect reset.", color.Faint)
+	if c := b.bisect.FirstBad; c != nil {
+		exit = term.Cprint(c.Hash[:7]+" is the first bad commit: "+c.Summary, color.FgRed)
+	}
+	b.prompt.SetExitMsg([][]term.Cell{exit})
+	return nil
+}
+
+func (b *bisect) quit(item interface{}) error {
+	b.prompt.Stop()
+	if b.bisect != nil {
+		b.prompt.SetExitMsg([][]term.Cell{
+			term.Cprint("Bisecting, run gitin bisect to continue or to reset it.", color.Faint),
+		})
+	}
+	return nil
+}
+
+// reloads the list once the commits left to test are known
+func (b *bisect) reloadCommits() error {
+	if len(b.bisect.Remaining) == 0 {
+		return nil
+	}
+	list, err := b.commitList(b.prompt.ListSize())
+	if err != nil {
+		return err
+	}
+	state := b.prompt.State()
+	state.List = list
+	state.SearchMode = false
+	state.SearchStr = ""
+	b.prompt.SetState(state)
+	return nil
+}
+
+func (b *bisect) info(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	bs := b.bisect
+	switch {
+	case bs == nil || bs.Bad == nil && len(bs.Good) == 0:
+		grid = append(grid, term.Cprint("Mark the bad commit with b and a good one with g to start.", color.Faint))
+	case bs.Bad == nil:
+		grid = append(grid, term.Cprint("Mark the bad commit with b.", color.Faint))
+	case len(bs.Good) == 0:
+		grid = append(grid, term.Cprint("Mark a good commit with g.", color.Faint))
+	default:
+		goods := make([]string, 0, len(bs.Good))
+		for _, c := range bs.Good {
+			goods = append(goods, c.Hash[:7])
+		}
+		cells := term.Cprint("Range   ", color.Faint)
+		cells = append(cells, term.Cprint(strings.Join(goods, ",")+".."+bs.Bad.Hash[:7], color.FgYellow)...)
+		cells = append(cells, term.Cprint(" "+strconv.Itoa(len(bs.Remaining))+" commit(s), roughly "+
+			strconv.Itoa(bs.Steps())+" step(s) left", color.Faint)...)
+		grid = append(grid, cells)
+		switch {
+		case bs.FirstBad != nil:
+			grid = append(grid, term.Cprint(bs.FirstBad.Hash[:7]+" is the first bad commit, press R to reset", color.FgRed))
+		case bs.Current != nil:
+			cells = term.Cprint("Testing ", color.Faint)
+			cells = append(cells, term.Cprint(bs.Current.Hash[:7]+" ", color.FgYellow)...)
+			cells = append(cells, term.Cprint(bs.Current.Summary, color.FgWhite)...)
+			grid = append(grid, cells)
+		default:
+			grid = append(grid, term.Cprint("Only skipped commits are left, the first bad one is among them", color.FgYellow))
+		}
+	}
+	commit, ok := item.(*git.Commit)
+	if !ok {
+		return grid
+	}
+	cells := term.Cprint("Author  ", color.Faint)
+	cells = append(cells, term.Cprint(commit.Author.Name+" <"+commit.Author.Email+">", color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("When    ", color.Faint)
+	cells = append(cells, term.Cprint(timeago.FromTime(commit.Author.When), color.FgWhite)...)
+	grid = append(grid, cells)
+	return grid
+}
+
+// renderItem marks the bad, good and skipped commits and the one to test
+func (b *bisect) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	lines := renderItem(item, matches, selected)
+	commit, ok := item.(*git.Commit)
+	if !ok || b.bisect == nil {
+		return lines
+	}
+	bs := b.bisect
+	switch {
+	case bs.Current != nil && commit.Hash == bs.Current.Hash:
+		lines[0][1] = term.Cell{Ch: '?', Attr: []color.Attribute{color.FgYellow}}
+	case bs.Bad != nil && commit.Hash == bs.Bad.Hash:
+		lines[0][1] = term.Cell{Ch: 'b', Attr: []color.Attribute{color.FgRed}}
+	case containsCommit(bs.Good, commit):
+		lines[0][1] = term.Cell{Ch: 'g', Attr: []color.Attribute{color.FgGreen}}
+	case containsCommit(bs.Skipped, commit):
+		lines[0][1] = term.Cell{Ch: 's', Attr: []color.Attribute{color.Faint}}
+	}
+	return lines
+}
+
+func containsCommit(commits []*git.Commit, c *git.Commit) bool {
+	for _, commit := range commits {
+		if commit.Hash == c.Hash {
+			return true
+		}
+	}
+	return false
+}
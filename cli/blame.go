@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// width of the author names in the blame
+const blameAuthorWidth = 16
+
+// blame holds the repository struct and the prompt pointer
+type blame struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	blame      *git.Blame
+	commits    map[string]*git.Commit // looked up commits of the hunks
+	history    []*blameState          // blames of the newer revisions
+	selected   *git.Commit
+	deltas     []*git.DiffDelta
+	oldState   *prompt.State
+}
+
+// blameState is a blame and the position of the list to return to
+type blameState struct {
+	blame *git.Blame
+	state *prompt.State
+}
+
+// BlamePrompt configures a prompt to annotate the lines of the file with the
+// commits that last changed them
+func BlamePrompt(r *git.Repository, opts *prompt.Options, path, rev string) (*prompt.Prompt, error) {
+	bl, err := r.Blame(path, rev)
+	if err != nil {
+		return nil, fmt.Errorf("could not blame %s: %v", path, err)
+	}
+	list, err := prompt.NewList(bl.Lines, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	b := &blame{
+		repository: r,
+		blame:      bl,
+		commits:    make(map[string]*git.Commit),
+	}
+	b.prompt = prompt.Create(blameLabel(bl), opts, list,
+		prompt.WithSelectionHandler(b.onSelect),
+		prompt.WithItemRenderer(b.renderItem),
+		prompt.WithInformation(b.info),
+	)
+	if err := b.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return b.prompt, nil
+}
+
+func blameLabel(b *git.Blame) string {
+	return "Blame of " + b.Path + " at " + b.Revision
+}
+
+// onSelect lists the files of the commit that changed the line, the blamed
+// file is selected
+func (b *blame) onSelect(item interface{}) error {
+	switch i := item.(type) {
+	case *git.BlameLine:
+		commit, err := b.commit(i.Hunk.Hash)
+		if err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		diff, err := commit.Diff()
+		if err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		deltas := diff.Deltas()
+		if len(deltas) == 0 {
+			return nil
+		}
+		b.selected = commit
+		b.deltas = deltas
+		cursor := 0
+		for j, d := range deltas {
+			if d.NewFile.Path == i.Hunk.OrigPath {
+				cursor = j
+			}
+		}
+		b.oldState = b.prompt.State()
+		list, err := prompt.NewList(deltas, b.oldState.ListSize)
+		if err != nil {
+			return err
+		}
+		b.prompt.SetState(&prompt.State{
+			List:        list,
+			SearchLabel: "Files of " + commit.Hash[:7],
+			Cursor:      cursor,
+		})
+	case *git.DiffDelta:
+		showDeltas(b.prompt, "Diff of "+b.selected.Hash[:7], b.deltas, i)
+	}
+	return nil
+}
+
+func (b *blame) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'p',
+			Display: "p",
+			Desc:    "blame the parent of the commit",
+			Handler: b.blameParent,
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "show diff of the commit",
+			Handler: b.commitDiff,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "back/quit",
+			Handler: b.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := b.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blameParent blames the file at the parent of the commit that changed the
+// line, the cursor is kept on the same line number if possible
+func (b *blame) blameParent(item interface{}) error {
+	line, ok := item.(*git.BlameLine)
+	if !ok {
+		return nil
+	}
+	parent, err := b.repository.BlameParent(line)
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	state := b.prompt.State()
+	b.history = append(b.history, &blameState{blame: b.blame, state: state})
+	cursor := line.Number - 1
+	if cursor >= len(parent.Lines) {
+		cursor = len(parent.Lines) - 1
+	}
+	return b.showBlame(parent, cursor, cursor-state.Cursor)
+}
+
+func (b *blame) showBlame(bl *git.Blame, cursor, scroll int) error {
+	list, err := prompt.NewList(bl.Lines, b.prompt.State().ListSize)
+	if err != nil {
+		return err
+	}
+	b.blame = bl
+	b.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: blameLabel(bl),
+		Cursor:      cursor,
+		Scroll:      scroll,
+	})
+	return nil
+}
+
+func (b *blame) commitDiff(item interface{}) error {
+	line, ok := item.(*git.BlameLine)
+	if !ok {
+		return nil
+	}
+	commit, err := b.commit(line.Hunk.Hash)
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	diff, err := commit.Diff()
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0)
+	index := 0
+	for i, d := range diff.Deltas() {
+		if d.NewFile.Path == line.Hunk.OrigPath {
+			index = i
+		}
+		files = append(files, deltaFile(d))
+	}
+	showDiff(b.prompt, "Diff of "+commit.Hash[:7], commitPreface(commit), files, index)
+	return nil
+}
+
+// quit returns to the blame from the files, or to the blame of the newer
+// revision. The prompt is stopped if there is nothing to return to.
+func (b *blame) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.DiffDelta:
+		b.prompt.SetState(b.oldState)
+		return nil
+	}
+	if len(b.history) == 0 {
+		b.prompt.Stop()
+		return nil
+	}
+	last := b.history[len(b.history)-1]
+	b.history = b.history[:len(b.history)-1]
+	b.blame = last.blame
+	b.prompt.SetState(last.state)
+	return nil
+}
+
+// commit looks up the commit of the hunk once
+func (b *blame) commit(hash string) (*git.Commit, error) {
+	if c, ok := b.commits[hash]; ok {
+		return c, nil
+	}
+	c, err := b.repository.LookupCommit(hash)
+	if err != nil {
+		return nil, err
+	}
+	b.commits[hash] = c
+	return c, nil
+}
+
+func (b *blame) info(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	switch i := item.(type) {
+	case *git.BlameLine:
+		commit, err := b.commit(i.Hunk.Hash)
+		if err != nil {
+			return append(grid, errorText(err))
+		}
+		cells := term.Cprint(commit.Hash[:7]+" ", color.FgYellow)
+		cells = append(cells, term.Cprint(commit.Summary, color.FgWhite)...)
+		grid = append(grid, cells)
+		cells = term.Cprint("Author ", color.Faint)
+		cells = append(cells, term.Cprint(commit.Author.Name+" <"+commit.Author.Email+">", color.FgWhite)...)
+		grid = append(grid, cells)
+		cells = term.Cprint("When", color.Faint)
+		cells = append(cells, term.Cprint("   "+commit.Author.When.Format("Mon Jan 2 15:04:05 2006 -0700"), color.FgWhite)...)
+		grid = append(grid, cells)
+		if i.Hunk.OrigPath != b.blame.Path {
+			grid = append(grid, term.Cprint("Was "+i.Hunk.OrigPath, color.Faint))
+		}
+	case *git.DiffDelta:
+		cells := term.Cprint("Changed in ", color.Faint)
+		cells = append(cells, term.Cprint(b.selected.Summary, color.FgWhite)...)
+		grid = append(grid, cells)
+	}
+	return grid
+}
+
+// renderItem draws the commit of a hunk on its first line only, so that the
+// lines are grouped by the hunks. Every line has its commit while searching.
+func (b *blame) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	l, ok := item.(*git.BlameLine)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	metaWidth := 7 + 1 + blameAuthorWidth + 1 + blameAgeWidth
+	if l.First() || len(b.prompt.State().SearchStr) > 0 {
+		hash := l.Hunk.Hash[:7]
+		if l.Hunk.Boundary {
+			hash = "^" + hash[:6]
+		}
+		line = append(line, term.Cprint(hash+" ", color.FgYellow)...)
+		author, age := "", ""
+		if l.Hunk.Author != nil {
+			author = l.Hunk.Author.Name
+			age = timeago.FromTime(l.Hunk.Author.When)
+		}
+		line = append(line, term.Fit(term.Cprint(author, color.FgCyan), blameAuthorWidth)...)
+		line = append(line, term.Cell{Ch: ' '})
+		line = append(line, term.Fit(term.Cprint(age, color.Faint), blameAgeWidth)...)
+	} else {
+		line = append(line, term.Cprint(strings.Repeat(" ", metaWidth), color.Faint)...)
+	}
+	numWidth := len(fmt.Sprint(len(b.blame.Lines)))
+	line = append(line, term.Cprint(fmt.Sprintf(" %*d │ ", numWidth, l.Number), color.Faint)...)
+	line = append(line, highLightedText(expandMatches(l.Content, matches), color.FgWhite, expandTabs(l.Content))...)
+	return [][]term.Cell{line}
+}
+
+// width of the ages in the blame e.g. "3 months ago"
+const blameAgeWidth = 14
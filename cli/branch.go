@@ -2,7 +2,8 @@ package cli
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/isacikgoz/gitin/prompt"
@@ -15,42 +16,109 @@ import (
 type branch struct {
 	repository *git.Repository
 	prompt     *prompt.Prompt
+	view       *BranchOptions
+	headers    map[*git.Branch]string
+
+	marked     *git.Branch
+	comparison *git.Comparison
+	sides      map[*git.Commit]string
+	deltas     []*git.DiffDelta
+	diffLabel  string
+	states     []*prompt.State
 }
 
 // BranchPrompt configures a prompt to serve as a branch prompt
-func BranchPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
-	branches, err := r.Branches()
+func BranchPrompt(r *git.Repository, opts *prompt.Options, view *BranchOptions) (*prompt.Prompt, error) {
+	branches, headers, err := listBranches(r, view)
 	if err != nil {
 		return nil, fmt.Errorf("could not load branches: %v", err)
 	}
+	if len(branches) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No branches of "+branchFilterNames[view.Filter]+" found.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
 	list, err := prompt.NewList(branches, opts.LineSize)
 	if err != nil {
 		return nil, fmt.Errorf("could not create list: %v", err)
 	}
 
-	b := &branch{repository: r}
+	b := &branch{repository: r, view: view, headers: headers}
 	b.prompt = prompt.Create("Branches", opts, list,
 		prompt.WithSelectionHandler(b.onSelect),
-		prompt.WithItemRenderer(renderItem),
+		prompt.WithItemRenderer(b.renderItem),
 		prompt.WithInformation(b.branchInfo),
 	)
-	b.defineKeyBindings()
+	if err := b.defineKeyBindings(); err != nil {
+		return nil, err
+	}
 
 	return b.prompt, nil
 }
 
 func (b *branch) onSelect(item interface{}) error {
-	branch := item.(*git.Branch)
-	args := []string{"checkout", branch.Name}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = b.repository.Path()
-	if err := cmd.Run(); err != nil {
-		return nil // possibly dirty branch
+	switch i := item.(type) {
+	case *git.Branch:
+		return b.checkout(i)
+	case *git.Comparison:
+		return b.comparisonFiles(i)
+	case *git.Commit:
+		return b.commitFiles(i)
+	case *git.DiffDelta:
+		showDeltas(b.prompt, b.diffLabel, b.deltas, i)
+	}
+	return nil
+}
+
+// checkout switches to the branch and quits
+func (b *branch) checkout(branch *git.Branch) error {
+	err := b.repository.Checkout(branch, git.CheckoutSafe)
+	if conflict, ok := err.(*git.CheckoutError); ok {
+		b.offerStash(branch, conflict)
+		return nil
+	}
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
 	}
 	b.prompt.Stop() // quit after selection
 	return nil
 }
 
+// offerStash asks to stash the local changes that block the checkout, or to
+// discard them, before switching to the branch
+func (b *branch) offerStash(branch *git.Branch, conflict *git.CheckoutError) {
+	label := fmt.Sprintf("%d file(s) would be overwritten: stash and switch (s), discard and switch (f)?", len(conflict.Paths))
+	b.prompt.ReadInput(label, "", func(answer string) error {
+		var exit string
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "s":
+			if err := b.repository.StashAll("autostash before checkout of " + branch.Name); err != nil {
+				b.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			exit = "Local changes are stashed in stash@{0}."
+			if err := b.repository.Checkout(branch, git.CheckoutSafe); err != nil {
+				b.prompt.SetMessage(errorText(fmt.Errorf("changes are stashed but checkout failed: %v", err)))
+				return nil
+			}
+		case "f":
+			if err := b.repository.Checkout(branch, git.CheckoutForce); err != nil {
+				b.prompt.SetMessage(errorText(err))
+				return nil
+			}
+		default:
+			return nil
+		}
+		b.prompt.Stop()
+		if len(exit) > 0 {
+			b.prompt.SetExitMsg([][]term.Cell{term.Cprint(exit, color.Faint)})
+		}
+		return nil
+	})
+}
+
 func (b *branch) defineKeyBindings() error {
 	keybindings := []*prompt.KeyBinding{
 		&prompt.KeyBinding{
@@ -65,6 +133,90 @@ func (b *branch) defineKeyBindings() error {
 			Desc:    "force delete branch",
 			Handler: b.forceDeleteBranch,
 		},
+		&prompt.KeyBinding{
+			Key:     'n',
+			Display: "n",
+			Desc:    "new branch from selected",
+			Handler: b.createBranch,
+		},
+		&prompt.KeyBinding{
+			Key:     'R',
+			Display: "R",
+			Desc:    "rename branch",
+			Handler: b.renameBranch,
+		},
+		&prompt.KeyBinding{
+			Key:     't',
+			Display: "t",
+			Desc:    "set upstream",
+			Handler: b.setUpstream,
+		},
+		&prompt.KeyBinding{
+			Key:     'T',
+			Display: "T",
+			Desc:    "unset upstream",
+			Handler: b.unsetUpstream,
+		},
+		&prompt.KeyBinding{
+			Key:     'm',
+			Display: "m",
+			Desc:    "merge into HEAD",
+			Handler: b.merge,
+		},
+		&prompt.KeyBinding{
+			Key:     'r',
+			Display: "r",
+			Desc:    "rebase HEAD onto branch",
+			Handler: b.rebase,
+		},
+		&prompt.KeyBinding{
+			Key:     'f',
+			Display: "f",
+			Desc:    "fetch",
+			Handler: b.fetch,
+		},
+		&prompt.KeyBinding{
+			Key:     'u',
+			Display: "u",
+			Desc:    "pull (fast-forward only)",
+			Handler: b.pull,
+		},
+		&prompt.KeyBinding{
+			Key:     'P',
+			Display: "P",
+			Desc:    "push",
+			Handler: b.push,
+		},
+		&prompt.KeyBinding{
+			Key:     ' ',
+			Display: "space",
+			Desc:    "mark branch to compare",
+			Handler: b.markBranch,
+		},
+		&prompt.KeyBinding{
+			Key:     'c',
+			Display: "c",
+			Desc:    "compare with marked branch or HEAD",
+			Handler: b.compare,
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "toggle sort by date/name/ahead-behind",
+			Handler: b.toggleSort,
+		},
+		&prompt.KeyBinding{
+			Key:     'g',
+			Display: "g",
+			Desc:    "toggle grouping",
+			Handler: b.toggleGroup,
+		},
+		&prompt.KeyBinding{
+			Key:     'F',
+			Display: "F",
+			Desc:    "toggle filter merged/unmerged/gone",
+			Handler: b.toggleFilter,
+		},
 		&prompt.KeyBinding{
 			Key:     'q',
 			Display: "q",
@@ -81,7 +233,10 @@ func (b *branch) defineKeyBindings() error {
 }
 
 func (b *branch) branchInfo(item interface{}) [][]term.Cell {
-	branch := item.(*git.Branch)
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return b.comparisonInfo(item)
+	}
 	target := branch.Target()
 	grid := make([][]term.Cell, 0)
 	if target != nil {
@@ -91,40 +246,255 @@ func (b *branch) branchInfo(item interface{}) [][]term.Cell {
 		if branch.IsRemote() {
 			return grid
 		}
+		if branch.Gone {
+			grid = append(grid, term.Cprint("Upstream is gone", color.FgRed))
+		}
 		grid = append(grid, branchInfo(branch, false)...)
 	}
 	return grid
 }
 
 func (b *branch) deleteBranch(item interface{}) error {
-	return b.bareDelete(item, "d")
+	return b.delete(item, false)
 }
 
 func (b *branch) forceDeleteBranch(item interface{}) error {
-	return b.bareDelete(item, "D")
+	return b.delete(item, true)
 }
 
-func (b *branch) bareDelete(item interface{}, mode string) error {
-	branch := item.(*git.Branch)
-	cmd := exec.Command("git", "branch", "-"+mode, branch.Name)
-	cmd.Dir = b.repository.Path()
-	if err := cmd.Run(); err != nil {
-		return nil // possibly an unmerged branch, just ignore it
+// delete deletes a local branch, or the branch on the remote of a
+// remote-tracking branch after it is confirmed
+func (b *branch) delete(item interface{}, force bool) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if !branch.IsRemote() {
+		if err := branch.Delete(force); err != nil {
+			if err == git.ErrBranchNotMerged {
+				err = fmt.Errorf("%v, use D to delete it anyway", err)
+			}
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return b.reloadBranches()
+	}
+	b.prompt.ReadInput("Delete "+branch.Name+" from the remote? (y/N)", "", func(answer string) error {
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return nil
+		}
+		runRemoteAction(b.prompt, b.repository, "Deleting "+branch.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+			return r.DeleteRemoteBranch(branch, progress)
+		}, b.reloadBranches)
+		return nil
+	})
+	return nil
+}
+
+// createBranch creates a branch that points to the commit of the selected one
+func (b *branch) createBranch(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	target := branch.Target()
+	if target == nil {
+		return nil
+	}
+	b.prompt.ReadInput("New branch from "+branch.Name+":", "", func(name string) error {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			return nil
+		}
+		if err := b.repository.CreateBranch(name, target); err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return b.reloadBranches()
+	})
+	return nil
+}
+
+func (b *branch) renameBranch(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.IsRemote() {
+		b.prompt.SetMessage(errorText(git.ErrRemoteBranch))
+		return nil
+	}
+	b.prompt.ReadInput("Rename "+branch.Name+" to:", branch.Name, func(name string) error {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 || name == branch.Name {
+			return nil
+		}
+		if err := branch.Rename(name); err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return b.reloadBranches()
+	})
+	return nil
+}
+
+// setUpstream sets the remote-tracking branch that the branch tracks, the
+// current upstream or the branch on the default remote is suggested
+func (b *branch) setUpstream(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.IsRemote() {
+		b.prompt.SetMessage(errorText(git.ErrRemoteBranch))
+		return nil
+	}
+	suggestion := defaultRemote(branch) + "/" + branch.Name
+	if branch.Upstream != nil {
+		suggestion = branch.Upstream.Name
+	}
+	b.prompt.ReadInput("Upstream of "+branch.Name+":", suggestion, func(upstream string) error {
+		upstream = strings.TrimSpace(upstream)
+		if len(upstream) == 0 {
+			return nil
+		}
+		if err := branch.SetUpstream(upstream); err != nil {
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return b.reloadBranches()
+	})
+	return nil
+}
+
+func (b *branch) unsetUpstream(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if err := branch.UnsetUpstream(); err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
 	}
 	return b.reloadBranches()
 }
 
+// merge merges the branch into HEAD. If the changes conflict, the prompt is
+// stopped to resolve the conflicts in the status.
+func (b *branch) merge(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.Head {
+		return nil
+	}
+	err := b.repository.Merge(branch)
+	switch err {
+	case nil:
+		b.prompt.SetMessage(term.Cprint("Merged "+branch.Name+" into HEAD.", color.Faint))
+	case git.ErrAlreadyUpToDate:
+		b.prompt.SetMessage(term.Cprint("Already up to date.", color.Faint))
+	default:
+		if b.repository.State().InProgress() {
+			b.prompt.Stop()
+			b.prompt.SetExitMsg([][]term.Cell{
+				append(term.Cprint("Merging "+branch.Name+" stopped: ", color.Faint), errorText(err)...),
+			})
+			return nil
+		}
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return b.reloadBranches()
+}
+
+// rebase applies the commits of HEAD that are not in the branch on top of it
+func (b *branch) rebase(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.Head {
+		return nil
+	}
+	err := b.repository.Rebase(branch)
+	switch err {
+	case nil:
+		b.prompt.SetMessage(term.Cprint("Rebased HEAD onto "+branch.Name+".", color.Faint))
+	case git.ErrAlreadyUpToDate:
+		b.prompt.SetMessage(term.Cprint("Already up to date.", color.Faint))
+	default:
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return b.reloadBranches()
+}
+
+func (b *branch) fetch(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	remote := defaultRemote(branch)
+	runRemoteAction(b.prompt, b.repository, "Fetching "+remote, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.Fetch(remote, progress)
+	}, b.reloadBranches)
+	return nil
+}
+
+func (b *branch) pull(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.IsRemote() {
+		return nil
+	}
+	runRemoteUpdate(b.prompt, b.repository, "Pulling "+branch.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.FetchUpstream(branch, progress)
+	}, func() error {
+		return b.repository.FastForwardUpstream(branch)
+	}, b.reloadBranches)
+	return nil
+}
+
+func (b *branch) push(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if branch.IsRemote() {
+		return nil
+	}
+	runRemoteAction(b.prompt, b.repository, "Pushing "+branch.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.Push(branch, progress)
+	}, b.reloadBranches)
+	return nil
+}
+
 func (b *branch) quit(item interface{}) error {
-	b.prompt.Stop()
+	switch item.(type) {
+	case *git.Branch:
+		b.prompt.Stop()
+	default:
+		b.popState()
+	}
 	return nil
 }
 
 // reloads the list
 func (b *branch) reloadBranches() error {
-	branches, err := b.repository.Branches()
+	branches, headers, err := listBranches(b.repository, b.view)
 	if err != nil {
 		return err
 	}
+	if len(branches) == 0 && b.view.Filter != git.BranchFilterNone {
+		// the keys do not work on an empty list, list all branches instead
+		b.view.Filter = git.BranchFilterNone
+		return b.reloadBranches()
+	}
+	b.headers = headers
 	state := b.prompt.State()
 	list, err := prompt.NewList(branches, state.ListSize)
 	if err != nil {
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// BranchOptions is the initial order, grouping and filter of the branch list
+type BranchOptions struct {
+	Sort   git.BranchSort
+	Filter git.BranchFilter
+	Group  bool
+}
+
+var branchSortNames = map[git.BranchSort]string{
+	git.BranchSortDate:        "last commit date",
+	git.BranchSortName:        "name",
+	git.BranchSortAheadBehind: "ahead/behind",
+}
+
+var branchFilterNames = map[git.BranchFilter]string{
+	git.BranchFilterNone:     "all branches",
+	git.BranchFilterMerged:   "merged into HEAD",
+	git.BranchFilterUnmerged: "not merged into HEAD",
+	git.BranchFilterGone:     "upstream gone",
+}
+
+// the group of the stale branches comes after the local and remote groups
+const staleGroup = "stale"
+
+// listBranches loads the branches in the order, grouping and filter of the
+// options. If they are grouped, the first branch of each group is mapped to
+// the name of its group.
+func listBranches(r *git.Repository, opts *BranchOptions) ([]*git.Branch, map[*git.Branch]string, error) {
+	branches, err := r.Branches()
+	if err != nil {
+		return nil, nil, err
+	}
+	branches, err = r.FilterBranches(branches, opts.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	git.SortBranches(branches, opts.Sort)
+	headers := make(map[*git.Branch]string)
+	if !opts.Group {
+		return branches, headers, nil
+	}
+	groups := make(map[string][]*git.Branch)
+	names := make([]string, 0)
+	for _, b := range branches {
+		group := branchGroup(b)
+		if _, ok := groups[group]; !ok {
+			names = append(names, group)
+		}
+		groups[group] = append(groups[group], b)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return groupRank(names[i]) < groupRank(names[j]) ||
+			groupRank(names[i]) == groupRank(names[j]) && names[i] < names[j]
+	})
+	grouped := make([]*git.Branch, 0, len(branches))
+	for _, name := range names {
+		headers[groups[name][0]] = name
+		grouped = append(grouped, groups[name]...)
+	}
+	return grouped, headers, nil
+}
+
+// branchGroup returns "local", the remote name or "stale" for the branch
+func branchGroup(b *git.Branch) string {
+	switch {
+	case b.Stale() && !b.Head:
+		return staleGroup
+	case b.IsRemote():
+		if remote := b.RemoteName(); len(remote) > 0 {
+			return remote
+		}
+		return "remote"
+	default:
+		return "local"
+	}
+}
+
+// groupRank orders the local branches first and the stale ones last
+func groupRank(group string) int {
+	switch group {
+	case "local":
+		return 0
+	case staleGroup:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (b *branch) toggleSort(item interface{}) error {
+	if _, ok := item.(*git.Branch); !ok {
+		return nil
+	}
+	b.view.Sort = (b.view.Sort + 1) % git.BranchSort(len(branchSortNames))
+	if err := b.reloadBranches(); err != nil {
+		return err
+	}
+	b.prompt.SetMessage(term.Cprint("Sorted by "+branchSortNames[b.view.Sort]+".", color.Faint))
+	return nil
+}
+
+func (b *branch) toggleGroup(item interface{}) error {
+	if _, ok := item.(*git.Branch); !ok {
+		return nil
+	}
+	b.view.Group = !b.view.Group
+	return b.reloadBranches()
+}
+
+// toggleFilter switches to the next filter, the filters that match no
+// branches are skipped since the keys work only on a non-empty list
+func (b *branch) toggleFilter(item interface{}) error {
+	if _, ok := item.(*git.Branch); !ok {
+		return nil
+	}
+	previous := b.view.Filter
+	for {
+		b.view.Filter = (b.view.Filter + 1) % git.BranchFilter(len(branchFilterNames))
+		if b.view.Filter == previous {
+			return nil
+		}
+		branches, _, err := listBranches(b.repository, b.view)
+		if err != nil {
+			b.view.Filter = previous
+			b.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		if len(branches) > 0 {
+			break
+		}
+	}
+	if err := b.reloadBranches(); err != nil {
+		return err
+	}
+	b.prompt.SetMessage(term.Cprint("Showing "+branchFilterNames[b.view.Filter]+".", color.Faint))
+	return nil
+}
+
+// renderItem prints the name of the group above its first branch unless
+// the list is searched
+func (b *branch) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	br, ok := item.(*git.Branch)
+	if !ok {
+		return b.renderCompareItem(item, matches, selected)
+	}
+	lines := renderItem(item, matches, selected)
+	if b.marked != nil && b.marked.FullName == br.FullName {
+		lines[0][1] = term.Cell{Ch: '*', Attr: []color.Attribute{color.FgYellow}}
+	}
+	if br.Gone {
+		lines[0] = append(lines[0], term.Cprint(" [gone]", color.Faint)...)
+	}
+	header, ok := b.headers[br]
+	if !ok || len(b.prompt.State().SearchStr) > 0 {
+		return lines
+	}
+	return append([][]term.Cell{term.Cprint(header+":", color.Faint)}, lines...)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// markCommit marks or unmarks the commit to cherry-pick or revert it with
+// the other marked commits
+func (l *log) markCommit(item interface{}) error {
+	c, ok := item.(*git.Commit)
+	if !ok {
+		return nil
+	}
+	if l.marked[c] {
+		delete(l.marked, c)
+	} else {
+		l.marked[c] = true
+	}
+	return nil
+}
+
+// cherryPick applies the marked commits, or the selected one, to HEAD from
+// the oldest to the newest
+func (l *log) cherryPick(item interface{}) error {
+	return l.applyCommits(item, "Cherry-picked", "Cherry-picking", false, l.repository.CherryPick)
+}
+
+// revert undoes the marked commits, or the selected one, on HEAD from the
+// newest to the oldest
+func (l *log) revert(item interface{}) error {
+	return l.applyCommits(item, "Reverted", "Reverting", true, l.repository.Revert)
+}
+
+// applyCommits applies the operation to the commits one by one in the
+// topological order. If a commit conflicts, the prompt is stopped to resolve
+// the conflicts in the status.
+func (l *log) applyCommits(item interface{}, done, doing string, newestFirst bool, apply func(*git.Commit) (*git.Commit, error)) error {
+	c, ok := item.(*git.Commit)
+	if !ok {
+		return nil
+	}
+	commits := l.markedCommits(c, newestFirst)
+	for i, commit := range commits {
+		_, err := apply(commit)
+		if err == nil {
+			continue
+		}
+		if l.repository.State().InProgress() {
+			grid := [][]term.Cell{
+				append(term.Cprint(doing+" "+commit.Hash[:7]+" stopped: ", color.Faint), errorText(err)...),
+			}
+			for _, rest := range commits[i+1:] {
+				grid = append(grid, term.Cprint("not applied: "+rest.Hash[:7]+" "+rest.Summary, color.Faint))
+			}
+			l.prompt.Stop()
+			l.prompt.SetExitMsg(grid)
+			return nil
+		}
+		if i > 0 {
+			err = fmt.Errorf("%s %d commit(s), failed at %s: %v", done, i, commit.Hash[:7], err)
+		}
+		l.prompt.SetMessage(errorText(err))
+		return l.reloadCommits()
+	}
+	l.prompt.SetMessage(term.Cprint(fmt.Sprintf("%s %d commit(s).", done, len(commits)), color.Faint))
+	return l.reloadCommits()
+}
+
+// markedCommits returns the marked commits in the topological order, or the
+// selected commit if none is marked
+func (l *log) markedCommits(selected *git.Commit, newestFirst bool) []*git.Commit {
+	if len(l.marked) == 0 {
+		return []*git.Commit{selected}
+	}
+	commits := make([]*git.Commit, 0, len(l.marked))
+	for c := range l.marked {
+		commits = append(commits, c)
+	}
+	l.mu.RLock()
+	sort.Slice(commits, func(i, j int) bool {
+		if newestFirst {
+			return l.order[commits[i]] < l.order[commits[j]]
+		}
+		return l.order[commits[i]] > l.order[commits[j]]
+	})
+	l.mu.RUnlock()
+	return commits
+}
+
+// reloadCommits loads the commits again since HEAD has moved, the marks are
+// cleared
+func (l *log) reloadCommits() error {
+	l.repository.LoadHead()
+	l.repository.RefMap = make(map[string][]git.Ref)
+	l.repository.Branches()
+	l.repository.Tags()
+	l.marked = make(map[*git.Commit]bool)
+	state := l.prompt.State()
+	list, err := l.commitList(state.ListSize)
+	if err != nil {
+		return err
+	}
+	l.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: state.SearchLabel,
+	})
+	return nil
+}
@@ -8,9 +8,18 @@ import (
 )
 
 func popGitCommand(r *git.Repository, args []string) error {
+	return popGitCommandWithEnv(r, args, nil)
+}
+
+// popGitCommandWithEnv runs the git command with additional environment
+// variables e.g. "GIT_SEQUENCE_EDITOR=..."
+func popGitCommandWithEnv(r *git.Repository, args []string, env []string) error {
 	os.Setenv("LESS", "-RCS")
 	cmd := exec.Command("git", args...)
 	cmd.Dir = r.Path()
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	cmd.Stdout = os.Stdout
 	cmd.Stdin = os.Stdin
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// subjectLength is the length that the subject of a commit should not exceed
+// unless the gitin.lint.subjectLength is configured
+const subjectLength = 50
+
+// composer is the state of the commit message that is being written
+type composer struct {
+	head     *git.Commit // the commit to amend, nil for a new commit
+	template string
+	linter   *git.Linter
+}
+
+// composeCommit opens the commit composer with the commit template, or with
+// the message of HEAD to amend it. The pre-commit hook runs before the
+// message is written like "git commit" does.
+func (s *status) composeCommit(amend bool) error {
+	r := s.repository
+	if len(operationCommand(r.State())) > 0 && !amend {
+		// the operation knows the parents and the message of the commit
+		return s.continueOperation(nil)
+	}
+	linter, err := r.Linter()
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	c := &composer{linter: linter}
+	if amend {
+		head, err := r.LookupCommit("HEAD")
+		if err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		c.head = head
+	} else if !s.hasStagedChanges() {
+		s.prompt.SetMessage(term.Cprint("Nothing to commit, stage changes with space or a.", color.Faint))
+		return nil
+	}
+	if err := r.RunHook("pre-commit"); err != nil {
+		s.showHookError(err)
+		return nil
+	}
+	if c.head != nil {
+		s.editMessage(c, strings.TrimRight(c.head.Message, "\n"))
+		return nil
+	}
+	if c.template, err = r.CommitTemplate(); err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	s.editMessage(c, c.template)
+	return nil
+}
+
+func (s *status) hasStagedChanges() bool {
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		return false
+	}
+	for _, e := range st.Entities {
+		if e.Indexed() {
+			return true
+		}
+	}
+	return false
+}
+
+// editMessage shows the composer with the text, the message is committed or
+// amends the head if it is set. The lint violations are shown below the text
+// and they prevent the commit unless ctrl-o is pressed. The text is kept in
+// the composer if the commit fails.
+func (s *status) editMessage(c *composer, text string) {
+	label := "Commit message"
+	if c.head != nil {
+		label = "Amend " + c.head.Hash[:7]
+	}
+	ruler := c.linter.SubjectLength()
+	if ruler == 0 {
+		ruler = subjectLength
+	}
+	s.prompt.ShowEditor(label, text, ruler, c.lintInfo, func(text string) error {
+		return s.submitMessage(c, text, false)
+	}, &prompt.KeyBinding{
+		Key:     rune(term.KeyCtrlO),
+		Display: "ctrl-o",
+		Desc:    "commit anyway",
+		Handler: func(item interface{}) error {
+			text, ok := item.(string)
+			if !ok {
+				return nil
+			}
+			return s.submitMessage(c, text, true)
+		},
+	})
+}
+
+// submitMessage commits the text of the composer, the lint violations are
+// ignored if it is forced
+func (s *status) submitMessage(c *composer, text string, force bool) error {
+	message := git.CleanupMessage(text)
+	switch {
+	case len(message) == 0:
+		s.prompt.SetMessage(errorText(git.ErrEmptyCommitMessage))
+		return nil
+	case len(c.template) > 0 && message == git.CleanupMessage(c.template):
+		s.prompt.SetMessage(term.Cprint("Aborting commit, the template is not edited.", color.FgRed))
+		return nil
+	}
+	if violations := c.linter.Lint(message); len(violations) > 0 && !force {
+		s.editMessage(c, text)
+		s.prompt.SetMessage(term.Cprint(fmt.Sprintf("The message has %d lint problem(s), fix them or press ctrl-o to commit anyway.", len(violations)), color.FgRed))
+		return nil
+	}
+	commit, err := s.commitMessage(c.head, message)
+	if err != nil {
+		// the first line of the output of a hook is in the error
+		s.editMessage(c, text)
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if err := s.reloadStatus(); err != nil {
+		return err
+	}
+	s.prompt.SetMessage(commitSummary(s.repository.Head, commit))
+	return nil
+}
+
+// lintInfo renders the lint violations of the text, nothing is shown until
+// a message is written
+func (c *composer) lintInfo(text string) [][]term.Cell {
+	message := git.CleanupMessage(text)
+	if len(message) == 0 || (len(c.template) > 0 && message == git.CleanupMessage(c.template)) {
+		return nil
+	}
+	lines := make([][]term.Cell, 0)
+	for _, v := range c.linter.Lint(message) {
+		cells := term.Cprint("✗ ", color.FgRed)
+		cells = append(cells, term.Cprint(v.Rule+": ", color.Faint)...)
+		lines = append(lines, append(cells, term.Cprint(v.Message, color.FgRed)...))
+	}
+	return lines
+}
+
+// commitMessage runs the commit-msg hook on the message and commits it, the
+// post-commit hook cannot fail the commit
+func (s *status) commitMessage(head *git.Commit, message string) (*git.Commit, error) {
+	r := s.repository
+	message, err := r.RunCommitMsgHook(message)
+	if err != nil {
+		return nil, err
+	}
+	var c *git.Commit
+	if head != nil {
+		c, err = head.Amend(message)
+	} else {
+		c, err = r.Commit(message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.RunHook("post-commit")
+	return c, nil
+}
+
+// showHookError shows the output of a failed hook in a pane, or the error
+// if the hook has no output
+func (s *status) showHookError(err error) {
+	hookErr, ok := err.(*git.HookError)
+	if !ok || len(hookErr.Output) == 0 {
+		s.prompt.SetMessage(errorText(err))
+		return
+	}
+	lines := make([]*prompt.PaneLine, 0)
+	for _, line := range strings.Split(hookErr.Output, "\n") {
+		lines = append(lines, &prompt.PaneLine{Cells: term.Cprint(line, color.FgWhite)})
+	}
+	s.prompt.ShowPane(hookErr.Hook+" hook failed", func(width int) []*prompt.PaneLine {
+		return lines
+	}, 0)
+}
+
+// commitSummary is the line that "git commit" prints e.g. "[main 1a2b3c4] subject"
+func commitSummary(b *git.Branch, c *git.Commit) []term.Cell {
+	cells := term.Cprint("[", color.Faint)
+	if b != nil {
+		cells = append(cells, term.Cprint(b.Name+" ", color.FgGreen)...)
+	}
+	cells = append(cells, term.Cprint(c.Hash[:7], color.FgYellow)...)
+	cells = append(cells, term.Cprint("] ", color.Faint)...)
+	return append(cells, term.Cprint(c.Summary, color.FgWhite)...)
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// markers of the commits in a comparison like "git log --left-right"
+const (
+	sideA    = "<"
+	sideB    = ">"
+	sideBase = "="
+)
+
+// markBranch marks or unmarks the branch to compare it with the selected one
+func (b *branch) markBranch(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	if b.marked != nil && b.marked.FullName == branch.FullName {
+		b.marked = nil
+	} else {
+		b.marked = branch
+	}
+	return nil
+}
+
+// compare lists the commits that are only in the marked branch, or HEAD if
+// none is marked, or only in the selected branch and their merge base
+func (b *branch) compare(item interface{}) error {
+	branch, ok := item.(*git.Branch)
+	if !ok {
+		return nil
+	}
+	a := b.marked
+	if a == nil {
+		a = b.repository.Head
+	}
+	if a == nil || a.FullName == branch.FullName {
+		b.prompt.SetMessage(term.Cprint("Mark another branch with space to compare.", color.Faint))
+		return nil
+	}
+	c, err := b.repository.Compare(a, branch)
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	items := []interface{}{c}
+	sides := make(map[*git.Commit]string)
+	for _, commit := range c.OnlyA {
+		items = append(items, commit)
+		sides[commit] = sideA
+	}
+	for _, commit := range c.OnlyB {
+		items = append(items, commit)
+		sides[commit] = sideB
+	}
+	if c.Base != nil {
+		items = append(items, c.Base)
+		sides[c.Base] = sideBase
+	}
+	list, err := prompt.NewList(items, b.prompt.State().ListSize)
+	if err != nil {
+		return err
+	}
+	b.comparison = c
+	b.sides = sides
+	b.pushState(&prompt.State{
+		List:        list,
+		SearchLabel: "Compare " + a.Name + "..." + branch.Name,
+	})
+	return nil
+}
+
+// comparisonFiles lists the files that changed between the tips
+func (b *branch) comparisonFiles(c *git.Comparison) error {
+	diff, err := c.Diff()
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if len(diff.Deltas()) == 0 {
+		b.prompt.SetMessage(term.Cprint("No changes between the tips.", color.Faint))
+		return nil
+	}
+	return b.listDeltas(diff.Deltas(), "Diff of "+c.A.Name+".."+c.B.Name)
+}
+
+// commitFiles lists the files that the commit changed
+func (b *branch) commitFiles(commit *git.Commit) error {
+	diff, err := commit.Diff()
+	if err != nil {
+		b.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if len(diff.Deltas()) == 0 {
+		return nil
+	}
+	return b.listDeltas(diff.Deltas(), "Diff of "+commit.Hash[:7])
+}
+
+func (b *branch) listDeltas(deltas []*git.DiffDelta, label string) error {
+	list, err := prompt.NewList(deltas, 5)
+	if err != nil {
+		return err
+	}
+	b.deltas = deltas
+	b.diffLabel = label
+	b.pushState(&prompt.State{
+		List:        list,
+		SearchLabel: "Files",
+	})
+	return nil
+}
+
+// pushState saves the current state to return to it on quit
+func (b *branch) pushState(state *prompt.State) {
+	b.states = append(b.states, b.prompt.State())
+	b.prompt.SetState(state)
+}
+
+// popState returns to the previous state, the comparison is dropped when
+// the branches are listed again
+func (b *branch) popState() {
+	if len(b.states) == 0 {
+		return
+	}
+	last := b.states[len(b.states)-1]
+	b.states = b.states[:len(b.states)-1]
+	if len(b.states) == 0 {
+		b.comparison = nil
+		b.sides = nil
+	}
+	b.prompt.SetState(last)
+}
+
+func (b *branch) comparisonInfo(item interface{}) [][]term.Cell {
+	c := b.comparison
+	if c == nil {
+		return nil
+	}
+	grid := make([][]term.Cell, 0)
+	switch i := item.(type) {
+	case *git.Comparison:
+		for _, side := range []struct {
+			marker string
+			branch *git.Branch
+			count  int
+		}{{sideA, c.A, len(c.OnlyA)}, {sideB, c.B, len(c.OnlyB)}} {
+			cells := term.Cprint(side.marker+" ", color.FgCyan)
+			cells = append(cells, term.Cprint(side.branch.Name, color.FgYellow)...)
+			cells = append(cells, term.Cprint(" has ", color.Faint)...)
+			cells = append(cells, term.Cprint(strconv.Itoa(side.count), color.FgWhite)...)
+			cells = append(cells, term.Cprint(" commit(s) that the other does not", color.Faint)...)
+			grid = append(grid, cells)
+		}
+	case *git.Commit:
+		switch b.sides[i] {
+		case sideA:
+			grid = append(grid, term.Cprint("Only in "+c.A.Name, color.Faint))
+		case sideB:
+			grid = append(grid, term.Cprint("Only in "+c.B.Name, color.Faint))
+		}
+		cells := term.Cprint("Author ", color.Faint)
+		cells = append(cells, term.Cprint(i.Author.Name+" <"+i.Author.Email+">", color.FgWhite)...)
+		grid = append(grid, cells)
+		cells = term.Cprint("When", color.Faint)
+		cells = append(cells, term.Cprint("   "+timeago.FromTime(i.Author.When), color.FgWhite)...)
+		grid = append(grid, cells)
+		return grid
+	case *git.DiffDelta:
+		grid = append(grid, term.Cprint(b.diffLabel, color.Faint))
+		return grid
+	}
+	if c.Base == nil {
+		grid = append(grid, term.Cprint("No merge base, the histories are unrelated", color.Faint))
+		return grid
+	}
+	cells := term.Cprint("Merge base ", color.Faint)
+	cells = append(cells, term.Cprint(c.Base.Hash[:7]+" ", color.FgYellow)...)
+	cells = append(cells, term.Cprint(c.Base.Summary, color.FgWhite)...)
+	grid = append(grid, cells)
+	return grid
+}
+
+// renderCompareItem prints the commits with the side they are in
+func (b *branch) renderCompareItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	lines := renderItem(item, matches, selected)
+	commit, ok := item.(*git.Commit)
+	if !ok {
+		return lines
+	}
+	if side, ok := b.sides[commit]; ok {
+		lines[0] = append(lines[0][:2:2], append(term.Cprint(side+" ", color.FgCyan), lines[0][2:]...)...)
+	}
+	return lines
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/highlight"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// showConflict lists the base, ours and theirs versions of the conflicted
+// entry so that one of them can be taken
+func (s *status) showConflict(entry *git.StatusEntry) error {
+	c, err := s.repository.Conflict(entry.String())
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	s.oldState = s.prompt.State()
+	list, err := prompt.NewList(c.Sides(), s.oldState.ListSize)
+	if err != nil {
+		return err
+	}
+	s.conflict = c
+	s.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: "Conflict of " + c.Path,
+	})
+	return nil
+}
+
+// showConflictSide opens the version of the conflicted file in the pane
+func (s *status) showConflictSide(side *git.ConflictSide) error {
+	content, err := s.repository.ConflictContent(side)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	hl := highlight.New(side.Path)
+	numWidth := len(fmt.Sprint(len(lines)))
+	s.prompt.ShowPane(side.Name+" of "+side.Path, func(width int) []*prompt.PaneLine {
+		hl.Reset()
+		pane := make([]*prompt.PaneLine, 0, len(lines))
+		for i, line := range lines {
+			cells := term.Cprint(fmt.Sprintf("%*d ", numWidth, i+1), color.Faint)
+			for _, c := range highlight.Paint(hl.Line(line), color.FgWhite, 0) {
+				if c.Ch == '\t' {
+					cells = append(cells, term.Cprint(strings.Repeat(" ", tabWidth))...)
+					continue
+				}
+				cells = append(cells, c)
+			}
+			pane = append(pane, &prompt.PaneLine{Cells: cells})
+		}
+		return pane
+	}, 0)
+	return nil
+}
+
+// conflictOf returns the conflict of the item, it is loaded from the index
+// if the item is a status entry
+func (s *status) conflictOf(item interface{}) (*git.Conflict, error) {
+	switch i := item.(type) {
+	case *git.ConflictSide:
+		return i.Conflict, nil
+	case *git.StatusEntry:
+		return s.repository.Conflict(i.String())
+	}
+	return nil, nil
+}
+
+func (s *status) takeOurs(item interface{}) error {
+	return s.takeSide(item, func(c *git.Conflict) *git.ConflictSide { return c.Ours })
+}
+
+func (s *status) takeTheirs(item interface{}) error {
+	return s.takeSide(item, func(c *git.Conflict) *git.ConflictSide { return c.Theirs })
+}
+
+// takeSide resolves the conflict with a version of the file, the file is
+// deleted if that side deletes it
+func (s *status) takeSide(item interface{}, side func(*git.Conflict) *git.ConflictSide) error {
+	c, err := s.conflictOf(item)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if c == nil {
+		return nil
+	}
+	return s.leaveConflict(s.repository.ResolveConflict(c, side(c)))
+}
+
+// mergeEditor opens "git mergetool" for the conflicted file
+func (s *status) mergeEditor(item interface{}) error {
+	c, err := s.conflictOf(item)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if c == nil {
+		return nil
+	}
+	args := []string{"mergetool", "--", c.Path}
+	return s.leaveConflict(popGitCommand(s.repository, args))
+}
+
+// markResolved adds the conflicted file as it is in the working tree
+func (s *status) markResolved(item interface{}) error {
+	c, err := s.conflictOf(item)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	if c == nil {
+		return nil
+	}
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		return err
+	}
+	for _, e := range st.Entities {
+		if e.String() == c.Path && e.EntryType == git.StatusEntryTypeConflicted {
+			return s.leaveConflict(s.repository.MarkResolved(e))
+		}
+	}
+	return s.leaveConflict(git.ErrEntryNotConflicted)
+}
+
+// leaveConflict returns to the status entries and shows the error if any
+func (s *status) leaveConflict(err error) error {
+	if s.conflict != nil {
+		s.conflict = nil
+		s.prompt.SetState(s.oldState)
+	}
+	return s.reloadWithError(err)
+}
+
+// continueOperation continues the merge, rebase, cherry-pick or revert after
+// the conflicts are resolved
+func (s *status) continueOperation(item interface{}) error {
+	return s.runOperation("--continue")
+}
+
+// abortOperation stops the operation in progress and restores the branch
+func (s *status) abortOperation(item interface{}) error {
+	return s.runOperation("--abort")
+}
+
+func (s *status) runOperation(arg string) error {
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		return err
+	}
+	cmd := operationCommand(st.State)
+	if len(cmd) == 0 {
+		s.prompt.SetMessage(term.Cprint("no operation in progress", color.Faint))
+		return nil
+	}
+	if err := popGitCommand(s.repository, []string{cmd, arg}); err != nil {
+		s.prompt.SetMessage(errorText(fmt.Errorf("failed to %s %s: %v", cmd, arg[2:], err)))
+	}
+	return s.reloadStatus()
+}
+
+// operationCommand returns the git command of the operation in progress
+func operationCommand(state git.State) string {
+	switch state {
+	case git.StateMerge:
+		return "merge"
+	case git.StateRevert:
+		return "revert"
+	case git.StateCherrypick:
+		return "cherry-pick"
+	case git.StateRebase, git.StateRebaseInteractive, git.StateRebaseMerge, git.StateApplyMailboxOrRebase:
+		return "rebase"
+	case git.StateApplyMailbox:
+		return "am"
+	}
+	return ""
+}
+
+// OperationStopped returns true if the state changed to an operation that is
+// continued in the status, it is false for the operations that were already
+// in progress and for bisecting
+func OperationStopped(before, after git.State) bool {
+	return after != before && len(operationCommand(after)) > 0
+}
+
+// stateInfo describes the operation in progress e.g. "Rebasing 3/7"
+func stateInfo(r *git.Repository, state git.State) [][]term.Cell {
+	if !state.InProgress() {
+		return nil
+	}
+	cells := term.Cprint(state.String(), color.FgYellow)
+	if step, total := r.RebaseProgress(); total > 0 {
+		cells = append(cells, term.Cprint(fmt.Sprintf(" %d/%d", step, total), color.FgYellow)...)
+	}
+	if len(operationCommand(state)) > 0 {
+		cells = append(cells, term.Cprint(" (\"C\" to continue, \"A\" to abort)", color.Faint)...)
+	}
+	return [][]term.Cell{cells}
+}
+
+func (s *status) conflictInfo(side *git.ConflictSide) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Version ", color.Faint)
+	cells = append(cells, term.Cprint(side.Name, color.FgYellow)...)
+	cells = append(cells, term.Cprint(" "+side.Hash[:7], color.Faint)...)
+	grid = append(grid, cells)
+	if side.Path != side.Conflict.Path {
+		grid = append(grid, term.Cprint("Path "+side.Path, color.Faint))
+	}
+	missing := make([]string, 0)
+	if side.Conflict.Ours == nil {
+		missing = append(missing, "deleted by us")
+	}
+	if side.Conflict.Theirs == nil {
+		missing = append(missing, "deleted by them")
+	}
+	if side.Conflict.Ancestor == nil {
+		missing = append(missing, "added by both")
+	}
+	if len(missing) > 0 {
+		grid = append(grid, term.Cprint(strings.Join(missing, ", "), color.FgRed))
+	}
+	return grid
+}
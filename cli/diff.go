@@ -0,0 +1,400 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/highlight"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// tabs are expanded to spaces in the diff pane
+const tabWidth = 4
+
+// diffFile is a file shown in the diff pane
+type diffFile struct {
+	name   string // path of the file for the syntax highlighting
+	path   string
+	status string
+	header []string // shown if the file has no hunks e.g. binary files
+	hunks  []*git.Hunk
+}
+
+func deltaFile(d *git.DiffDelta) *diffFile {
+	header, hunks := git.ParsePatch(d.Patch)
+	path := d.NewFile.Path
+	if d.OldFile.Path != d.NewFile.Path && len(d.OldFile.Path) > 0 {
+		path = d.OldFile.Path + " → " + d.NewFile.Path
+	}
+	return &diffFile{
+		name:   d.NewFile.Path,
+		path:   path,
+		status: d.DeltaStatusString(),
+		header: header,
+		hunks:  hunks,
+	}
+}
+
+func entryFile(r *git.Repository, e *git.StatusEntry) *diffFile {
+	f := &diffFile{
+		name:   e.String(),
+		path:   e.String(),
+		status: e.StatusEntryString(),
+	}
+	if e.Indexed() {
+		f.status += " (staged)"
+	}
+	patch, err := r.LoadPatch(e)
+	if err != nil {
+		f.header = []string{err.Error()}
+		return f
+	}
+	f.hunks = patch.Hunks
+	return f
+}
+
+// showDeltas opens the diff pane with the deltas, scrolled to the selected one
+func showDeltas(p *prompt.Prompt, label string, deltas []*git.DiffDelta, selected *git.DiffDelta) {
+	files := make([]*diffFile, 0, len(deltas))
+	index := 0
+	for i, d := range deltas {
+		if d == selected {
+			index = i
+		}
+		files = append(files, deltaFile(d))
+	}
+	showDiff(p, label, nil, files, index)
+}
+
+// showDiff opens the diff pane with the files scrolled to the nth one, the
+// preface is shown before the first file
+func showDiff(p *prompt.Prompt, label string, preface [][]term.Cell, files []*diffFile, index int) {
+	v := &diffView{preface: preface, files: files}
+	p.ShowPane(label, v.render, index, &prompt.KeyBinding{
+		Key:     's',
+		Display: "s",
+		Desc:    "toggle side-by-side view",
+		Handler: func(interface{}) error {
+			v.toggle()
+			p.RefreshPane()
+			return nil
+		},
+	})
+}
+
+// diffLayout is the way the hunks are rendered
+type diffLayout uint8
+
+// the layout is decided by the width of the terminal unless it is toggled
+const (
+	layoutAuto diffLayout = iota
+	layoutUnified
+	layoutSplit
+)
+
+// minimum terminal width to show a side-by-side diff by default
+const splitDiffWidth = 160
+
+// diffView renders the files as a unified or a side-by-side diff with line
+// numbers
+type diffView struct {
+	preface [][]term.Cell
+	files   []*diffFile
+	layout  diffLayout
+	split   bool // whether the last render was side-by-side
+}
+
+func (v *diffView) render(width int) []*prompt.PaneLine {
+	switch v.layout {
+	case layoutAuto:
+		v.split = width >= splitDiffWidth
+	default:
+		v.split = v.layout == layoutSplit
+	}
+	lines := make([]*prompt.PaneLine, 0)
+	for _, cells := range v.preface {
+		lines = append(lines, &prompt.PaneLine{Cells: cells})
+	}
+	for _, f := range v.files {
+		lines = append(lines, fileLines(f, width, v.split)...)
+	}
+	return lines
+}
+
+// toggle switches between the unified and the side-by-side view
+func (v *diffView) toggle() {
+	if v.split {
+		v.layout = layoutUnified
+	} else {
+		v.layout = layoutSplit
+	}
+}
+
+func fileLines(f *diffFile, width int, split bool) []*prompt.PaneLine {
+	lines := make([]*prompt.PaneLine, 0)
+	lines = append(lines, &prompt.PaneLine{
+		Cells:  fileHeading(f, width),
+		Anchor: prompt.AnchorFile,
+	})
+	if len(f.hunks) == 0 {
+		for _, line := range f.header {
+			if isPatchPreamble(line) {
+				continue
+			}
+			lines = append(lines, &prompt.PaneLine{Cells: term.Cprint(line, color.Faint)})
+		}
+		return lines
+	}
+	numWidth := lineNumberWidth(f.hunks)
+	hl := newHighlighters(f.name)
+	for _, h := range f.hunks {
+		lines = append(lines, &prompt.PaneLine{
+			Cells:  term.Cprint(h.Header, color.FgCyan),
+			Anchor: prompt.AnchorHunk,
+		})
+		hl.reset()
+		if split {
+			for _, pair := range git.PairLines(h) {
+				lines = append(lines, &prompt.PaneLine{Cells: splitLine(pair, hl, numWidth, width)})
+			}
+			continue
+		}
+		for _, l := range h.Lines {
+			cells := lineNumbers(l, numWidth)
+			cells = append(cells, diffLineText(l, hl)...)
+			lines = append(lines, &prompt.PaneLine{Cells: cells})
+		}
+	}
+	return lines
+}
+
+// highlighters keeps the syntax state of the old and the new side of a file
+type highlighters struct {
+	old *highlight.Highlighter
+	new *highlight.Highlighter
+}
+
+func newHighlighters(path string) *highlighters {
+	return &highlighters{old: highlight.New(path), new: highlight.New(path)}
+}
+
+// reset is called at the start of a hunk since the lines in between are not
+// known
+func (hl *highlighters) reset() {
+	hl.old.Reset()
+	hl.new.Reset()
+}
+
+// line highlights the line on its side, context lines are on both sides
+func (hl *highlighters) line(l *git.DiffLine) []term.Cell {
+	switch l.Type {
+	case git.DiffLineDeletion:
+		return hl.old.Line(l.String())
+	case git.DiffLineAddition:
+		return hl.new.Line(l.String())
+	}
+	hl.old.Line(l.String())
+	return hl.new.Line(l.String())
+}
+
+func (hl *highlighters) enabled() bool {
+	return hl.new != nil
+}
+
+// splitLine renders the old and the new side of the pair in two columns, the
+// changed words of a modified line are highlighted
+func splitLine(pair *git.LinePair, hl *highlighters, numWidth, width int) []term.Cell {
+	var oldMask, newMask []bool
+	var oldCells, newCells []term.Cell
+	switch {
+	case pair.Changed():
+		oldMask, newMask = git.WordDiff(pair.Old.String(), pair.New.String())
+		oldCells, newCells = hl.line(pair.Old), hl.line(pair.New)
+	case pair.Old == pair.New:
+		oldCells = hl.line(pair.Old)
+		newCells = oldCells
+	case pair.Old != nil:
+		oldCells = hl.line(pair.Old)
+	default:
+		newCells = hl.line(pair.New)
+	}
+	column := (width - 1) / 2
+	cells := term.Fit(sideText(pair.Old, oldCells, true, numWidth, oldMask, hl.enabled()), column)
+	cells = append(cells, term.Cprint("│", color.Faint)...)
+	return append(cells, term.Fit(sideText(pair.New, newCells, false, numWidth, newMask, hl.enabled()), width-column-1)...)
+}
+
+// sideText renders a line of a side-by-side diff with its line number on the
+// old or the new side
+func sideText(l *git.DiffLine, content []term.Cell, old bool, numWidth int, mask []bool, highlighted bool) []term.Cell {
+	if l == nil {
+		return term.Cprint(strings.Repeat(" ", numWidth+1), color.Faint)
+	}
+	number := l.NewLineno
+	if old {
+		number = l.OldLineno
+	}
+	cells := term.Cprint(fmt.Sprintf("%*d ", numWidth, number), color.Faint)
+	return append(cells, diffText(l, content, mask, highlighted)...)
+}
+
+func fileHeading(f *diffFile, width int) []term.Cell {
+	cells := term.Cprint("── ", color.Faint)
+	cells = append(cells, term.Cprint(f.status+" ", color.FgYellow, color.Bold)...)
+	cells = append(cells, term.Cprint(f.path+" ", color.FgWhite, color.Bold)...)
+	if rest := width - term.Width(cells); rest > 0 {
+		cells = append(cells, term.Cprint(strings.Repeat("─", rest), color.Faint)...)
+	}
+	return cells
+}
+
+// isPatchPreamble returns true for the lines that are already shown in the
+// file heading
+func isPatchPreamble(line string) bool {
+	for _, prefix := range []string{"diff --git ", "index ", "--- ", "+++ "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineNumberWidth returns the number of digits of the largest line number
+func lineNumberWidth(hunks []*git.Hunk) int {
+	max := 0
+	for _, h := range hunks {
+		if end := h.OldStart + h.OldLines; end > max {
+			max = end
+		}
+		if end := h.NewStart + h.NewLines; end > max {
+			max = end
+		}
+	}
+	return len(strconv.Itoa(max))
+}
+
+func lineNumbers(l *git.DiffLine, width int) []term.Cell {
+	number := func(n int) string {
+		if n < 0 {
+			return strings.Repeat(" ", width)
+		}
+		return fmt.Sprintf("%*d", width, n)
+	}
+	cells := term.Cprint(number(l.OldLineno)+" "+number(l.NewLineno), color.Faint)
+	return append(cells, term.Cprint(" │", color.Faint)...)
+}
+
+func diffLineText(l *git.DiffLine, hl *highlighters) []term.Cell {
+	return diffText(l, hl.line(l), nil, hl.enabled())
+}
+
+// diffText renders the marker and the content of the line. The changed lines
+// of the highlighted content have a background so that the syntax colors can
+// be seen, otherwise the text is colored. Runes set in the mask are the
+// changed words of the line.
+func diffText(l *git.DiffLine, content []term.Cell, mask []bool, highlighted bool) []term.Cell {
+	fg, bg, wordBg, marker := color.FgWhite, color.Attribute(0), color.Attribute(0), " "
+	switch l.Type {
+	case git.DiffLineAddition:
+		fg, wordBg, marker = color.FgGreen, color.BgGreen, "+"
+		if highlighted {
+			fg, bg, wordBg = color.FgWhite, color.BgGreen, color.BgHiGreen
+		}
+	case git.DiffLineDeletion:
+		fg, wordBg, marker = color.FgRed, color.BgRed, "-"
+		if highlighted {
+			fg, bg, wordBg = color.FgWhite, color.BgRed, color.BgHiRed
+		}
+	}
+	cells := highlight.Paint(term.Cprint(marker), fg, bg)
+	for i, c := range content {
+		background := bg
+		if i < len(mask) && mask[i] {
+			background = wordBg
+		}
+		painted := highlight.Paint([]term.Cell{c}, fg, background)[0]
+		if c.Ch == '\t' {
+			for j := 0; j < tabWidth; j++ {
+				cells = append(cells, term.Cell{Ch: ' ', Attr: painted.Attr})
+			}
+			continue
+		}
+		cells = append(cells, painted)
+	}
+	return cells
+}
+
+// expandTabs replaces the tabs with spaces
+func expandTabs(s string) string {
+	return strings.Replace(s, "\t", strings.Repeat(" ", tabWidth), -1)
+}
+
+// expandMatches maps the rune indexes of the matches in s to the indexes of
+// the same runes in expandTabs(s), a matched tab covers all of its spaces
+func expandMatches(s string, matches []int) []int {
+	if len(matches) == 0 {
+		return matches
+	}
+	offsets := make([]int, 0, len(s))
+	offset := 0
+	for _, r := range s {
+		offsets = append(offsets, offset)
+		if r == '\t' {
+			offset += tabWidth
+		} else {
+			offset++
+		}
+	}
+	expanded := make([]int, 0, len(matches))
+	for _, m := range matches {
+		if m < 0 || m >= len(offsets) {
+			continue
+		}
+		end := offset
+		if m+1 < len(offsets) {
+			end = offsets[m+1]
+		}
+		for i := offsets[m]; i < end; i++ {
+			expanded = append(expanded, i)
+		}
+	}
+	return expanded
+}
+
+// commitPreface renders the commit like the header of "git show"
+func commitPreface(c *git.Commit) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	grid = append(grid, term.Cprint("commit "+c.Hash, color.FgYellow))
+	grid = append(grid, term.Cprint("Author: "+c.Author.Name+" <"+c.Author.Email+">", color.FgWhite))
+	grid = append(grid, term.Cprint("Date:   "+c.Author.When.Format("Mon Jan 2 15:04:05 2006 -0700"), color.FgWhite))
+	grid = append(grid, nil)
+	for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+		grid = append(grid, term.Cprint("    "+expandTabs(line), color.FgWhite))
+	}
+	grid = append(grid, nil)
+	return grid
+}
+
+// statText colors the diffstat graph
+func statText(line string) []term.Cell {
+	i := strings.LastIndex(line, "|")
+	if i < 0 {
+		return term.Cprint(line, color.Faint)
+	}
+	cells := term.Cprint(line[:i+1], color.FgWhite)
+	for _, ch := range line[i+1:] {
+		switch ch {
+		case '+':
+			cells = append(cells, term.Cell{Ch: ch, Attr: []color.Attribute{color.FgGreen}})
+		case '-':
+			cells = append(cells, term.Cell{Ch: ch, Attr: []color.Attribute{color.FgRed}})
+		default:
+			cells = append(cells, term.Cell{Ch: ch})
+		}
+	}
+	return cells
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// history lists the commits that changed a file. It is either a prompt of
+// its own or shown in another prompt until it is closed.
+type history struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	path       string
+	oldState   *prompt.State // state to return to, nil if the history is the prompt
+}
+
+// HistoryPrompt configures a prompt to list the commits that changed the file
+func HistoryPrompt(r *git.Repository, opts *prompt.Options, path string) (*prompt.Prompt, error) {
+	list, err := historyList(r, "", path, opts.LineSize)
+	if err != nil {
+		return nil, err
+	}
+	h := &history{repository: r, path: path}
+	h.prompt = prompt.Create("History of "+path, opts, list,
+		prompt.WithSelectionHandler(h.onSelect),
+		prompt.WithItemRenderer(renderItem),
+		prompt.WithInformation(h.info),
+	)
+	if err := h.prompt.AddKeyBinding(&prompt.KeyBinding{
+		Key:     'q',
+		Display: "q",
+		Desc:    "quit",
+		Handler: h.quit,
+	}); err != nil {
+		return nil, err
+	}
+	return h.prompt, nil
+}
+
+// showHistory lists the commits that changed the file, starting from the
+// revision, in the prompt until the history is closed
+func showHistory(p *prompt.Prompt, r *git.Repository, rev, path string) (*history, error) {
+	h := &history{repository: r, prompt: p, path: path, oldState: p.State()}
+	list, err := historyList(r, rev, path, h.oldState.ListSize)
+	if err != nil {
+		return nil, err
+	}
+	p.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: "History of " + path,
+	})
+	return h, nil
+}
+
+func historyList(r *git.Repository, rev, path string, size int) (prompt.List, error) {
+	revisions, err := r.FileHistory(rev, path, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not load history: %v", err)
+	}
+	items := make(chan interface{})
+	go func() {
+		for rev := range revisions {
+			items <- rev
+		}
+		close(items)
+	}()
+	list, err := prompt.NewAsyncList(items, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	return list, nil
+}
+
+func (h *history) onSelect(item interface{}) error {
+	rev, ok := item.(*git.FileRevision)
+	if !ok {
+		return nil
+	}
+	showDeltas(h.prompt, rev.Commit.Hash[:7]+" "+rev.Path, []*git.DiffDelta{rev.Delta}, rev.Delta)
+	return nil
+}
+
+func (h *history) info(item interface{}) [][]term.Cell {
+	rev, ok := item.(*git.FileRevision)
+	if !ok {
+		return nil
+	}
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Author ", color.Faint)
+	cells = append(cells, term.Cprint(rev.Commit.Author.Name, color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("When", color.Faint)
+	cells = append(cells, term.Cprint("   "+timeago.FromTime(rev.Commit.Author.When), color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint(rev.Delta.DeltaStatusString()+" ", color.Faint)
+	if rev.Renamed() {
+		cells = append(cells, term.Cprint(rev.Delta.OldFile.Path+" → ", color.FgWhite)...)
+	}
+	cells = append(cells, term.Cprint(rev.Path, color.FgYellow)...)
+	grid = append(grid, cells)
+	return grid
+}
+
+// quit returns to the state before the history or stops the prompt if the
+// history is the prompt itself
+func (h *history) quit(item interface{}) error {
+	if h.oldState == nil {
+		h.prompt.Stop()
+		return nil
+	}
+	h.prompt.SetState(h.oldState)
+	return nil
+}
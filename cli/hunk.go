@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// maximum number of hunk lines shown below the hunk list
+const hunkPreviewSize = 10
+
+// hunkStageEntry lists the hunks of the entry to stage or unstage them
+func (s *status) hunkStageEntry(item interface{}) error {
+	entry, ok := item.(*git.StatusEntry)
+	if !ok {
+		return nil
+	}
+	patch, err := s.repository.LoadPatch(entry)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	s.patch = patch
+	s.oldState = s.prompt.State()
+	return s.showHunks(0, 0)
+}
+
+func (s *status) showHunks(cursor, scroll int) error {
+	list, err := prompt.NewList(s.patch.Hunks, s.oldState.ListSize)
+	if err != nil {
+		return err
+	}
+	label := "Unstaged hunks of " + s.patch.Path
+	if s.patch.Staged {
+		label = "Staged hunks of " + s.patch.Path
+	}
+	s.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: label,
+		Cursor:      cursor,
+		Scroll:      scroll,
+	})
+	return nil
+}
+
+// showLines lists the lines of the hunk so that they can be marked
+func (s *status) showLines(h *git.Hunk) error {
+	list, err := prompt.NewList(h.Lines, s.oldState.ListSize)
+	if err != nil {
+		return err
+	}
+	s.hunk = h
+	s.marked = make(map[*git.DiffLine]bool)
+	s.hunkState = s.prompt.State()
+	s.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: "Lines of " + h.Header,
+	})
+	return nil
+}
+
+// stageLines stages the lines of an unstaged patch or unstages the lines of
+// a staged patch, then the patch is reloaded
+func (s *status) stageLines(lines []*git.DiffLine) error {
+	var err error
+	if s.patch.Staged {
+		err = s.repository.UnstageLines(s.patch, lines)
+	} else {
+		err = s.repository.StageLines(s.patch, lines)
+	}
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return s.reloadPatch()
+}
+
+// markLine marks or unmarks a changed line
+func (s *status) markLine(l *git.DiffLine) error {
+	if l.Type == git.DiffLineContext {
+		return nil
+	}
+	s.marked[l] = !s.marked[l]
+	return nil
+}
+
+// stageMarkedLines stages the marked lines, or the line if none is marked
+func (s *status) stageMarkedLines(l *git.DiffLine) error {
+	lines := make([]*git.DiffLine, 0)
+	for _, line := range s.hunk.Lines {
+		if s.marked[line] {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		if l.Type == git.DiffLineContext {
+			return nil
+		}
+		lines = append(lines, l)
+	}
+	return s.stageLines(lines)
+}
+
+func (s *status) splitHunk(h *git.Hunk) error {
+	if !s.patch.SplitHunk(h) {
+		s.prompt.SetMessage(term.Cprint("Sorry, cannot split this hunk.", color.Faint))
+		return nil
+	}
+	state := s.prompt.State()
+	return s.showHunks(state.Cursor, state.Scroll)
+}
+
+// reloadPatch loads the patch of the same file again since the hunks are
+// changed, the status is shown if there is nothing left to stage
+func (s *status) reloadPatch() error {
+	state := s.prompt.State()
+	if s.hunk != nil {
+		state = s.hunkState
+		s.hunk = nil
+	}
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		return err
+	}
+	for _, e := range st.Entities {
+		if e.String() != s.patch.Path || e.Indexed() != s.patch.Staged {
+			continue
+		}
+		patch, err := s.repository.LoadPatch(e)
+		if err != nil {
+			break
+		}
+		s.patch = patch
+		return s.showHunks(state.Cursor, state.Scroll)
+	}
+	return s.leaveHunks()
+}
+
+// leaveHunks returns to the status entries
+func (s *status) leaveHunks() error {
+	s.patch = nil
+	s.prompt.SetState(s.oldState)
+	return s.reloadStatus()
+}
+
+func (s *status) hunkInfo(h *git.Hunk) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	hl := newHighlighters(s.patch.Path)
+	for i, l := range h.Lines {
+		if i == hunkPreviewSize {
+			more := fmt.Sprintf("... %d more lines", len(h.Lines)-hunkPreviewSize)
+			grid = append(grid, term.Cprint(more, color.Faint))
+			break
+		}
+		grid = append(grid, diffLineText(l, hl))
+	}
+	return grid
+}
+
+func (s *status) lineInfo(l *git.DiffLine) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Line ", color.Faint)
+	switch l.Type {
+	case git.DiffLineAddition:
+		cells = append(cells, term.Cprint(fmt.Sprintf("+%d", l.NewLineno), color.FgGreen)...)
+	case git.DiffLineDeletion:
+		cells = append(cells, term.Cprint(fmt.Sprintf("-%d", l.OldLineno), color.FgRed)...)
+	default:
+		cells = append(cells, term.Cprint(fmt.Sprintf("%d", l.NewLineno), color.FgWhite)...)
+	}
+	grid = append(grid, cells)
+	action := "stage"
+	if s.patch.Staged {
+		action = "unstage"
+	}
+	marked := 0
+	for _, m := range s.marked {
+		if m {
+			marked++
+		}
+	}
+	if marked == 0 {
+		grid = append(grid, term.Cprint("space to mark lines, enter to "+action+" this line", color.Faint))
+	} else {
+		grid = append(grid, term.Cprint(fmt.Sprintf("%d line(s) marked, enter to %s them", marked, action), color.Faint))
+	}
+	return grid
+}
+
+// renderItem adds the marks to the hunk lines
+func (s *status) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	l, ok := item.(*git.DiffLine)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	switch {
+	case l.Type == git.DiffLineContext:
+		line = append(line, term.Cprint("    ", color.FgWhite)...)
+	case s.marked[l]:
+		line = append(line, stautsText("x")...)
+	default:
+		line = append(line, stautsText(" ")...)
+	}
+	line = append(line, diffLineText(l, newHighlighters(s.patch.Path))...)
+	return [][]term.Cell{line}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 	"github.com/isacikgoz/gitin/git"
@@ -18,34 +19,33 @@ type log struct {
 	repository *git.Repository
 	prompt     *prompt.Prompt
 	selected   *git.Commit
+	deltas     []*git.DiffDelta
 	oldState   *prompt.State
+	options    *git.LogOptions
+	history    *history // set while the history of a file is listed
+
+	marked map[*git.Commit]bool // commits to cherry-pick or revert
+
+	mu    sync.RWMutex // guards the graph rows and the order written while loading
+	graph map[*git.Commit][]git.GraphRow
+	order map[*git.Commit]int // topological order of the commits, newest first
+	err   error               // the error that stopped loading the commits
 }
 
-// LogPrompt configures a prompt to serve as a commit prompt
-func LogPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
-	commits, err := r.CommitsChan(0)
-	if err != nil {
-		return nil, fmt.Errorf("could not load commits: %v", err)
-	}
+// LogPrompt configures a prompt to serve as a commit prompt, the commits are
+// selected by the log options
+func LogPrompt(r *git.Repository, opts *prompt.Options, logOpts *git.LogOptions) (*prompt.Prompt, error) {
 	r.Branches() // to find refs
 	r.Tags()
-	items := make(chan interface{})
-	go func() {
-		for c := range commits {
-			items <- c
-		}
-		close(items)
-	}()
-
-	list, err := prompt.NewAsyncList(items, opts.LineSize)
+	l := &log{repository: r, options: logOpts, marked: make(map[*git.Commit]bool)}
+	list, err := l.commitList(opts.LineSize)
 	if err != nil {
-		return nil, fmt.Errorf("could not create list: %v", err)
+		return nil, err
 	}
 
-	l := &log{repository: r}
 	l.prompt = prompt.Create("Commits", opts, list,
 		prompt.WithSelectionHandler(l.onSelect),
-		prompt.WithItemRenderer(renderItem),
+		prompt.WithItemRenderer(l.renderItem),
 		prompt.WithInformation(l.logInfo),
 	)
 	if err := l.defineKeybindings(); err != nil {
@@ -55,6 +55,54 @@ func LogPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error)
 	return l.prompt, nil
 }
 
+// commitList loads the commits in the background, the graph is drawn while
+// they are loaded
+func (l *log) commitList(size int) (prompt.List, error) {
+	commits, errs, err := l.repository.Log(l.options, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not load commits: %v", err)
+	}
+	l.mu.Lock()
+	l.graph = make(map[*git.Commit][]git.GraphRow)
+	l.order = make(map[*git.Commit]int)
+	l.err = nil
+	l.mu.Unlock()
+	items := make(chan interface{})
+	go func() {
+		graph := git.NewGraph()
+		n := 0
+		for c := range commits {
+			var rows []git.GraphRow
+			if !l.options.Filtered() {
+				parents := c.ParentHashes()
+				if l.options.FirstParent && len(parents) > 1 {
+					parents = parents[:1]
+				}
+				rows = graph.AddHash(c.Hash, parents)
+			}
+			l.mu.Lock()
+			l.graph[c] = rows
+			l.order[c] = n
+			l.mu.Unlock()
+			n++
+			items <- c
+		}
+		// the prompt may not be running yet, so the error is shown by the info
+		if err := <-errs; err != nil {
+			l.mu.Lock()
+			l.err = err
+			l.mu.Unlock()
+		}
+		close(items)
+	}()
+
+	list, err := prompt.NewAsyncList(items, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	return list, nil
+}
+
 // return true to terminate
 func (l *log) onSelect(item interface{}) error {
 	switch item.(type) {
@@ -69,6 +117,7 @@ func (l *log) onSelect(item interface{}) error {
 		if len(deltas) <= 0 {
 			return nil
 		}
+		l.deltas = deltas
 
 		l.oldState = l.prompt.State()
 		list, err := prompt.NewList(deltas, 5)
@@ -85,18 +134,9 @@ func (l *log) onSelect(item interface{}) error {
 		if l.selected == nil {
 			return nil
 		}
-		var args []string
-		pid, err := l.selected.ParentID()
-		if err != nil {
-			args = []string{"show", "--oneline", "--patch"}
-		} else {
-			args = []string{"diff", pid + ".." + l.selected.Hash}
-		}
-		dd := item.(*git.DiffDelta)
-		args = append(args, dd.OldFile.Path)
-		if err := popGitCommand(l.repository, args); err != nil {
-			//no err handling required here
-		}
+		showDeltas(l.prompt, "Diff of "+l.selected.Hash[:7], l.deltas, item.(*git.DiffDelta))
+	case *git.FileRevision:
+		return l.history.onSelect(item)
 	}
 	return nil
 }
@@ -106,8 +146,17 @@ func (l *log) commitStat(item interface{}) error {
 	if !ok {
 		return nil
 	}
-	args := []string{"show", "--stat", commit.Hash}
-	return popGitCommand(l.repository, args)
+	diff, err := commit.Diff()
+	if err != nil {
+		l.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	preface := commitPreface(commit)
+	for _, line := range diff.Stats() {
+		preface = append(preface, statText(line))
+	}
+	l.prompt.ShowPane("Stat of "+commit.Hash[:7], (&diffView{preface: preface}).render, 0)
+	return nil
 }
 
 func (l *log) commitDiff(item interface{}) error {
@@ -115,8 +164,17 @@ func (l *log) commitDiff(item interface{}) error {
 	if !ok {
 		return nil
 	}
-	args := []string{"show", commit.Hash}
-	return popGitCommand(l.repository, args)
+	diff, err := commit.Diff()
+	if err != nil {
+		l.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0)
+	for _, d := range diff.Deltas() {
+		files = append(files, deltaFile(d))
+	}
+	showDiff(l.prompt, "Diff of "+commit.Hash[:7], commitPreface(commit), files, 0)
+	return nil
 }
 
 func (l *log) quit(item interface{}) error {
@@ -125,12 +183,37 @@ func (l *log) quit(item interface{}) error {
 		l.prompt.Stop()
 	case *git.DiffDelta:
 		l.prompt.SetState(l.oldState)
+	case *git.FileRevision:
+		err := l.history.quit(item)
+		l.history = nil
+		return err
 	}
 	return nil
 }
 
+// fileHistory lists the commits that changed the file of the delta, starting
+// from the selected commit
+func (l *log) fileHistory(item interface{}) error {
+	delta, ok := item.(*git.DiffDelta)
+	if !ok || l.selected == nil {
+		return nil
+	}
+	h, err := showHistory(l.prompt, l.repository, l.selected.Hash, delta.NewFile.Path)
+	if err != nil {
+		l.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	l.history = h
+	return nil
+}
+
 func (l *log) logInfo(item interface{}) [][]term.Cell {
 	grid := make([][]term.Cell, 0)
+	l.mu.RLock()
+	if l.err != nil {
+		grid = append(grid, errorText(fmt.Errorf("could not load all commits: %v", l.err)))
+	}
+	l.mu.RUnlock()
 	if item == nil {
 		return grid
 	}
@@ -174,6 +257,8 @@ func (l *log) logInfo(item interface{}) [][]term.Cell {
 			cells = append(cells, term.Cell{Ch: '.', Attr: []color.Attribute{color.Faint}})
 		}
 		grid = append(grid, cells)
+	case *git.FileRevision:
+		return l.history.info(item)
 	}
 	return grid
 }
@@ -192,6 +277,30 @@ func (l *log) defineKeybindings() error {
 			Desc:    "show diff",
 			Handler: l.commitDiff,
 		},
+		&prompt.KeyBinding{
+			Key:     'H',
+			Display: "H",
+			Desc:    "history of file",
+			Handler: l.fileHistory,
+		},
+		&prompt.KeyBinding{
+			Key:     ' ',
+			Display: "space",
+			Desc:    "mark commit",
+			Handler: l.markCommit,
+		},
+		&prompt.KeyBinding{
+			Key:     'c',
+			Display: "c",
+			Desc:    "cherry-pick commit(s)",
+			Handler: l.cherryPick,
+		},
+		&prompt.KeyBinding{
+			Key:     'r',
+			Display: "r",
+			Desc:    "revert commit(s)",
+			Handler: l.revert,
+		},
 		&prompt.KeyBinding{
 			Key:     'q',
 			Display: "q",
@@ -234,3 +343,62 @@ func commitRefs(r *git.Repository, c *git.Commit) []term.Cell {
 	}
 	return cells
 }
+
+// colors of the graph lanes
+var laneColors = []color.Attribute{
+	color.FgRed, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta, color.FgCyan,
+}
+
+// renderItem draws the commit graph to the left of the hash, the graph is
+// only drawn if the list is not filtered since the lanes would be broken
+func (l *log) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	c, ok := item.(*git.Commit)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	lines := l.renderCommit(c, matches, selected)
+	if l.marked[c] {
+		lines[0][1] = term.Cell{Ch: '*', Attr: []color.Attribute{color.FgYellow}}
+	}
+	return lines
+}
+
+func (l *log) renderCommit(c *git.Commit, matches []int, selected bool) [][]term.Cell {
+	if len(l.prompt.State().SearchStr) > 0 || l.options.Filtered() {
+		return renderItem(c, matches, selected)
+	}
+	l.mu.RLock()
+	rows := l.graph[c]
+	l.mu.RUnlock()
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	if len(rows) > 0 {
+		line = append(line, graphCells(rows[0])...)
+		line = append(line, term.Cell{Ch: ' '})
+	}
+	line = append(line, stautsText(c.Hash[:7])...)
+	line = append(line, highLightedText(matches, color.FgWhite, c.String())...)
+	lines := [][]term.Cell{line}
+	// the connector rows are drawn below the commit
+	for i := 1; i < len(rows); i++ {
+		lines = append(lines, append(term.Cprint("  ", color.FgWhite), graphCells(rows[i])...))
+	}
+	return lines
+}
+
+// graphCells colors the symbols of the graph row by their lanes
+func graphCells(row git.GraphRow) []term.Cell {
+	cells := make([]term.Cell, 0, len(row))
+	for _, gc := range row {
+		attr := laneColors[gc.Lane%len(laneColors)]
+		if gc.Symbol == git.GraphCommit || gc.Symbol == git.GraphMerge {
+			attr = color.FgWhite
+		}
+		cells = append(cells, term.Cell{Ch: gc.Symbol, Attr: []color.Attribute{attr}})
+	}
+	return cells
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// rebase plans an interactive rebase, the commits are reordered and their
+// actions are set before the plan is handed to "git rebase -i"
+type rebase struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	upstream   string
+	steps      *prompt.SyncList
+	oldState   *prompt.State
+}
+
+// RebasePrompt configures a prompt to plan the rebase of the commits of HEAD
+// that are not in the upstream
+func RebasePrompt(r *git.Repository, opts *prompt.Options, upstream string) (*prompt.Prompt, error) {
+	steps, err := r.RebasePlan(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("could not plan the rebase: %v", err)
+	}
+	list, err := prompt.NewList(steps, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	rb := &rebase{
+		repository: r,
+		upstream:   upstream,
+		steps:      list,
+	}
+	rb.prompt = prompt.Create("Rebase onto "+upstream, opts, list,
+		prompt.WithSelectionHandler(rb.onSelect),
+		prompt.WithItemRenderer(rb.renderItem),
+		prompt.WithInformation(rb.info),
+	)
+	if err := rb.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return rb.prompt, nil
+}
+
+// onSelect shows the changes of the commit
+func (rb *rebase) onSelect(item interface{}) error {
+	step, ok := item.(*git.RebaseStep)
+	if !ok {
+		return nil
+	}
+	diff, err := step.Commit.Diff()
+	if err != nil {
+		rb.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0)
+	for _, d := range diff.Deltas() {
+		files = append(files, deltaFile(d))
+	}
+	showDiff(rb.prompt, "Diff of "+step.Commit.Hash[:7], commitPreface(step.Commit), files, 0)
+	return nil
+}
+
+func (rb *rebase) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'p',
+			Display: "p",
+			Desc:    "pick",
+			Handler: rb.setAction(git.RebasePick),
+		},
+		&prompt.KeyBinding{
+			Key:     'r',
+			Display: "r",
+			Desc:    "reword",
+			Handler: rb.setAction(git.RebaseReword),
+		},
+		&prompt.KeyBinding{
+			Key:     'e',
+			Display: "e",
+			Desc:    "edit",
+			Handler: rb.setAction(git.RebaseEdit),
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "squash",
+			Handler: rb.setAction(git.RebaseSquash),
+		},
+		&prompt.KeyBinding{
+			Key:     'f',
+			Display: "f",
+			Desc:    "fixup",
+			Handler: rb.setAction(git.RebaseFixup),
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "drop",
+			Handler: rb.setAction(git.RebaseDrop),
+		},
+		&prompt.KeyBinding{
+			Key:     'K',
+			Display: "K",
+			Desc:    "move commit up",
+			Handler: rb.move(-1),
+		},
+		&prompt.KeyBinding{
+			Key:     'J',
+			Display: "J",
+			Desc:    "move commit down",
+			Handler: rb.move(1),
+		},
+		&prompt.KeyBinding{
+			Key:     'X',
+			Display: "X",
+			Desc:    "execute the rebase",
+			Handler: rb.execute,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit without rebasing",
+			Handler: rb.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := rb.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setAction returns a handler that sets the action of the selected commit
+func (rb *rebase) setAction(action git.RebaseAction) func(interface{}) error {
+	return func(item interface{}) error {
+		step, ok := item.(*git.RebaseStep)
+		if !ok {
+			return nil
+		}
+		step.Action = action
+		rb.steps.Next()
+		return nil
+	}
+}
+
+// move returns a handler that moves the selected commit up or down, the
+// commits are applied from top to bottom
+func (rb *rebase) move(delta int) func(interface{}) error {
+	return func(item interface{}) error {
+		if _, ok := item.(*git.RebaseStep); !ok {
+			return nil
+		}
+		if !rb.steps.Move(delta) && len(rb.prompt.State().SearchStr) > 0 {
+			rb.prompt.SetMessage(term.Cprint("commits cannot be moved while searching", color.Faint))
+		}
+		return nil
+	}
+}
+
+// plan returns the steps in the order of the list
+func (rb *rebase) plan() []*git.RebaseStep {
+	values := rb.steps.Values()
+	steps := make([]*git.RebaseStep, 0, len(values))
+	for _, v := range values {
+		steps = append(steps, v.(*git.RebaseStep))
+	}
+	return steps
+}
+
+// execute writes the todo list and runs "git rebase -i" with a sequence
+// editor that replaces the todo list of git with it. If the rebase stops for
+// conflicts or edits, the prompt is stopped to continue in the status.
+func (rb *rebase) execute(item interface{}) error {
+	if _, ok := item.(*git.RebaseStep); !ok {
+		return nil
+	}
+	todo, err := git.RebaseTodo(rb.plan())
+	if err != nil {
+		rb.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	file, err := os.CreateTemp("", "gitin-rebase-todo")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(todo); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	editor := "cat '" + strings.Replace(file.Name(), "'", `'\''`, -1) + "' >"
+	args := []string{"rebase", "--interactive", rb.upstream}
+	err = popGitCommandWithEnv(rb.repository, args, []string{"GIT_SEQUENCE_EDITOR=" + editor})
+	rb.repository.LoadHead()
+	if rb.repository.State().InProgress() {
+		rb.prompt.Stop()
+		return nil
+	}
+	if err != nil {
+		rb.prompt.SetMessage(errorText(fmt.Errorf("failed to rebase: %v", err)))
+		return nil
+	}
+	rb.prompt.Stop()
+	rb.prompt.SetExitMsg([][]term.Cell{
+		term.Cprint("Successfully rebased onto "+rb.upstream+".", color.Faint),
+	})
+	return nil
+}
+
+func (rb *rebase) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.RebaseStep:
+		rb.prompt.Stop()
+	}
+	return nil
+}
+
+func (rb *rebase) info(item interface{}) [][]term.Cell {
+	step, ok := item.(*git.RebaseStep)
+	if !ok {
+		return nil
+	}
+	c := step.Commit
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Author ", color.Faint)
+	cells = append(cells, term.Cprint(c.Author.Name+" <"+c.Author.Email+">", color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("When", color.Faint)
+	cells = append(cells, term.Cprint("   "+c.Author.When.Format("Mon Jan 2 15:04:05 2006 -0700"), color.FgWhite)...)
+	grid = append(grid, cells)
+	grid = append(grid, term.Cprint("Commits are applied from top to bottom.", color.Faint))
+	return grid
+}
+
+// width of the longest action name
+const rebaseActionWidth = 6
+
+var rebaseActionColors = map[git.RebaseAction]color.Attribute{
+	git.RebasePick:   color.FgWhite,
+	git.RebaseReword: color.FgYellow,
+	git.RebaseEdit:   color.FgYellow,
+	git.RebaseSquash: color.FgCyan,
+	git.RebaseFixup:  color.FgCyan,
+	git.RebaseDrop:   color.FgRed,
+}
+
+func (rb *rebase) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	step, ok := item.(*git.RebaseStep)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	action := step.Action.String()
+	action += strings.Repeat(" ", rebaseActionWidth-len(action))
+	line = append(line, term.Cprint(action+" ", rebaseActionColors[step.Action])...)
+	line = append(line, stautsText(step.Commit.Hash[:7])...)
+	attr := color.FgWhite
+	if step.Action == git.RebaseDrop {
+		attr = color.Faint
+	}
+	line = append(line, highLightedText(matches, attr, step.String())...)
+	return [][]term.Cell{line}
+}
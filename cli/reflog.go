@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// reflog holds the repository struct and the prompt pointer.
+type reflog struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	ref        string
+	selected   *git.ReflogEntry
+	deltas     []*git.DiffDelta
+	oldState   *prompt.State
+}
+
+// ReflogPrompt configures a prompt to list the reflog of the reference, or
+// of HEAD if the reference is empty
+func ReflogPrompt(r *git.Repository, opts *prompt.Options, ref string) (*prompt.Prompt, error) {
+	entries, err := r.Reflog(ref)
+	if err != nil {
+		return nil, fmt.Errorf("could not load reflog: %v", err)
+	}
+	if len(entries) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No reflog entries found.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
+	list, err := prompt.NewList(entries, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	rl := &reflog{repository: r, ref: ref}
+	rl.prompt = prompt.Create("Reflog of "+entries[0].Ref, opts, list,
+		prompt.WithSelectionHandler(rl.onSelect),
+		prompt.WithItemRenderer(rl.renderItem),
+		prompt.WithInformation(rl.info),
+	)
+	if err := rl.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return rl.prompt, nil
+}
+
+// onSelect lists the files of the commit of the entry like the log does
+func (rl *reflog) onSelect(item interface{}) error {
+	switch i := item.(type) {
+	case *git.ReflogEntry:
+		commit, err := rl.repository.LookupCommit(i.New)
+		if err != nil {
+			rl.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		diff, err := commit.Diff()
+		if err != nil {
+			return nil
+		}
+		deltas := diff.Deltas()
+		if len(deltas) <= 0 {
+			return nil
+		}
+		rl.selected = i
+		rl.deltas = deltas
+
+		rl.oldState = rl.prompt.State()
+		list, err := prompt.NewList(deltas, 5)
+		if err != nil {
+			return err
+		}
+		rl.prompt.SetState(&prompt.State{
+			List:        list,
+			SearchLabel: "Files",
+		})
+	case *git.DiffDelta:
+		if rl.selected == nil {
+			return nil
+		}
+		showDeltas(rl.prompt, "Diff of "+rl.selected.Selector(), rl.deltas, i)
+	}
+	return nil
+}
+
+func (rl *reflog) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "show diff",
+			Handler: rl.commitDiff,
+		},
+		&prompt.KeyBinding{
+			Key:     'b',
+			Display: "b",
+			Desc:    "create branch at entry",
+			Handler: rl.createBranch,
+		},
+		&prompt.KeyBinding{
+			Key:     'R',
+			Display: "R",
+			Desc:    "hard reset to entry",
+			Handler: rl.resetHard,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: rl.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := rl.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rl *reflog) commitDiff(item interface{}) error {
+	entry, ok := item.(*git.ReflogEntry)
+	if !ok {
+		return nil
+	}
+	commit, err := rl.repository.LookupCommit(entry.New)
+	if err != nil {
+		rl.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	diff, err := commit.Diff()
+	if err != nil {
+		rl.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0)
+	for _, d := range diff.Deltas() {
+		files = append(files, deltaFile(d))
+	}
+	showDiff(rl.prompt, "Diff of "+entry.Selector(), commitPreface(commit), files, 0)
+	return nil
+}
+
+// createBranch creates a branch at the commit of the entry, the usual way to
+// recover a commit that no branch points to
+func (rl *reflog) createBranch(item interface{}) error {
+	entry, ok := item.(*git.ReflogEntry)
+	if !ok {
+		return nil
+	}
+	commit, err := rl.repository.LookupCommit(entry.New)
+	if err != nil {
+		rl.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	rl.prompt.ReadInput("Branch name:", "", func(name string) error {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			return nil
+		}
+		if err := rl.repository.CreateBranch(name, commit); err != nil {
+			rl.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		rl.prompt.SetMessage(term.Cprint("Created branch "+name+" at "+commit.Hash[:7]+".", color.Faint))
+		return nil
+	})
+	return nil
+}
+
+// resetHard moves the current branch to the commit of the entry after it is
+// confirmed, the uncommitted changes are lost
+func (rl *reflog) resetHard(item interface{}) error {
+	entry, ok := item.(*git.ReflogEntry)
+	if !ok {
+		return nil
+	}
+	commit, err := rl.repository.LookupCommit(entry.New)
+	if err != nil {
+		rl.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	label := "Discard the changes and reset to " + entry.Selector() + "? (y/N)"
+	rl.prompt.ReadInput(label, "", func(answer string) error {
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return nil
+		}
+		if err := rl.repository.ResetHard(commit); err != nil {
+			rl.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return rl.reloadReflog()
+	})
+	return nil
+}
+
+// reloads the list, the reset adds an entry to the reflog
+func (rl *reflog) reloadReflog() error {
+	rl.repository.LoadHead()
+	entries, err := rl.repository.Reflog(rl.ref)
+	if err != nil {
+		return err
+	}
+	state := rl.prompt.State()
+	list, err := prompt.NewList(entries, state.ListSize)
+	if err != nil {
+		return err
+	}
+	state.List = list
+	rl.prompt.SetState(state)
+	return nil
+}
+
+func (rl *reflog) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.ReflogEntry:
+		rl.prompt.Stop()
+	case *git.DiffDelta:
+		rl.prompt.SetState(rl.oldState)
+	}
+	return nil
+}
+
+func (rl *reflog) info(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	entry, ok := item.(*git.ReflogEntry)
+	if !ok {
+		return grid
+	}
+	cells := term.Cprint(entry.Selector()+" ", color.FgCyan)
+	cells = append(cells, term.Cprint(shortHash(entry.Old), color.FgYellow)...)
+	cells = append(cells, term.Cprint(" → ", color.Faint)...)
+	cells = append(cells, term.Cprint(shortHash(entry.New), color.FgYellow)...)
+	grid = append(grid, cells)
+	if c := entry.Committer; c != nil {
+		cells = term.Cprint("By ", color.Faint)
+		cells = append(cells, term.Cprint(c.Name+" <"+c.Email+">", color.FgWhite)...)
+		grid = append(grid, cells)
+		cells = term.Cprint("When", color.Faint)
+		cells = append(cells, term.Cprint(" "+timeago.FromTime(c.When)+" ("+c.When.Format("Mon Jan 2 15:04:05 2006 -0700")+")", color.FgWhite)...)
+		grid = append(grid, cells)
+	}
+	return grid
+}
+
+func (rl *reflog) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	entry, ok := item.(*git.ReflogEntry)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	line = append(line, stautsText(shortHash(entry.New))...)
+	line = append(line, term.Cprint(entry.Selector()+" ", color.FgCyan)...)
+	line = append(line, highLightedText(matches, color.FgWhite, entry.String())...)
+	return [][]term.Cell{line}
+}
+
+// shortHash abbreviates the hash to 7 characters
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
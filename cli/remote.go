@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// remote holds the repository struct and the prompt pointer.
+type remote struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	oldState   *prompt.State
+}
+
+// RemotePrompt configures a prompt to serve as a remote manager prompt
+func RemotePrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
+	remotes, err := r.Remotes()
+	if err != nil {
+		return nil, fmt.Errorf("could not load remotes: %v", err)
+	}
+	if len(remotes) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No remotes configured.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
+	list, err := prompt.NewList(remotes, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+
+	rm := &remote{repository: r}
+	rm.prompt = prompt.Create("Remotes", opts, list,
+		prompt.WithSelectionHandler(rm.onSelect),
+		prompt.WithItemRenderer(renderItem),
+		prompt.WithInformation(rm.remoteInfo),
+	)
+	if err := rm.defineKeybindings(); err != nil {
+		return nil, err
+	}
+
+	return rm.prompt, nil
+}
+
+// lists the remote-tracking branches of the remote
+func (rm *remote) onSelect(item interface{}) error {
+	rmt, ok := item.(*git.Remote)
+	if !ok {
+		return nil
+	}
+	branches, err := rm.repository.Branches()
+	if err != nil {
+		return err
+	}
+	tracking := make([]*git.Branch, 0)
+	for _, b := range branches {
+		if b.IsRemote() && strings.HasPrefix(b.Name, rmt.Name+"/") {
+			tracking = append(tracking, b)
+		}
+	}
+	if len(tracking) == 0 {
+		rm.prompt.SetMessage(term.Cprint("No remote-tracking branches, fetch the remote first.", color.Faint))
+		return nil
+	}
+	rm.oldState = rm.prompt.State()
+	list, err := prompt.NewList(tracking, 5)
+	if err != nil {
+		return err
+	}
+	rm.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchMode:  false,
+		SearchStr:   "",
+		SearchLabel: "Branches",
+	})
+	return nil
+}
+
+func (rm *remote) remoteInfo(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	switch i := item.(type) {
+	case *git.Remote:
+		cells := term.Cprint("Fetch URL ", color.Faint)
+		cells = append(cells, term.Cprint(i.URL, color.FgCyan)...)
+		grid = append(grid, cells)
+		if len(i.PushURL) > 0 {
+			cells = term.Cprint("Push URL  ", color.Faint)
+			cells = append(cells, term.Cprint(i.PushURL, color.FgCyan)...)
+			grid = append(grid, cells)
+		}
+		for _, refspec := range i.FetchRefspecs {
+			cells = term.Cprint("Refspec   ", color.Faint)
+			cells = append(cells, term.Cprint(refspec, color.FgWhite)...)
+			grid = append(grid, cells)
+		}
+	case *git.Branch:
+		if target := i.Target(); target != nil {
+			cells := term.Cprint("Last commit ", color.Faint)
+			cells = append(cells, term.Cprint(target.Hash[:7], color.FgYellow)...)
+			cells = append(cells, term.Cprint(" "+target.Summary, color.FgWhite)...)
+			grid = append(grid, cells)
+		}
+	}
+	return grid
+}
+
+func (rm *remote) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'f',
+			Display: "f",
+			Desc:    "fetch remote",
+			Handler: rm.fetch,
+		},
+		&prompt.KeyBinding{
+			Key:     'a',
+			Display: "a",
+			Desc:    "add remote",
+			Handler: rm.add,
+		},
+		&prompt.KeyBinding{
+			Key:     'r',
+			Display: "r",
+			Desc:    "rename remote",
+			Handler: rm.rename,
+		},
+		&prompt.KeyBinding{
+			Key:     'u',
+			Display: "u",
+			Desc:    "set remote url",
+			Handler: rm.setURL,
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "remove remote",
+			Handler: rm.delete,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: rm.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := rm.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rm *remote) fetch(item interface{}) error {
+	rmt, ok := item.(*git.Remote)
+	if !ok {
+		return nil
+	}
+	runRemoteAction(rm.prompt, rm.repository, "Fetching "+rmt.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.Fetch(rmt.Name, progress)
+	}, nil)
+	return nil
+}
+
+func (rm *remote) add(item interface{}) error {
+	if _, ok := item.(*git.Remote); !ok {
+		return nil
+	}
+	rm.prompt.ReadInput("Remote name:", "", func(name string) error {
+		if len(name) == 0 {
+			return nil
+		}
+		rm.prompt.ReadInput("Remote url:", "", func(url string) error {
+			if len(url) == 0 {
+				return nil
+			}
+			if _, err := rm.repository.AddRemote(name, url); err != nil {
+				rm.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			return rm.reloadRemotes()
+		})
+		return nil
+	})
+	return nil
+}
+
+func (rm *remote) rename(item interface{}) error {
+	rmt, ok := item.(*git.Remote)
+	if !ok {
+		return nil
+	}
+	rm.prompt.ReadInput("New name:", rmt.Name, func(name string) error {
+		if len(name) == 0 || name == rmt.Name {
+			return nil
+		}
+		if err := rmt.Rename(name); err != nil {
+			rm.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return rm.reloadRemotes()
+	})
+	return nil
+}
+
+func (rm *remote) setURL(item interface{}) error {
+	rmt, ok := item.(*git.Remote)
+	if !ok {
+		return nil
+	}
+	rm.prompt.ReadInput("Remote url:", rmt.URL, func(url string) error {
+		if len(url) == 0 {
+			return nil
+		}
+		if err := rmt.SetURL(url); err != nil {
+			rm.prompt.SetMessage(errorText(err))
+		}
+		return nil
+	})
+	return nil
+}
+
+func (rm *remote) delete(item interface{}) error {
+	rmt, ok := item.(*git.Remote)
+	if !ok {
+		return nil
+	}
+	if err := rmt.Delete(); err != nil {
+		rm.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return rm.reloadRemotes()
+}
+
+func (rm *remote) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.Remote:
+		rm.prompt.Stop()
+	case *git.Branch:
+		rm.prompt.SetState(rm.oldState)
+	}
+	return nil
+}
+
+// reloads the list
+func (rm *remote) reloadRemotes() error {
+	remotes, err := rm.repository.Remotes()
+	if err != nil {
+		return err
+	}
+	if len(remotes) == 0 {
+		rm.prompt.Stop()
+		rm.prompt.SetExitMsg([][]term.Cell{term.Cprint("No remotes left.", color.Faint)})
+		return nil
+	}
+	state := rm.prompt.State()
+	list, err := prompt.NewList(remotes, state.ListSize)
+	if err != nil {
+		return fmt.Errorf("could not reload remotes: %v", err)
+	}
+	state.List = list
+	rm.prompt.SetState(state)
+	return nil
+}
+
+// runRemoteAction runs a network operation in the background while showing
+// its progress, done is called on the prompt's main loop when it ends. The
+// action gets its own handle of the repository since the UI keeps using r.
+func runRemoteAction(p *prompt.Prompt, r *git.Repository, label string, action func(*git.Repository, chan<- *git.Progress) error, done func() error) {
+	runRemoteUpdate(p, r, label, action, nil, done)
+}
+
+// runRemoteUpdate is runRemoteAction with an update of the working tree or
+// the refs after a successful network operation. The update runs on the
+// prompt's main loop with r.
+func runRemoteUpdate(p *prompt.Prompt, r *git.Repository, label string, action func(*git.Repository, chan<- *git.Progress) error, update func() error, done func() error) {
+	p.SetMessage(term.Cprint(label+"...", color.Faint))
+	progress := make(chan *git.Progress)
+	result := make(chan error, 1)
+	go func() {
+		other, err := r.Reopen()
+		if err != nil {
+			close(progress)
+			result <- err
+			return
+		}
+		defer other.Free()
+		result <- action(other, progress) // progress is closed by the action
+	}()
+	go func() {
+		for pr := range progress {
+			cells := progressText(label, pr)
+			p.Post(func() error {
+				p.SetMessage(cells)
+				return nil
+			})
+		}
+		err := <-result
+		p.Post(func() error {
+			if err == nil && update != nil {
+				err = update()
+			}
+			switch err {
+			case nil:
+				p.SetMessage(term.Cprint(label+", done.", color.Faint))
+			case git.ErrAlreadyUpToDate:
+				p.SetMessage(term.Cprint("Already up to date.", color.Faint))
+			case git.ErrFastForwardOnly:
+				p.SetMessage(term.Cprint("Not possible to fast-forward, the branches have diverged.", color.FgRed))
+			default:
+				p.SetMessage(errorText(err))
+			}
+			if done == nil {
+				return nil
+			}
+			return done()
+		})
+	}()
+}
+
+// defaultRemote returns the remote of the branch, or "origin" if the branch
+// does not track a remote branch
+func defaultRemote(b *git.Branch) string {
+	if b != nil {
+		if remote := b.RemoteName(); len(remote) > 0 {
+			return remote
+		}
+	}
+	return "origin"
+}
+
+func progressText(label string, pr *git.Progress) []term.Cell {
+	if len(pr.Message) > 0 {
+		return term.Cprint("remote: "+pr.Message, color.Faint)
+	}
+	cells := term.Cprint(label+": "+pr.Phase+" ", color.Faint)
+	cells = append(cells, term.Cprint(strconv.Itoa(pr.Current*100/pr.Total)+"%", color.FgYellow)...)
+	cells = append(cells, term.Cprint(fmt.Sprintf(" (%d/%d)", pr.Current, pr.Total), color.Faint)...)
+	return cells
+}
@@ -24,12 +24,38 @@ func renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
 		}
 		line = append(line, stautsText(i.StatusEntryString()[:1])...)
 		line = append(line, highLightedText(matches, attr, i.String())...)
+		if sm := i.Submodule; sm != nil && sm.Changes != 0 {
+			line = append(line, term.Cprint(" ("+sm.ChangesString()+")", color.Faint)...)
+		}
 	case *git.Commit:
 		line = append(line, stautsText(i.Hash[:7])...)
 		line = append(line, highLightedText(matches, color.FgWhite, i.String())...)
+	case *git.FileRevision:
+		line = append(line, stautsText(i.Commit.Hash[:7])...)
+		line = append(line, highLightedText(matches, color.FgWhite, i.String())...)
+		if i.Renamed() {
+			line = append(line, term.Cprint(" ("+i.Delta.OldFile.Path+" → "+i.Path+")", color.Faint)...)
+		}
 	case *git.DiffDelta:
 		line = append(line, stautsText(i.DeltaStatusString()[:1])...)
 		line = append(line, highLightedText(matches, color.FgWhite, i.String())...)
+	case *git.Tag:
+		hash := i.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		attr := color.FgWhite
+		if i.Annotated {
+			attr = color.FgYellow
+		}
+		line = append(line, stautsText(hash)...)
+		line = append(line, highLightedText(matches, attr, i.String())...)
+	case *git.Remote:
+		line = append(line, highLightedText(matches, color.FgYellow, i.String())...)
+		line = append(line, term.Cprint(" "+i.URL, color.Faint)...)
+	case *git.Stash:
+		line = append(line, stautsText(fmt.Sprintf("stash@{%d}", i.Index))...)
+		line = append(line, highLightedText(matches, color.FgWhite, i.String())...)
 	case *git.Branch:
 		attr := color.FgWhite
 		headIndicator := ""
@@ -146,3 +172,7 @@ func workingTreeClean(b *git.Branch) [][]term.Cell {
 	grid = append(grid, term.Cprint("Nothing to commit, working tree clean", color.Faint))
 	return grid
 }
+
+func errorText(err error) []term.Cell {
+	return term.Cprint(err.Error(), color.FgRed)
+}
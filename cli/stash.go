@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// stash holds the repository struct and the prompt pointer.
+type stash struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	selected   *git.Stash
+	deltas     []*git.DiffDelta
+	oldState   *prompt.State
+}
+
+// StashPrompt configures a prompt to serve as a stash explorer prompt
+func StashPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
+	stashes, err := r.Stashes()
+	if err != nil {
+		return nil, fmt.Errorf("could not load stashes: %v", err)
+	}
+	if len(stashes) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No stash entries found.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
+	list, err := prompt.NewList(stashes, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+
+	s := &stash{repository: r}
+	s.prompt = prompt.Create("Stashes", opts, list,
+		prompt.WithSelectionHandler(s.onSelect),
+		prompt.WithItemRenderer(renderItem),
+		prompt.WithInformation(s.stashInfo),
+	)
+	if err := s.defineKeybindings(); err != nil {
+		return nil, err
+	}
+
+	return s.prompt, nil
+}
+
+func (s *stash) onSelect(item interface{}) error {
+	switch item.(type) {
+	case *git.Stash:
+		st := item.(*git.Stash)
+		s.selected = st
+		diff, err := st.Diff()
+		if err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		deltas := diff.Deltas()
+		if len(deltas) <= 0 {
+			return nil
+		}
+		s.deltas = deltas
+
+		s.oldState = s.prompt.State()
+		list, err := prompt.NewList(deltas, 5)
+		if err != nil {
+			return err
+		}
+		s.prompt.SetState(&prompt.State{
+			List:        list,
+			SearchMode:  false,
+			SearchStr:   "",
+			SearchLabel: "Files",
+		})
+	case *git.DiffDelta:
+		if s.selected == nil {
+			return nil
+		}
+		showDeltas(s.prompt, fmt.Sprintf("stash@{%d}", s.selected.Index), s.deltas, item.(*git.DiffDelta))
+	}
+	return nil
+}
+
+func (s *stash) stashInfo(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	switch item.(type) {
+	case *git.Stash:
+		st := item.(*git.Stash)
+		if len(st.Branch) > 0 {
+			cells := term.Cprint("Branch ", color.Faint)
+			cells = append(cells, term.Cprint(st.Branch, color.FgYellow)...)
+			grid = append(grid, cells)
+		}
+		cells := term.Cprint("When", color.Faint)
+		cells = append(cells, term.Cprint("   "+timeago.FromTime(st.Target().Author.When), color.FgWhite)...)
+		grid = append(grid, cells)
+		cells = term.Cprint("Hash", color.Faint)
+		cells = append(cells, term.Cprint("   "+st.Hash[:7], color.FgYellow)...)
+		grid = append(grid, cells)
+	case *git.DiffDelta:
+		cells := term.Cprint("Stashed ", color.Faint)
+		cells = append(cells, term.Cprint(s.selected.Message, color.FgWhite)...)
+		grid = append(grid, cells)
+	}
+	return grid
+}
+
+func (s *stash) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'a',
+			Display: "a",
+			Desc:    "apply stash",
+			Handler: s.apply,
+		},
+		&prompt.KeyBinding{
+			Key:     'p',
+			Display: "p",
+			Desc:    "pop stash",
+			Handler: s.pop,
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "drop stash",
+			Handler: s.drop,
+		},
+		&prompt.KeyBinding{
+			Key:     'b',
+			Display: "b",
+			Desc:    "create branch from stash",
+			Handler: s.branch,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: s.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := s.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stash) apply(item interface{}) error {
+	st, ok := item.(*git.Stash)
+	if !ok {
+		return nil
+	}
+	if err := st.Apply(); err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	s.prompt.SetMessage(term.Cprint("Applied "+st.Message, color.Faint))
+	return nil
+}
+
+func (s *stash) pop(item interface{}) error {
+	st, ok := item.(*git.Stash)
+	if !ok {
+		return nil
+	}
+	if err := st.Pop(); err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return s.reloadStashes()
+}
+
+func (s *stash) drop(item interface{}) error {
+	st, ok := item.(*git.Stash)
+	if !ok {
+		return nil
+	}
+	if err := st.Drop(); err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return s.reloadStashes()
+}
+
+func (s *stash) branch(item interface{}) error {
+	st, ok := item.(*git.Stash)
+	if !ok {
+		return nil
+	}
+	s.prompt.ReadInput("Branch name:", "", func(name string) error {
+		if len(name) == 0 {
+			return nil
+		}
+		if err := st.NewBranch(name); err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return s.reloadStashes()
+	})
+	return nil
+}
+
+func (s *stash) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.Stash:
+		s.prompt.Stop()
+	case *git.DiffDelta:
+		s.prompt.SetState(s.oldState)
+	}
+	return nil
+}
+
+// reloads the list
+func (s *stash) reloadStashes() error {
+	stashes, err := s.repository.Stashes()
+	if err != nil {
+		return err
+	}
+	if len(stashes) == 0 {
+		s.prompt.Stop()
+		s.prompt.SetExitMsg([][]term.Cell{term.Cprint("No stash entries left.", color.Faint)})
+		return nil
+	}
+	state := s.prompt.State()
+	list, err := prompt.NewList(stashes, state.ListSize)
+	if err != nil {
+		return fmt.Errorf("could not reload stashes: %v", err)
+	}
+	state.List = list
+	s.prompt.SetState(state)
+	return nil
+}
@@ -2,21 +2,28 @@ package cli
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 
-	"github.com/isacikgoz/gia/editor"
+	"github.com/fatih/color"
 	"github.com/isacikgoz/gitin/git"
 	"github.com/isacikgoz/gitin/prompt"
 	"github.com/isacikgoz/gitin/term"
-	"github.com/waigani/diffparser"
 )
 
 // status holds the repository struct and the prompt pointer.
 type status struct {
 	repository *git.Repository
 	prompt     *prompt.Prompt
+	oldState   *prompt.State
+
+	// set while staging the hunks of an entry
+	patch     *git.FilePatch
+	hunk      *git.Hunk
+	hunkState *prompt.State
+	marked    map[*git.DiffLine]bool
+
+	history  *history      // set while the history of an entry is listed
+	conflict *git.Conflict // set while the versions of a conflict are listed
 }
 
 // StatusPrompt configures a prompt to serve as work-dir explorer prompt
@@ -42,7 +49,7 @@ func StatusPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, erro
 
 	s.prompt = prompt.Create("Files", opts, list,
 		prompt.WithSelectionHandler(s.onSelect),
-		prompt.WithItemRenderer(renderItem),
+		prompt.WithItemRenderer(s.renderItem),
 		prompt.WithInformation(s.info),
 	)
 	if err := s.defineKeybindings(); err != nil {
@@ -54,16 +61,60 @@ func StatusPrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, erro
 
 // return err to terminate
 func (s *status) onSelect(item interface{}) error {
-	entry := item.(*git.StatusEntry)
-	if err := popGitCommand(s.repository, fileStatArgs(entry)); err != nil {
-		return nil // intentionally ignore errors here
+	switch i := item.(type) {
+	case *git.StatusEntry:
+		if i.EntryType == git.StatusEntryTypeConflicted {
+			return s.showConflict(i)
+		}
+		return s.showChanges(i)
+	case *git.ConflictSide:
+		return s.showConflictSide(i)
+	case *git.Hunk:
+		return s.showLines(i)
+	case *git.DiffLine:
+		return s.stageMarkedLines(i)
+	case *git.FileRevision:
+		return s.history.onSelect(i)
+	}
+	return nil
+}
+
+// showChanges opens the diff pane with the changes of every entry, scrolled
+// to the selected one
+func (s *status) showChanges(entry *git.StatusEntry) error {
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		return err
+	}
+	files := make([]*diffFile, 0, len(st.Entities))
+	index := 0
+	for i, e := range st.Entities {
+		if e.String() == entry.String() && e.Indexed() == entry.Indexed() {
+			index = i
+		}
+		files = append(files, entryFile(s.repository, e))
 	}
+	showDiff(s.prompt, "Changes", nil, files, index)
 	return nil
 }
 
 func (s *status) info(item interface{}) [][]term.Cell {
+	switch i := item.(type) {
+	case *git.Hunk:
+		return s.hunkInfo(i)
+	case *git.DiffLine:
+		return s.lineInfo(i)
+	case *git.FileRevision:
+		return s.history.info(i)
+	case *git.ConflictSide:
+		return s.conflictInfo(i)
+	case *git.StatusEntry:
+		if i.Submodule != nil {
+			return submoduleInfo(i.Submodule)
+		}
+	}
 	b := s.repository.Head
-	return branchInfo(b, true)
+	return append(stateInfo(s.repository, s.repository.State()), branchInfo(b, true)...)
 }
 
 func (s *status) defineKeybindings() error {
@@ -71,44 +122,116 @@ func (s *status) defineKeybindings() error {
 		&prompt.KeyBinding{
 			Key:     ' ',
 			Display: "space",
-			Desc:    "add/reset entry",
+			Desc:    "add/reset entry or hunk, mark line",
 			Handler: s.addResetEntry,
 		},
 		&prompt.KeyBinding{
 			Key:     'p',
 			Display: "p",
 			Desc:    "hunk stage entry",
-			Handler: s.hunkStageEntry,
+			Handler: onEntry(s.hunkStageEntry),
 		},
 		&prompt.KeyBinding{
 			Key:     'c',
 			Display: "c",
 			Desc:    "commit",
-			Handler: s.commit,
+			Handler: onEntry(s.commit),
 		},
 		&prompt.KeyBinding{
 			Key:     'm',
 			Display: "m",
 			Desc:    "amend",
-			Handler: s.amend,
+			Handler: onEntry(s.amend),
 		},
 		&prompt.KeyBinding{
 			Key:     'a',
 			Display: "a",
 			Desc:    "add all",
-			Handler: s.addAllEntries,
+			Handler: onEntry(s.addAllEntries),
 		},
 		&prompt.KeyBinding{
 			Key:     'r',
 			Display: "r",
 			Desc:    "reset all",
-			Handler: s.resetAllEntries,
+			Handler: onEntry(s.resetAllEntries),
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "stash entry, split hunk",
+			Handler: s.stashEntry,
+		},
+		&prompt.KeyBinding{
+			Key:     'S',
+			Display: "S",
+			Desc:    "stash all",
+			Handler: onEntry(s.stashAll),
+		},
+		&prompt.KeyBinding{
+			Key:     'f',
+			Display: "f",
+			Desc:    "fetch",
+			Handler: onEntry(s.fetch),
+		},
+		&prompt.KeyBinding{
+			Key:     'u',
+			Display: "u",
+			Desc:    "pull (fast-forward only)",
+			Handler: onEntry(s.pull),
+		},
+		&prompt.KeyBinding{
+			Key:     'P',
+			Display: "P",
+			Desc:    "push",
+			Handler: onEntry(s.push),
 		},
 		&prompt.KeyBinding{
 			Key:     '!',
 			Display: "!",
 			Desc:    "discard changes",
-			Handler: s.discardEntry,
+			Handler: onEntry(s.discardEntry),
+		},
+		&prompt.KeyBinding{
+			Key:     'H',
+			Display: "H",
+			Desc:    "history of entry",
+			Handler: onEntry(s.entryHistory),
+		},
+		&prompt.KeyBinding{
+			Key:     'o',
+			Display: "o",
+			Desc:    "take ours",
+			Handler: s.takeOurs,
+		},
+		&prompt.KeyBinding{
+			Key:     't',
+			Display: "t",
+			Desc:    "take theirs",
+			Handler: s.takeTheirs,
+		},
+		&prompt.KeyBinding{
+			Key:     'e',
+			Display: "e",
+			Desc:    "open merge editor",
+			Handler: s.mergeEditor,
+		},
+		&prompt.KeyBinding{
+			Key:     'R',
+			Display: "R",
+			Desc:    "mark resolved",
+			Handler: s.markResolved,
+		},
+		&prompt.KeyBinding{
+			Key:     'C',
+			Display: "C",
+			Desc:    "continue merge/rebase/cherry-pick/revert",
+			Handler: onEntry(s.continueOperation),
+		},
+		&prompt.KeyBinding{
+			Key:     'A',
+			Display: "A",
+			Desc:    "abort merge/rebase/cherry-pick/revert",
+			Handler: onEntry(s.abortOperation),
 		},
 		&prompt.KeyBinding{
 			Key:     'q',
@@ -126,93 +249,172 @@ func (s *status) defineKeybindings() error {
 }
 
 func (s *status) addResetEntry(item interface{}) error {
+	switch i := item.(type) {
+	case *git.Hunk:
+		return s.stageLines(i.Changes())
+	case *git.DiffLine:
+		return s.markLine(i)
+	case *git.FileRevision, *git.ConflictSide:
+		return nil
+	}
 	entry := item.(*git.StatusEntry)
-	args := []string{"add", "--", entry.String()}
 	if entry.Indexed() {
-		args = []string{"reset", "HEAD", "--", entry.String()}
+		return s.reloadWithError(s.repository.RemoveFromIndex(entry))
 	}
-	return s.runCommandWithArgs(args)
+	return s.reloadWithError(s.repository.AddToIndex(entry))
 }
 
-func (s *status) hunkStageEntry(item interface{}) error {
+func (s *status) commit(item interface{}) error {
+	return s.composeCommit(false)
+}
+
+func (s *status) amend(item interface{}) error {
+	return s.composeCommit(true)
+}
+
+func (s *status) addAllEntries(item interface{}) error {
+	return s.reloadWithError(s.repository.AddAll())
+}
+
+func (s *status) resetAllEntries(item interface{}) error {
+	return s.reloadWithError(s.repository.ResetAll())
+}
+
+func (s *status) discardEntry(item interface{}) error {
 	entry := item.(*git.StatusEntry)
-	file, err := generateDiffFile(s.repository, entry)
-	if err == nil {
-		editor, err := editor.NewEditor(file)
-		if err != nil {
-			return err
-		}
-		patches, err := editor.Run()
+	return s.reloadWithError(s.repository.DiscardEntry(entry))
+}
+
+func (s *status) stashEntry(item interface{}) error {
+	switch i := item.(type) {
+	case *git.Hunk:
+		return s.splitHunk(i)
+	case *git.DiffLine, *git.FileRevision, *git.ConflictSide:
+		return nil
+	}
+	entry := item.(*git.StatusEntry)
+	s.prompt.ReadInput("Stash message:", "", func(msg string) error {
+		st, err := s.repository.LoadStatus()
 		if err != nil {
 			return err
 		}
-		for _, patch := range patches {
-			if err := applyPatchCmd(s.repository, entry, patch); err != nil {
-				return err
+		// stash both staged and unstaged changes of the file
+		entries := make([]*git.StatusEntry, 0)
+		for _, e := range st.Entities {
+			if e.String() == entry.String() {
+				entries = append(entries, e)
 			}
 		}
-	}
-	return s.reloadStatus()
+		if err := s.repository.StashEntries(msg, entries); err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return s.reloadStatus()
+	})
+	return nil
 }
 
-func (s *status) commit(item interface{}) error {
-	s.bareCommit("--edit") // why ignore err? simply to return status screen
+func (s *status) stashAll(item interface{}) error {
+	s.prompt.ReadInput("Stash message:", "", func(msg string) error {
+		if err := s.repository.StashAll(msg); err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return s.reloadStatus()
+	})
 	return nil
 }
 
-func (s *status) amend(item interface{}) error {
-	s.bareCommit("--amend")
+func (s *status) fetch(item interface{}) error {
+	remote := defaultRemote(s.repository.Head)
+	runRemoteAction(s.prompt, s.repository, "Fetching "+remote, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.Fetch(remote, progress)
+	}, s.reloadStatus)
 	return nil
 }
 
-func (s *status) bareCommit(arg string) error {
-	args := []string{"commit", arg, "--quiet"}
-	err := popGitCommand(s.repository, args)
-	if err != nil {
-		return err
-	}
-	s.repository.LoadHead()
-	args, err = lastCommitArgs(s.repository)
-	if err != nil {
-		return err
+func (s *status) pull(item interface{}) error {
+	head := s.headBranch()
+	if head == nil {
+		return nil
 	}
-	if err := popGitCommand(s.repository, args); err != nil {
-		return fmt.Errorf("failed to commit: %v", err)
+	runRemoteUpdate(s.prompt, s.repository, "Pulling "+head.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.FetchUpstream(head, progress)
+	}, func() error {
+		return s.repository.FastForwardUpstream(head)
+	}, s.reloadStatus)
+	return nil
+}
+
+func (s *status) push(item interface{}) error {
+	head := s.headBranch()
+	if head == nil {
+		return nil
 	}
-	return s.reloadStatus()
+	runRemoteAction(s.prompt, s.repository, "Pushing "+head.Name, func(r *git.Repository, progress chan<- *git.Progress) error {
+		return r.Push(head, progress)
+	}, s.reloadStatus)
+	return nil
 }
 
-func (s *status) addAllEntries(item interface{}) error {
-	args := []string{"add", "."}
-	return s.runCommandWithArgs(args)
+// headBranch returns the checked out branch, a message is shown and it is
+// nil if HEAD is detached or the branch has no commits yet
+func (s *status) headBranch() *git.Branch {
+	head := s.repository.Head
+	if head == nil || s.repository.HeadDetached() {
+		s.prompt.SetMessage(term.Cprint("Not on a branch, check out a branch to pull or push.", color.FgRed))
+		return nil
+	}
+	return head
 }
 
-func (s *status) resetAllEntries(item interface{}) error {
-	args := []string{"reset", "--mixed"}
-	return s.runCommandWithArgs(args)
+func (s *status) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.StatusEntry:
+		s.prompt.Stop()
+	case *git.Hunk:
+		return s.leaveHunks()
+	case *git.DiffLine:
+		s.hunk = nil
+		s.prompt.SetState(s.hunkState)
+	case *git.FileRevision:
+		err := s.history.quit(item)
+		s.history = nil
+		return err
+	case *git.ConflictSide:
+		return s.leaveConflict(nil)
+	}
+	return nil
 }
 
-func (s *status) discardEntry(item interface{}) error {
+// entryHistory lists the commits that changed the file of the entry
+func (s *status) entryHistory(item interface{}) error {
 	entry := item.(*git.StatusEntry)
-	var args []string
-	if entry.EntryType == git.StatusEntryTypeUntracked {
-		args = []string{"clean", "--force", entry.String()}
-	} else {
-		args = []string{"checkout", "--", entry.String()}
+	h, err := showHistory(s.prompt, s.repository, "", entry.String())
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
 	}
-	return s.runCommandWithArgs(args)
+	s.history = h
+	return nil
 }
 
-func (s *status) quit(item interface{}) error {
-	s.prompt.Stop()
-	return nil
+// onEntry restricts the handler to the status entries, so that it is not
+// called while staging hunks
+func onEntry(handler func(interface{}) error) func(interface{}) error {
+	return func(item interface{}) error {
+		if _, ok := item.(*git.StatusEntry); !ok {
+			return nil
+		}
+		return handler(item)
+	}
 }
 
-func (s *status) runCommandWithArgs(args []string) error {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = s.repository.Path()
-	if err := cmd.Run(); err != nil {
-		return nil //ignore command errors for now
+// reloadWithError shows the error of an index operation, the list is reloaded
+// in any case since the operation may have been partially applied
+func (s *status) reloadWithError(err error) error {
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
 	}
 	return s.reloadStatus()
 }
@@ -239,64 +441,3 @@ func (s *status) reloadStatus() error {
 	s.prompt.SetState(state)
 	return nil
 }
-
-// fileStatArgs returns git command args for getting diff
-func fileStatArgs(e *git.StatusEntry) []string {
-	var args []string
-	if e.Indexed() {
-		args = []string{"diff", "--cached", e.String()}
-	} else if e.EntryType == git.StatusEntryTypeUntracked {
-		args = []string{"diff", "--no-index", "/dev/null", e.String()}
-	} else {
-		args = []string{"diff", "--", e.String()}
-	}
-	return args
-}
-
-// lastCommitArgs returns the args for show stat
-func lastCommitArgs(r *git.Repository) ([]string, error) {
-	r.LoadStatus()
-	head := r.Head
-	if head == nil {
-		return nil, fmt.Errorf("can't get HEAD")
-	}
-	hash := string(head.Target().Hash)
-	args := []string{"show", "--stat", hash}
-	return args, nil
-}
-
-func generateDiffFile(r *git.Repository, entry *git.StatusEntry) (*diffparser.DiffFile, error) {
-	args := fileStatArgs(entry)
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.Path()
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, err
-	}
-	diff, err := diffparser.Parse(string(out))
-	if err != nil {
-		return nil, err
-	}
-	return diff.Files[0], nil
-}
-
-func applyPatchCmd(r *git.Repository, entry *git.StatusEntry, patch string) error {
-	mode := []string{"apply", "--cached"}
-	if entry.Indexed() {
-		mode = []string{"apply", "--cached", "--reverse"}
-	}
-	cmd := exec.Command("git", mode...)
-	cmd.Dir = r.Path()
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return err
-	}
-	go func() {
-		defer stdin.Close()
-		io.WriteString(stdin, patch+"\n")
-	}()
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-	return nil
-}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// submodule explores the submodules of a repository, descending into a
+// submodule lists its own submodules and changes
+type submodule struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	items      []interface{}
+	parents    []*submoduleParent
+}
+
+// submoduleParent is a repository that is descended from
+type submoduleParent struct {
+	repository *git.Repository
+	items      []interface{}
+	state      *prompt.State
+}
+
+// SubmodulePrompt configures a prompt to list the submodules of the repository
+func SubmodulePrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
+	items, err := submoduleItems(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not load submodules: %v", err)
+	}
+	if len(items) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No submodules found.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
+	list, err := prompt.NewList(items, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	s := &submodule{repository: r, items: items}
+	s.prompt = prompt.Create("Submodules", opts, list,
+		prompt.WithSelectionHandler(s.onSelect),
+		prompt.WithItemRenderer(s.renderItem),
+		prompt.WithInformation(s.info),
+	)
+	if err := s.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return s.prompt, nil
+}
+
+// submoduleItems lists the submodules of the repository
+func submoduleItems(r *git.Repository) ([]interface{}, error) {
+	submodules, err := r.Submodules()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0, len(submodules))
+	for _, sm := range submodules {
+		items = append(items, sm)
+	}
+	return items, nil
+}
+
+// onSelect descends into a submodule or shows the changes of an entry
+func (s *submodule) onSelect(item interface{}) error {
+	switch i := item.(type) {
+	case *git.Submodule:
+		return s.descend(i)
+	case *git.StatusEntry:
+		return s.showChanges(i)
+	}
+	return nil
+}
+
+// descend opens the repository of the submodule and lists its submodules
+// and changes
+func (s *submodule) descend(sm *git.Submodule) error {
+	nested, err := sm.Open()
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	items, err := submoduleItems(nested)
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	st, err := nested.LoadStatus()
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	for _, e := range st.Entities {
+		if e.Submodule == nil {
+			items = append(items, e)
+		}
+	}
+	if len(items) == 0 {
+		s.prompt.SetMessage(term.Cprint(sm.Path+" has no submodules or changes.", color.Faint))
+		return nil
+	}
+	list, err := prompt.NewList(items, s.prompt.State().ListSize)
+	if err != nil {
+		return err
+	}
+	s.parents = append(s.parents, &submoduleParent{
+		repository: s.repository,
+		items:      s.items,
+		state:      s.prompt.State(),
+	})
+	s.repository = nested
+	s.items = items
+	s.prompt.SetState(&prompt.State{
+		List:        list,
+		SearchLabel: "Submodule " + sm.Path,
+	})
+	return nil
+}
+
+// showChanges opens the diff pane with the changes of the submodule
+func (s *submodule) showChanges(entry *git.StatusEntry) error {
+	st, err := s.repository.LoadStatus()
+	if err != nil {
+		s.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	files := make([]*diffFile, 0, len(st.Entities))
+	index := 0
+	for _, e := range st.Entities {
+		if e.Submodule != nil {
+			continue
+		}
+		if e.String() == entry.String() && e.Indexed() == entry.Indexed() {
+			index = len(files)
+		}
+		files = append(files, entryFile(s.repository, e))
+	}
+	showDiff(s.prompt, "Changes", nil, files, index)
+	return nil
+}
+
+func (s *submodule) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'i',
+			Display: "i",
+			Desc:    "init submodule",
+			Handler: s.run("Initialized", (*git.Submodule).Init),
+		},
+		&prompt.KeyBinding{
+			Key:     'u',
+			Display: "u",
+			Desc:    "update submodule",
+			Handler: s.update,
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "sync submodule url",
+			Handler: s.run("Synchronized", (*git.Submodule).Sync),
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: s.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := s.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run returns a handler that runs the operation on the selected submodule
+func (s *submodule) run(done string, operation func(*git.Submodule) error) func(interface{}) error {
+	return func(item interface{}) error {
+		sm, ok := item.(*git.Submodule)
+		if !ok {
+			return nil
+		}
+		if err := operation(sm); err != nil {
+			s.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		if err := s.reloadSubmodules(); err != nil {
+			return err
+		}
+		s.prompt.SetMessage(term.Cprint(done+" "+sm.Path+".", color.Faint))
+		return nil
+	}
+}
+
+// update clones the submodule if it is missing and checks out the recorded
+// commit in the background since it may fetch
+func (s *submodule) update(item interface{}) error {
+	sm, ok := item.(*git.Submodule)
+	if !ok {
+		return nil
+	}
+	runRemoteAction(s.prompt, s.repository, "Updating "+sm.Path, func(r *git.Repository, progress chan<- *git.Progress) error {
+		close(progress)
+		other, err := r.Submodule(sm.Name)
+		if err != nil {
+			return err
+		}
+		return other.Update()
+	}, s.reloadSubmodules)
+	return nil
+}
+
+// quit returns to the parent repository or quits
+func (s *submodule) quit(item interface{}) error {
+	if len(s.parents) == 0 {
+		s.prompt.Stop()
+		return nil
+	}
+	parent := s.parents[len(s.parents)-1]
+	s.parents = s.parents[:len(s.parents)-1]
+	s.repository = parent.repository
+	s.items = parent.items
+	s.prompt.SetState(parent.state)
+	return s.reloadSubmodules()
+}
+
+// reloads the list of the current repository, its changes are kept as they
+// are since the operations do not change them
+func (s *submodule) reloadSubmodules() error {
+	items := make([]interface{}, 0, len(s.items))
+	for _, item := range s.items {
+		sm, ok := item.(*git.Submodule)
+		if !ok {
+			items = append(items, item)
+			continue
+		}
+		reloaded, err := s.repository.Submodule(sm.Name)
+		if err != nil {
+			return err
+		}
+		items = append(items, reloaded)
+	}
+	s.items = items
+	state := s.prompt.State()
+	list, err := prompt.NewList(items, state.ListSize)
+	if err != nil {
+		return fmt.Errorf("could not reload submodules: %v", err)
+	}
+	state.List = list
+	s.prompt.SetState(state)
+	return nil
+}
+
+func (s *submodule) info(item interface{}) [][]term.Cell {
+	switch i := item.(type) {
+	case *git.Submodule:
+		return submoduleInfo(i)
+	case *git.StatusEntry:
+		status := i.StatusEntryString()
+		if i.Indexed() {
+			status += " (staged)"
+		}
+		return [][]term.Cell{term.Cprint(status, color.Faint)}
+	}
+	return nil
+}
+
+// submoduleInfo shows the commit that the superproject records and the one
+// that is checked out in the submodule
+func submoduleInfo(sm *git.Submodule) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Url         ", color.Faint)
+	cells = append(cells, term.Cprint(sm.URL, color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("Recorded    ", color.Faint)
+	cells = append(cells, term.Cprint(shortHash(sm.Recorded), color.FgYellow)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("Checked out ", color.Faint)
+	switch {
+	case len(sm.CheckedOut) > 0:
+		cells = append(cells, term.Cprint(shortHash(sm.CheckedOut), color.FgYellow)...)
+	case sm.Initialized:
+		cells = append(cells, term.Cprint("not cloned, press u to update", color.Faint)...)
+	default:
+		cells = append(cells, term.Cprint("not initialized, press i to init", color.Faint)...)
+	}
+	grid = append(grid, cells)
+	if sm.Changes != 0 {
+		grid = append(grid, term.Cprint("Has "+sm.ChangesString(), color.FgRed))
+	}
+	return grid
+}
+
+func (s *submodule) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	sm, ok := item.(*git.Submodule)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	marker := " "
+	switch {
+	case len(sm.CheckedOut) == 0:
+		marker = "-"
+	case sm.Changes&git.SubmoduleNewCommits != 0:
+		marker = "+"
+	}
+	line = append(line, stautsText(marker+shortHash(sm.Recorded))...)
+	attr := color.FgWhite
+	if sm.Changes != 0 {
+		attr = color.FgRed
+	}
+	line = append(line, highLightedText(matches, attr, sm.String())...)
+	if sm.Changes != 0 {
+		line = append(line, term.Cprint(" ("+sm.ChangesString()+")", color.Faint)...)
+	}
+	return [][]term.Cell{line}
+}
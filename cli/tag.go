@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+	"github.com/justincampbell/timeago"
+)
+
+// tag holds the repository struct and the prompt pointer.
+type tag struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+	sortBy     git.TagSort
+	selected   *git.Tag
+	deltas     []*git.DiffDelta
+	oldState   *prompt.State
+
+	// the signature verifications by the hash of the tag object, the result
+	// is nil while the tag is being verified
+	signatures map[string]*signatureResult
+}
+
+type signatureResult struct {
+	status git.SignatureStatus
+	signer string
+}
+
+// TagPrompt configures a prompt to serve as a tag explorer prompt
+func TagPrompt(r *git.Repository, opts *prompt.Options, sortBy git.TagSort) (*prompt.Prompt, error) {
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("could not load tags: %v", err)
+	}
+	if len(tags) == 0 {
+		writer := term.NewBufferedWriter(os.Stdout)
+		writer.WriteCells(term.Cprint("No tags found.", color.Faint))
+		writer.Flush()
+		os.Exit(0)
+	}
+	git.SortTags(tags, sortBy)
+	list, err := prompt.NewList(tags, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+
+	t := &tag{repository: r, sortBy: sortBy, signatures: make(map[string]*signatureResult)}
+	t.prompt = prompt.Create("Tags", opts, list,
+		prompt.WithSelectionHandler(t.onSelect),
+		prompt.WithItemRenderer(renderItem),
+		prompt.WithInformation(t.tagInfo),
+	)
+	if err := t.defineKeybindings(); err != nil {
+		return nil, err
+	}
+
+	return t.prompt, nil
+}
+
+func (t *tag) onSelect(item interface{}) error {
+	switch item.(type) {
+	case *git.Tag:
+		tg := item.(*git.Tag)
+		if tg.Target() == nil {
+			return nil
+		}
+		t.selected = tg
+		diff, err := tg.Target().Diff()
+		if err != nil {
+			return nil
+		}
+		deltas := diff.Deltas()
+		if len(deltas) <= 0 {
+			return nil
+		}
+		t.deltas = deltas
+
+		t.oldState = t.prompt.State()
+		list, err := prompt.NewList(deltas, 5)
+		if err != nil {
+			return err
+		}
+		t.prompt.SetState(&prompt.State{
+			List:        list,
+			SearchMode:  false,
+			SearchStr:   "",
+			SearchLabel: "Files",
+		})
+	case *git.DiffDelta:
+		if t.selected == nil {
+			return nil
+		}
+		showDeltas(t.prompt, "Diff of "+t.selected.Shorthand, t.deltas, item.(*git.DiffDelta))
+	}
+	return nil
+}
+
+func (t *tag) tagInfo(item interface{}) [][]term.Cell {
+	grid := make([][]term.Cell, 0)
+	tg, ok := item.(*git.Tag)
+	if !ok {
+		return grid
+	}
+	if tg.Annotated && tg.Tagger != nil {
+		cells := term.Cprint("Tagger ", color.Faint)
+		cells = append(cells, term.Cprint(tg.Tagger.Name+" <"+tg.Tagger.Email+">", color.FgWhite)...)
+		grid = append(grid, cells)
+	} else {
+		grid = append(grid, term.Cprint("Lightweight tag", color.Faint))
+	}
+	cells := term.Cprint("When", color.Faint)
+	cells = append(cells, term.Cprint("   "+timeago.FromTime(tg.When()), color.FgWhite)...)
+	grid = append(grid, cells)
+	if c := tg.Target(); c != nil {
+		cells = term.Cprint("Commit ", color.Faint)
+		cells = append(cells, term.Cprint(c.Hash[:7], color.FgYellow)...)
+		cells = append(cells, term.Cprint(" "+c.Summary, color.FgWhite)...)
+		grid = append(grid, cells)
+	}
+	if msg := strings.TrimSpace(tg.Message); len(msg) > 0 {
+		grid = append(grid, term.Cprint(strings.SplitN(msg, "\n", 2)[0], color.FgWhite))
+	}
+	if len(tg.Signature) == 0 {
+		return grid
+	}
+	result, ok := t.signatures[tg.TagHash]
+	if !ok {
+		t.verifySignature(tg)
+	}
+	switch {
+	case result == nil:
+		grid = append(grid, term.Cprint("Verifying the signature...", color.Faint))
+	case result.status == git.SignatureGood:
+		cells = term.Cprint("Good signature from ", color.Faint)
+		cells = append(cells, term.Cprint(result.signer, color.FgGreen)...)
+		grid = append(grid, cells)
+	case result.status == git.SignatureBad:
+		grid = append(grid, term.Cprint("BAD signature", color.FgRed, color.Bold))
+	default:
+		grid = append(grid, term.Cprint("Signed, but the signature could not be verified", color.FgYellow))
+	}
+	return grid
+}
+
+// verifySignature runs gpg or ssh-keygen in the background since they are
+// slow to draw the screen with, the result is kept through the reloads
+func (t *tag) verifySignature(tg *git.Tag) {
+	hash := tg.TagHash
+	t.signatures[hash] = nil
+	payload, err := tg.SignedPayload()
+	if err != nil {
+		t.signatures[hash] = &signatureResult{status: git.SignatureUnverified}
+		return
+	}
+	verifier := t.repository.SignatureVerifier()
+	signature := []byte(tg.Signature)
+	go func() {
+		status, signer := verifier.Verify(payload, signature)
+		t.prompt.Post(func() error {
+			t.signatures[hash] = &signatureResult{status: status, signer: signer}
+			return nil
+		})
+	}()
+}
+
+func (t *tag) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'a',
+			Display: "a",
+			Desc:    "create annotated tag",
+			Handler: t.createAnnotated,
+		},
+		&prompt.KeyBinding{
+			Key:     't',
+			Display: "t",
+			Desc:    "create lightweight tag",
+			Handler: t.createLightweight,
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "delete tag",
+			Handler: t.delete,
+		},
+		&prompt.KeyBinding{
+			Key:     's',
+			Display: "s",
+			Desc:    "toggle sort by date/semver",
+			Handler: t.toggleSort,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: t.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := t.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tag) createAnnotated(item interface{}) error {
+	if _, ok := item.(*git.Tag); !ok {
+		return nil
+	}
+	t.readTarget(func(c *git.Commit, name string) error {
+		t.prompt.ReadInput("Tag message:", "", func(msg string) error {
+			if len(strings.TrimSpace(msg)) == 0 {
+				t.prompt.SetMessage(term.Cprint("Aborting due to empty tag message.", color.FgRed))
+				return nil
+			}
+			if err := t.repository.CreateTag(name, c, msg); err != nil {
+				t.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			return t.reloadTags()
+		})
+		return nil
+	})
+	return nil
+}
+
+func (t *tag) createLightweight(item interface{}) error {
+	if _, ok := item.(*git.Tag); !ok {
+		return nil
+	}
+	t.readTarget(func(c *git.Commit, name string) error {
+		if err := t.repository.CreateLightweightTag(name, c); err != nil {
+			t.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return t.reloadTags()
+	})
+	return nil
+}
+
+// readTarget asks for the commit to be tagged and the name of the tag
+func (t *tag) readTarget(handler func(*git.Commit, string) error) {
+	t.prompt.ReadInput("Tag commit:", "HEAD", func(rev string) error {
+		c, err := t.repository.LookupCommit(rev)
+		if err != nil {
+			t.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		t.prompt.ReadInput("Tag name:", "", func(name string) error {
+			if len(name) == 0 {
+				return nil
+			}
+			return handler(c, name)
+		})
+		return nil
+	})
+}
+
+func (t *tag) delete(item interface{}) error {
+	tg, ok := item.(*git.Tag)
+	if !ok {
+		return nil
+	}
+	if err := tg.Delete(); err != nil {
+		t.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return t.reloadTags()
+}
+
+func (t *tag) toggleSort(item interface{}) error {
+	if _, ok := item.(*git.Tag); !ok {
+		return nil
+	}
+	if t.sortBy == git.TagSortDate {
+		t.sortBy = git.TagSortSemver
+	} else {
+		t.sortBy = git.TagSortDate
+	}
+	return t.reloadTags()
+}
+
+func (t *tag) quit(item interface{}) error {
+	switch item.(type) {
+	case *git.Tag:
+		t.prompt.Stop()
+	case *git.DiffDelta:
+		t.prompt.SetState(t.oldState)
+	}
+	return nil
+}
+
+// reloads the list
+func (t *tag) reloadTags() error {
+	tags, err := t.repository.Tags()
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		t.prompt.Stop()
+		t.prompt.SetExitMsg([][]term.Cell{term.Cprint("No tags left.", color.Faint)})
+		return nil
+	}
+	git.SortTags(tags, t.sortBy)
+	state := t.prompt.State()
+	list, err := prompt.NewList(tags, state.ListSize)
+	if err != nil {
+		return fmt.Errorf("could not reload tags: %v", err)
+	}
+	state.List = list
+	t.prompt.SetState(state)
+	return nil
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/git"
+	"github.com/isacikgoz/gitin/prompt"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// worktree holds the repository struct and the prompt pointer.
+type worktree struct {
+	repository *git.Repository
+	prompt     *prompt.Prompt
+}
+
+// WorktreePrompt configures a prompt to list the worktrees of the repository
+func WorktreePrompt(r *git.Repository, opts *prompt.Options) (*prompt.Prompt, error) {
+	worktrees, err := r.Worktrees()
+	if err != nil {
+		return nil, fmt.Errorf("could not load worktrees: %v", err)
+	}
+	list, err := prompt.NewList(worktrees, opts.LineSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not create list: %v", err)
+	}
+	w := &worktree{repository: r}
+	w.prompt = prompt.Create("Worktrees", opts, list,
+		prompt.WithSelectionHandler(w.onSelect),
+		prompt.WithItemRenderer(w.renderItem),
+		prompt.WithInformation(w.info),
+	)
+	if err := w.defineKeybindings(); err != nil {
+		return nil, err
+	}
+	return w.prompt, nil
+}
+
+// onSelect quits with the path of the worktree to change into it
+func (w *worktree) onSelect(item interface{}) error {
+	wt, ok := item.(*git.Worktree)
+	if !ok || wt.Prunable {
+		return nil
+	}
+	w.prompt.Stop()
+	w.prompt.SetExitMsg([][]term.Cell{
+		term.Cprint("cd "+wt.Path, color.Faint),
+	})
+	return nil
+}
+
+func (w *worktree) defineKeybindings() error {
+	keybindings := []*prompt.KeyBinding{
+		&prompt.KeyBinding{
+			Key:     'a',
+			Display: "a",
+			Desc:    "add worktree for a branch",
+			Handler: w.add,
+		},
+		&prompt.KeyBinding{
+			Key:     'd',
+			Display: "d",
+			Desc:    "remove worktree",
+			Handler: w.remove(false),
+		},
+		&prompt.KeyBinding{
+			Key:     'D',
+			Display: "D",
+			Desc:    "force remove worktree",
+			Handler: w.remove(true),
+		},
+		&prompt.KeyBinding{
+			Key:     'L',
+			Display: "L",
+			Desc:    "lock/unlock worktree",
+			Handler: w.toggleLock,
+		},
+		&prompt.KeyBinding{
+			Key:     'p',
+			Display: "p",
+			Desc:    "prune worktree",
+			Handler: w.prune,
+		},
+		&prompt.KeyBinding{
+			Key:     'q',
+			Display: "q",
+			Desc:    "quit",
+			Handler: w.quit,
+		},
+	}
+	for _, kb := range keybindings {
+		if err := w.prompt.AddKeyBinding(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add asks for a branch and a path, the path defaults to a sibling of the
+// main worktree named after the branch
+func (w *worktree) add(item interface{}) error {
+	w.prompt.ReadInput("Branch:", "", func(name string) error {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			return nil
+		}
+		branch, err := w.findBranch(name)
+		if err != nil {
+			w.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		root := w.repository.Path()
+		if worktrees, err := w.repository.Worktrees(); err == nil && len(worktrees) > 0 && worktrees[0].Main {
+			root = worktrees[0].Path
+		}
+		base := filepath.Base(root) + "-" + strings.Replace(name[strings.LastIndex(name, "/")+1:], "/", "-", -1)
+		suggestion := filepath.Join(filepath.Dir(root), base)
+		w.prompt.ReadInput("Path:", suggestion, func(path string) error {
+			path = strings.TrimSpace(path)
+			if len(path) == 0 {
+				return nil
+			}
+			if _, err := w.repository.AddWorktree(path, branch); err != nil {
+				w.prompt.SetMessage(errorText(err))
+				return nil
+			}
+			return w.reloadWorktrees()
+		})
+		return nil
+	})
+	return nil
+}
+
+// findBranch finds the local or remote-tracking branch with the name
+func (w *worktree) findBranch(name string) (*git.Branch, error) {
+	branches, err := w.repository.Branches()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range branches {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("branch %s not found", name)
+}
+
+// remove removes the worktree, the forced removal discards the changes in
+// the worktree so it is confirmed first
+func (w *worktree) remove(force bool) func(interface{}) error {
+	return func(item interface{}) error {
+		wt, ok := item.(*git.Worktree)
+		if !ok {
+			return nil
+		}
+		if !force {
+			return w.removeWorktree(wt, false)
+		}
+		label := "Remove " + wt.Path + " and discard its changes? (y/N)"
+		w.prompt.ReadInput(label, "", func(answer string) error {
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				return nil
+			}
+			return w.removeWorktree(wt, true)
+		})
+		return nil
+	}
+}
+
+func (w *worktree) removeWorktree(wt *git.Worktree, force bool) error {
+	if err := wt.Remove(force); err != nil {
+		if err == git.ErrWorktreeDirty {
+			err = fmt.Errorf("%v, use D to remove it anyway", err)
+		}
+		w.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return w.reloadWorktrees()
+}
+
+// toggleLock unlocks a locked worktree or locks it with a reason
+func (w *worktree) toggleLock(item interface{}) error {
+	wt, ok := item.(*git.Worktree)
+	if !ok {
+		return nil
+	}
+	if wt.Locked {
+		if err := wt.Unlock(); err != nil {
+			w.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return w.reloadWorktrees()
+	}
+	w.prompt.ReadInput("Lock reason:", "", func(reason string) error {
+		if err := wt.Lock(strings.TrimSpace(reason)); err != nil {
+			w.prompt.SetMessage(errorText(err))
+			return nil
+		}
+		return w.reloadWorktrees()
+	})
+	return nil
+}
+
+func (w *worktree) prune(item interface{}) error {
+	wt, ok := item.(*git.Worktree)
+	if !ok {
+		return nil
+	}
+	if err := wt.Prune(); err != nil {
+		w.prompt.SetMessage(errorText(err))
+		return nil
+	}
+	return w.reloadWorktrees()
+}
+
+func (w *worktree) quit(item interface{}) error {
+	w.prompt.Stop()
+	return nil
+}
+
+// reloads the list
+func (w *worktree) reloadWorktrees() error {
+	worktrees, err := w.repository.Worktrees()
+	if err != nil {
+		return err
+	}
+	state := w.prompt.State()
+	list, err := prompt.NewList(worktrees, state.ListSize)
+	if err != nil {
+		return fmt.Errorf("could not reload worktrees: %v", err)
+	}
+	state.List = list
+	w.prompt.SetState(state)
+	return nil
+}
+
+func (w *worktree) info(item interface{}) [][]term.Cell {
+	wt, ok := item.(*git.Worktree)
+	if !ok {
+		return nil
+	}
+	grid := make([][]term.Cell, 0)
+	cells := term.Cprint("Path ", color.Faint)
+	cells = append(cells, term.Cprint(wt.Path, color.FgWhite)...)
+	grid = append(grid, cells)
+	cells = term.Cprint("HEAD ", color.Faint)
+	if len(wt.Branch) > 0 {
+		cells = append(cells, term.Cprint(wt.Branch+" ", color.FgYellow)...)
+	} else {
+		cells = append(cells, term.Cprint("detached ", color.FgYellow)...)
+	}
+	cells = append(cells, term.Cprint(shortHash(wt.Hash), color.FgWhite)...)
+	grid = append(grid, cells)
+	switch {
+	case wt.Locked && len(wt.LockReason) > 0:
+		grid = append(grid, term.Cprint("Locked: "+wt.LockReason, color.FgYellow))
+	case wt.Prunable:
+		grid = append(grid, term.Cprint("The working tree is missing, press p to prune it", color.Faint))
+	case wt.Dirty:
+		grid = append(grid, term.Cprint("Has uncommitted changes", color.FgRed))
+	}
+	return grid
+}
+
+func (w *worktree) renderItem(item interface{}, matches []int, selected bool) [][]term.Cell {
+	wt, ok := item.(*git.Worktree)
+	if !ok {
+		return renderItem(item, matches, selected)
+	}
+	var line []term.Cell
+	if selected {
+		line = append(line, term.Cprint("> ", color.FgCyan)...)
+	} else {
+		line = append(line, term.Cprint("  ", color.FgWhite)...)
+	}
+	head := wt.Branch
+	if len(head) == 0 {
+		head = shortHash(wt.Hash)
+	}
+	line = append(line, stautsText(head)...)
+	attr := color.FgWhite
+	if wt.Current {
+		attr = color.FgGreen
+	} else if wt.Prunable {
+		attr = color.Faint
+	}
+	line = append(line, highLightedText(matches, attr, wt.String())...)
+	var flags []string
+	if wt.Main {
+		flags = append(flags, "main")
+	}
+	if wt.Locked {
+		flags = append(flags, "locked")
+	}
+	if wt.Prunable {
+		flags = append(flags, "prunable")
+	}
+	if wt.Dirty {
+		flags = append(flags, "dirty")
+	}
+	if len(flags) > 0 {
+		line = append(line, term.Cprint(" ("+strings.Join(flags, ", ")+")", color.Faint)...)
+	}
+	return [][]term.Cell{line}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/isacikgoz/gitin/cli"
 	"github.com/isacikgoz/gitin/git"
@@ -13,6 +14,26 @@ import (
 	pin "gopkg.in/alecthomas/kingpin.v2"
 )
 
+var (
+	tagSort   *string
+	logOpts   = &git.LogOptions{}
+	logSince  *string
+	logUntil  *string
+	logFollow *bool
+	filePath  *string
+	blamePath *string
+	blameRev  *string
+
+	branchOpts   = &cli.BranchOptions{}
+	branchSort   *string
+	branchFilter *string
+
+	reflogRef *string
+
+	rebaseInteractive *bool
+	rebaseUpstream    *string
+)
+
 func main() {
 	mode := evalArgs()
 	pwd, _ := os.Getwd()
@@ -24,6 +45,9 @@ func main() {
 	err = env.Process("gitin", &o)
 	exitIfError(err)
 
+	logOpts.Paths, err = relativePaths(r, logOpts.Paths)
+	exitIfError(err)
+
 	var p *prompt.Prompt
 
 	// cli package is for responsible to create and configure a prompt
@@ -31,16 +55,75 @@ func main() {
 	case "status":
 		p, err = cli.StatusPrompt(r, &o)
 	case "log":
-		p, err = cli.LogPrompt(r, &o)
+		if *logFollow && len(logOpts.Paths) == 1 {
+			p, err = cli.HistoryPrompt(r, &o, logOpts.Paths[0])
+			break
+		}
+		p, err = cli.LogPrompt(r, &o, logOpts)
+	case "history":
+		var path string
+		path, err = r.RelativePath(*filePath)
+		exitIfError(err)
+		p, err = cli.HistoryPrompt(r, &o, path)
+	case "blame":
+		var path string
+		path, err = r.RelativePath(*blamePath)
+		exitIfError(err)
+		p, err = cli.BlamePrompt(r, &o, path, *blameRev)
+	case "reflog":
+		p, err = cli.ReflogPrompt(r, &o, *reflogRef)
+	case "rebase":
+		if !*rebaseInteractive {
+			exitIfError(fmt.Errorf("only interactive rebases are supported, use -i"))
+		}
+		p, err = cli.RebasePrompt(r, &o, *rebaseUpstream)
+	case "bisect":
+		p, err = cli.BisectPrompt(r, &o)
 	case "branch":
-		p, err = cli.BranchPrompt(r, &o)
+		switch *branchSort {
+		case "name":
+			branchOpts.Sort = git.BranchSortName
+		case "ahead-behind":
+			branchOpts.Sort = git.BranchSortAheadBehind
+		}
+		switch *branchFilter {
+		case "merged":
+			branchOpts.Filter = git.BranchFilterMerged
+		case "unmerged":
+			branchOpts.Filter = git.BranchFilterUnmerged
+		case "gone":
+			branchOpts.Filter = git.BranchFilterGone
+		}
+		p, err = cli.BranchPrompt(r, &o, branchOpts)
+	case "stash":
+		p, err = cli.StashPrompt(r, &o)
+	case "remote":
+		p, err = cli.RemotePrompt(r, &o)
+	case "worktree":
+		p, err = cli.WorktreePrompt(r, &o)
+	case "submodule":
+		p, err = cli.SubmodulePrompt(r, &o)
+	case "tag":
+		sortBy := git.TagSortDate
+		if *tagSort == "semver" {
+			sortBy = git.TagSortSemver
+		}
+		p, err = cli.TagPrompt(r, &o, sortBy)
 	default:
 		return
 	}
 
 	exitIfError(err)
 	ctx := context.Background()
+	state := r.State()
 	exitIfError(p.Run(ctx))
+
+	if (mode == "rebase" || mode == "log" || mode == "branch") && cli.OperationStopped(state, r.State()) {
+		// the rebase, merge, cherry-pick or revert is stopped for conflicts or edits
+		p, err = cli.StatusPrompt(r, &o)
+		exitIfError(err)
+		exitIfError(p.Run(ctx))
+	}
 }
 
 func exitIfError(err error) {
@@ -52,9 +135,39 @@ func exitIfError(err error) {
 
 // define the program commands and args
 func evalArgs() string {
-	pin.Command("log", "Show commit logs.")
+	log := pin.Command("log", "Show commit logs, optionally limited to a revision range and paths given after --.")
+	log.Flag("all", "Show the commits of every reference.").BoolVar(&logOpts.All)
+	log.Flag("branches", "Show the commits of every local branch.").BoolVar(&logOpts.Branches)
+	log.Flag("author", "Show the commits of the authors matching the pattern.").StringVar(&logOpts.Author)
+	log.Flag("grep", "Show the commits with a message matching the pattern.").StringVar(&logOpts.Grep)
+	logSince = log.Flag("since", "Show the commits more recent than the date e.g. 2006-01-02 or \"2 weeks ago\".").String()
+	logUntil = log.Flag("until", "Show the commits older than the date.").String()
+	log.Flag("no-merges", "Do not show the merge commits.").BoolVar(&logOpts.NoMerges)
+	log.Flag("first-parent", "Follow only the first parent of the merge commits.").BoolVar(&logOpts.FirstParent)
+	logFollow = log.Flag("follow", "Show the history of a single file beyond renames.").Bool()
+	log.Arg("revision-range", "Revisions or ranges to show e.g. main..feature, a...b or ^a.").StringsVar(&logOpts.Revisions)
+	history := pin.Command("history", "Show the commits that changed a file, following renames.")
+	filePath = history.Arg("file", "Path of the file.").Required().String()
+	blame := pin.Command("blame", "Show the commits that last changed the lines of a file.")
+	blamePath = blame.Arg("file", "Path of the file.").Required().String()
+	blameRev = blame.Arg("revision", "Revision to blame the file at, HEAD by default.").String()
+	reflog := pin.Command("reflog", "Show the reflog of HEAD or a branch. Also recover the lost commits.")
+	reflogRef = reflog.Arg("ref", "Reference to show the reflog of, HEAD by default.").String()
+	rebase := pin.Command("rebase", "Plan an interactive rebase of the commits that are not in the upstream.")
+	rebaseInteractive = rebase.Flag("interactive", "Reorder, reword, squash, fixup or drop the commits.").Short('i').Bool()
+	rebaseUpstream = rebase.Arg("upstream", "Branch or commit to rebase onto.").Required().String()
+	pin.Command("bisect", "Find the commit that introduced a bug by marking the commits good or bad, or by running a command.")
 	pin.Command("status", "Show working-tree status. Also stage and commit changes.")
-	pin.Command("branch", "Show list of branches.")
+	branch := pin.Command("branch", "Show list of branches.")
+	branchSort = branch.Flag("sort", "Sort branches by last commit date, name or ahead/behind counts.").Default("date").Enum("date", "name", "ahead-behind")
+	branchFilter = branch.Flag("filter", "Show only the branches merged or not merged into HEAD, or whose upstream is gone.").Default("all").Enum("all", "merged", "unmerged", "gone")
+	branch.Flag("group", "Group branches by local, remote and stale.").BoolVar(&branchOpts.Group)
+	pin.Command("stash", "Show list of stashes. Also apply, pop or drop them.")
+	pin.Command("remote", "Show list of remotes. Also fetch them or manage them.")
+	pin.Command("worktree", "Show list of worktrees. Also add, remove, lock or prune them.")
+	pin.Command("submodule", "Show list of submodules. Also init, update, sync or descend into them.")
+	tag := pin.Command("tag", "Show list of tags. Also create or delete them.")
+	tagSort = tag.Flag("sort", "Sort tags by tagger date or semantic version.").Default("date").Enum("date", "semver")
 
 	pin.Version("gitin version 0.3.0")
 
@@ -62,7 +175,49 @@ func evalArgs() string {
 	pin.CommandLine.HelpFlag.Short('h')
 	pin.CommandLine.VersionFlag.Short('v')
 
-	return pin.Parse()
+	args, paths := splitPaths(os.Args[1:])
+	mode := pin.MustParse(pin.CommandLine.Parse(args))
+	logOpts.Paths = paths
+	now := time.Now()
+	if len(*logSince) > 0 {
+		since, err := git.ParseDate(*logSince, now)
+		pin.FatalIfError(err, "--since")
+		logOpts.Since = since
+	}
+	if len(*logUntil) > 0 {
+		until, err := git.ParseDate(*logUntil, now)
+		pin.FatalIfError(err, "--until")
+		logOpts.Until = until
+	}
+	return mode
+}
+
+// relativePaths converts the paths given by the user to the paths in the
+// repository
+func relativePaths(r *git.Repository, paths []string) ([]string, error) {
+	rel := make([]string, 0, len(paths))
+	for _, path := range paths {
+		p, err := r.RelativePath(path)
+		if err != nil {
+			return nil, err
+		}
+		if p == "." {
+			// the root of the repository does not limit the commits
+			return nil, nil
+		}
+		rel = append(rel, p)
+	}
+	return rel, nil
+}
+
+// splitPaths separates the paths after "--" from the rest of the args
+func splitPaths(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
 }
 
 func additionalHelp() string {
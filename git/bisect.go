@@ -0,0 +1,456 @@
+package git
+
+import (
+	"fmt"
+	"math/bits"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// BisectTerm is the verdict on a commit that is tested by a bisect
+type BisectTerm uint8
+
+// The terms of "git bisect"
+const (
+	BisectGood BisectTerm = iota
+	BisectBad
+	BisectSkip
+)
+
+func (t BisectTerm) String() string {
+	switch t {
+	case BisectBad:
+		return "bad"
+	case BisectSkip:
+		return "skip"
+	}
+	return "good"
+}
+
+// bisectExactLimit is the number of remaining commits up to which the
+// commits they contain are counted to find the midpoint, the topological
+// order is used beyond it
+const bisectExactLimit = 4096
+
+// Bisect is a binary search for the commit that introduced a bug like
+// "git bisect". The state is kept in the refs/bisect references and the
+// BISECT_* files of the git directory so git can continue or replay it.
+type Bisect struct {
+	owner *Repository
+
+	Bad       *Commit
+	Good      []*Commit
+	Skipped   []*Commit
+	Remaining []*Commit // the commits that can be the first bad one, newest first
+	Current   *Commit   // the commit to test, nil if the bisect is waiting or done
+	FirstBad  *Commit
+}
+
+// StartBisect is the wrapper of "git bisect start", the bisect continues
+// when a bad and a good commit are marked
+func (r *Repository) StartBisect() (*Bisect, error) {
+	if r.State() == StateBisect {
+		return r.Bisect()
+	}
+	if r.State().InProgress() {
+		return nil, ErrOperationInProgress
+	}
+	head, err := r.essence.Head()
+	if err != nil {
+		return nil, err
+	}
+	start := head.Target().String()
+	if head.IsBranch() {
+		start = head.Shorthand()
+	}
+	head.Free()
+	files := map[string]string{
+		"BISECT_START": start + "\n",
+		"BISECT_TERMS": "bad\ngood\n",
+		"BISECT_NAMES": "\n",
+		"BISECT_LOG":   "git bisect start\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(r.bisectFile(name), []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return &Bisect{owner: r}, nil
+}
+
+// Bisect loads the bisect in progress
+func (r *Repository) Bisect() (*Bisect, error) {
+	if _, err := os.Stat(r.bisectFile("BISECT_START")); os.IsNotExist(err) {
+		return nil, ErrNotBisecting
+	}
+	b := &Bisect{owner: r}
+	iter, err := r.essence.NewReferenceIteratorGlob("refs/bisect/*")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Free()
+	for {
+		ref, err := iter.Next()
+		if lib.IsErrorCode(err, lib.ErrorCodeIterOver) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(ref.Name(), "refs/bisect/")
+		commit, err := r.essence.LookupCommit(ref.Target())
+		ref.Free()
+		if err != nil {
+			return nil, err
+		}
+		c := unpackRawCommit(r, commit)
+		switch {
+		case name == "bad":
+			b.Bad = c
+		case strings.HasPrefix(name, "good-"):
+			b.Good = append(b.Good, c)
+		case strings.HasPrefix(name, "skip-"):
+			b.Skipped = append(b.Skipped, c)
+		}
+	}
+	if err := b.findMidpoint(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// bisectFile returns the path of the file in the git directory of the
+// worktree, every worktree can bisect on its own
+func (r *Repository) bisectFile(name string) string {
+	return filepath.Join(r.essence.Path(), name)
+}
+
+// Steps estimates the number of commits left to test
+func (b *Bisect) Steps() int {
+	if len(b.Remaining) < 2 {
+		return 0
+	}
+	return bits.Len(uint(len(b.Remaining) - 1))
+}
+
+// Mark is the wrapper of "git bisect good|bad|skip <commit>", the next
+// commit to test is checked out as a detached HEAD
+func (b *Bisect) Mark(c *Commit, term BisectTerm) error {
+	r := b.owner
+	if err := b.checkRange(c, term); err != nil {
+		return err
+	}
+	oid, err := lib.NewOid(c.Hash)
+	if err != nil {
+		return err
+	}
+	name := "refs/bisect/bad"
+	if term != BisectBad {
+		name = "refs/bisect/" + term.String() + "-" + c.Hash
+	}
+	ref, err := r.essence.References.Create(name, oid, true, "")
+	if err != nil {
+		return err
+	}
+	ref.Free()
+	switch term {
+	case BisectBad:
+		b.Bad = c
+	case BisectGood:
+		b.Good = append(b.Good, c)
+	case BisectSkip:
+		b.Skipped = append(b.Skipped, c)
+	}
+	if err := b.appendLog(
+		fmt.Sprintf("# %s: [%s] %s", term, c.Hash, c.Summary),
+		"git bisect "+term.String()+" "+c.Hash,
+	); err != nil {
+		return err
+	}
+	return b.next()
+}
+
+// checkRange refuses the good commits that the bad commit does not contain
+// since the first bad commit would be searched in an unrelated history
+func (b *Bisect) checkRange(c *Commit, term BisectTerm) error {
+	r := b.owner
+	switch {
+	case term == BisectGood && b.Bad != nil:
+		ok, err := r.reachable(c.Hash, b.Bad.Hash)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrBisectRange
+		}
+	case term == BisectBad:
+		for _, good := range b.Good {
+			ok, err := r.reachable(good.Hash, c.Hash)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrBisectRange
+			}
+		}
+	}
+	return nil
+}
+
+// next checks out the commit to test, or logs the first bad commit if it is
+// found
+func (b *Bisect) next() error {
+	if err := b.findMidpoint(); err != nil {
+		return err
+	}
+	switch {
+	case b.FirstBad != nil:
+		return b.appendLog(fmt.Sprintf("# first bad commit: [%s] %s", b.FirstBad.Hash, b.FirstBad.Summary))
+	case b.Current == nil && len(b.Remaining) > 0:
+		lines := []string{"# only skipped commits left to test"}
+		for _, c := range b.Remaining {
+			lines = append(lines, fmt.Sprintf("# possible first bad commit: [%s] %s", c.Hash, c.Summary))
+		}
+		return b.appendLog(lines...)
+	case b.Current == nil:
+		// waiting for a good or a bad commit
+		return nil
+	}
+	r := b.owner
+	if err := r.checkoutCommit(b.Current.essence, CheckoutSafe); err != nil {
+		return err
+	}
+	oid, err := lib.NewOid(b.Current.Hash)
+	if err != nil {
+		return err
+	}
+	if err := r.essence.SetHeadDetached(oid); err != nil {
+		return err
+	}
+	return os.WriteFile(r.bisectFile("BISECT_EXPECTED_REV"), []byte(b.Current.Hash+"\n"), 0644)
+}
+
+// findMidpoint lists the commits of the bad commit that the good ones do not
+// contain and picks the one that splits them in two halves. A commit that is
+// found bad leaves the commits it contains, a good one leaves the others.
+func (b *Bisect) findMidpoint() error {
+	b.Remaining, b.Current, b.FirstBad = nil, nil, nil
+	if b.Bad == nil || len(b.Good) == 0 {
+		return nil
+	}
+	r := b.owner
+	walk, err := r.essence.Walk()
+	if err != nil {
+		return err
+	}
+	defer walk.Free()
+	walk.Sorting(lib.SortTopological)
+	oid, err := lib.NewOid(b.Bad.Hash)
+	if err != nil {
+		return err
+	}
+	if err := walk.Push(oid); err != nil {
+		return err
+	}
+	for _, good := range b.Good {
+		oid, err := lib.NewOid(good.Hash)
+		if err != nil {
+			return err
+		}
+		if err := walk.Hide(oid); err != nil {
+			return err
+		}
+	}
+	commits := make([]*Commit, 0)
+	if err := walk.Iterate(func(commit *lib.Commit) bool {
+		commits = append(commits, unpackRawCommit(r, commit))
+		return true
+	}); err != nil {
+		return err
+	}
+	b.Remaining = commits
+
+	skipped := make(map[string]bool)
+	for _, c := range b.Skipped {
+		skipped[c.Hash] = true
+	}
+	weights := bisectWeights(commits)
+	best, score := -1, -1
+	for i, c := range commits {
+		if c.Hash == b.Bad.Hash || skipped[c.Hash] {
+			continue
+		}
+		s := weights[i]
+		if len(commits)-weights[i] < s {
+			s = len(commits) - weights[i]
+		}
+		if s > score {
+			best, score = i, s
+		}
+	}
+	switch {
+	case best >= 0:
+		b.Current = commits[best]
+	case len(commits) == 1:
+		b.FirstBad = commits[0]
+	}
+	return nil
+}
+
+// bisectWeights counts the commits that each commit contains, the commits
+// are in topological order so the parents follow their children
+func bisectWeights(commits []*Commit) []int {
+	n := len(commits)
+	weights := make([]int, n)
+	if n > bisectExactLimit {
+		for i := range commits {
+			weights[i] = n - i
+		}
+		return weights
+	}
+	index := make(map[string]int, n)
+	for i, c := range commits {
+		index[c.Hash] = i
+	}
+	words := (n + 63) / 64
+	ancestors := make([][]uint64, n)
+	for i := n - 1; i >= 0; i-- {
+		set := make([]uint64, words)
+		set[i/64] |= 1 << (uint(i) % 64)
+		for _, parent := range commits[i].ParentHashes() {
+			if j, ok := index[parent]; ok {
+				for w := range set {
+					set[w] |= ancestors[j][w]
+				}
+			}
+		}
+		ancestors[i] = set
+		for _, w := range set {
+			weights[i] += bits.OnesCount64(w)
+		}
+	}
+	return weights
+}
+
+// Test runs the command on the checked out commit like "git bisect run", the
+// exit code 0 means good, 125 skip and the others up to 127 bad
+func (b *Bisect) Test(command string) (BisectTerm, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = b.owner.path
+	err := cmd.Run()
+	if err == nil {
+		return BisectGood, nil
+	}
+	exit, ok := err.(*exec.ExitError)
+	if !ok {
+		return BisectSkip, err
+	}
+	switch code := exit.ExitCode(); {
+	case code == 125:
+		return BisectSkip, nil
+	case code > 0 && code < 128:
+		return BisectBad, nil
+	default:
+		return BisectSkip, fmt.Errorf("bisect run failed, %q exited with %d", command, code)
+	}
+}
+
+// Log is the wrapper of "git bisect log", the output can be replayed with
+// "git bisect replay"
+func (b *Bisect) Log() (string, error) {
+	data, err := os.ReadFile(b.owner.bisectFile("BISECT_LOG"))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (b *Bisect) appendLog(lines ...string) error {
+	f, err := os.OpenFile(b.owner.bisectFile("BISECT_LOG"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Reset is the wrapper of "git bisect reset", the branch or the commit that
+// was checked out before the bisect is checked out again
+func (b *Bisect) Reset() error {
+	r := b.owner
+	data, err := os.ReadFile(r.bisectFile("BISECT_START"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if start := strings.TrimSpace(string(data)); len(start) > 0 {
+		if err := r.checkoutStart(start); err != nil {
+			return err
+		}
+	}
+	iter, err := r.essence.NewReferenceIteratorGlob("refs/bisect/*")
+	if err != nil {
+		return err
+	}
+	defer iter.Free()
+	for {
+		ref, err := iter.Next()
+		if lib.IsErrorCode(err, lib.ErrorCodeIterOver) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		err = ref.Delete()
+		ref.Free()
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{
+		"BISECT_START", "BISECT_TERMS", "BISECT_NAMES", "BISECT_LOG",
+		"BISECT_EXPECTED_REV", "BISECT_ANCESTORS_OK", "BISECT_RUN",
+	} {
+		if err := os.Remove(r.bisectFile(name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// HEAD has no branch to load if it was detached before the bisect
+	r.LoadHead()
+	return nil
+}
+
+// checkoutStart checks out the branch, or the commit if it is not a branch
+func (r *Repository) checkoutStart(start string) error {
+	if branch, err := r.essence.LookupBranch(start, lib.BranchLocal); err == nil {
+		defer branch.Free()
+		target, err := r.essence.LookupCommit(branch.Target())
+		if err != nil {
+			return err
+		}
+		defer target.Free()
+		if err := r.checkoutCommit(target, CheckoutSafe); err != nil {
+			return err
+		}
+		return r.essence.SetHead("refs/heads/" + start)
+	}
+	oid, err := lib.NewOid(start)
+	if err != nil {
+		return err
+	}
+	target, err := r.essence.LookupCommit(oid)
+	if err != nil {
+		return err
+	}
+	defer target.Free()
+	if err := r.checkoutCommit(target, CheckoutSafe); err != nil {
+		return err
+	}
+	return r.essence.SetHeadDetached(oid)
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBisect(t *testing.T) {
+	r := newTestRepository(t)
+	head := r.Head.Name
+	commits := make([]*Commit, 0)
+	contents := make(map[string]string)
+	for i := 0; i < 8; i++ {
+		c := commitTestFile(t, r, "file.txt", strconv.Itoa(i)+"\n")
+		commits = append(commits, c)
+		contents[c.Hash] = strconv.Itoa(i) + "\n"
+	}
+	// the bug is introduced by the sixth commit
+	firstBad := commits[5]
+	bad := make(map[string]bool)
+	for _, c := range commits[5:] {
+		bad[c.Hash] = true
+	}
+
+	b, err := r.StartBisect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.State() != StateBisect {
+		t.Errorf("expected the repository to be bisecting, got %v", r.State())
+	}
+	if err := b.Mark(commits[7], BisectBad); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Mark(commits[0], BisectGood); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Remaining) != 7 || b.Steps() != 3 {
+		t.Errorf("expected 7 commits in 3 steps, got %d in %d", len(b.Remaining), b.Steps())
+	}
+	for steps := 0; b.Current != nil; steps++ {
+		if steps > 3 {
+			t.Fatal("expected the first bad commit in 3 steps")
+		}
+		assertTestFile(t, r, "file.txt", contents[b.Current.Hash])
+		term := BisectGood
+		if bad[b.Current.Hash] {
+			term = BisectBad
+		}
+		if err := b.Mark(b.Current, term); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.FirstBad == nil || b.FirstBad.Hash != firstBad.Hash {
+		t.Fatalf("expected %s to be the first bad commit, got %v", firstBad.Hash, b.FirstBad)
+	}
+
+	loaded, err := r.Bisect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FirstBad == nil || loaded.FirstBad.Hash != firstBad.Hash {
+		t.Error("expected the loaded bisect to find the same commit")
+	}
+	log, err := b.Log()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"git bisect start",
+		"git bisect bad " + commits[7].Hash,
+		"git bisect good " + commits[0].Hash,
+		"# first bad commit: [" + firstBad.Hash + "]",
+	} {
+		if !strings.Contains(log, line) {
+			t.Errorf("expected the log to contain %q", line)
+		}
+	}
+
+	if err := b.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if r.State() != StateNone || r.Head.Name != head {
+		t.Errorf("expected to be back on %s", head)
+	}
+	if _, err := r.Bisect(); err != ErrNotBisecting {
+		t.Errorf("expected %v, got %v", ErrNotBisecting, err)
+	}
+}
+
+func TestBisectSteps(t *testing.T) {
+	var tests = []struct {
+		remaining int
+		steps     int
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 1},
+		{3, 2},
+		{8, 3},
+		{9, 4},
+	}
+	for _, test := range tests {
+		b := &Bisect{Remaining: make([]*Commit, test.remaining)}
+		if steps := b.Steps(); steps != test.steps {
+			t.Errorf("expected %d steps for %d commits, got %d", test.steps, test.remaining, steps)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Blame holds the commits that last changed the lines of a file
+type Blame struct {
+	Path     string
+	Revision string // the revision that the file is blamed at
+	Hunks    []*BlameHunk
+	Lines    []*BlameLine
+}
+
+// BlameHunk is a range of consecutive lines that are last changed by the
+// same commit
+type BlameHunk struct {
+	Hash     string
+	Author   *Signature
+	OrigPath string // path of the file in the commit
+	Boundary bool   // the commit is the oldest commit of the blame
+	Lines    []*BlameLine
+}
+
+// BlameLine is a line of the blamed file
+type BlameLine struct {
+	Number  int
+	Content string
+	Hunk    *BlameHunk
+}
+
+func (l *BlameLine) String() string {
+	return l.Content
+}
+
+// First returns true if the line is the first line of its hunk
+func (l *BlameLine) First() bool {
+	return l.Hunk.Lines[0] == l
+}
+
+// Blame annotates the lines of the file at the revision (or HEAD if it is
+// empty) with the commits that last changed them
+func (r *Repository) Blame(path, rev string) (*Blame, error) {
+	if len(rev) == 0 {
+		rev = "HEAD"
+	}
+	oid, err := r.revisionID(rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.essence.LookupCommit(oid)
+	if err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		return nil, ErrFileNotFound
+	}
+	content, err := r.blobContent(entry.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := lib.DefaultBlameOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts.NewestCommit = oid
+	blame, err := r.essence.BlameFile(path, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer blame.Free()
+
+	b := &Blame{
+		Path:     path,
+		Revision: rev,
+		Hunks:    make([]*BlameHunk, 0, blame.HunkCount()),
+		Lines:    make([]*BlameLine, 0),
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := 0; i < blame.HunkCount(); i++ {
+		h, err := blame.HunkByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		hunk := &BlameHunk{
+			Hash:     h.FinalCommitId.String(),
+			OrigPath: h.OrigPath,
+			Boundary: h.Boundary,
+		}
+		if h.FinalSignature != nil {
+			hunk.Author = &Signature{
+				Name:  h.FinalSignature.Name,
+				Email: h.FinalSignature.Email,
+				When:  h.FinalSignature.When,
+			}
+		}
+		start := int(h.FinalStartLineNumber)
+		for n := start; n < start+int(h.LinesInHunk) && n <= len(lines); n++ {
+			line := &BlameLine{Number: n, Content: lines[n-1], Hunk: hunk}
+			hunk.Lines = append(hunk.Lines, line)
+			b.Lines = append(b.Lines, line)
+		}
+		if len(hunk.Lines) > 0 {
+			b.Hunks = append(b.Hunks, hunk)
+		}
+	}
+	return b, nil
+}
+
+// BlameParent blames the file again at the parent of the commit that last
+// changed the line, so that the older changes of the line can be seen
+func (r *Repository) BlameParent(line *BlameLine) (*Blame, error) {
+	if line.Hunk.Boundary {
+		return nil, ErrNoParent
+	}
+	return r.Blame(line.Hunk.OrigPath, line.Hunk.Hash+"^")
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\nb\nc\n")
+	second := commitTestFile(t, r, "file.txt", "a\nB\nc\nd\n")
+
+	b, err := r.Blame("file.txt", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(b.Lines))
+	}
+	var tests = []struct {
+		content string
+		hash    string
+	}{
+		{"a", first.Hash},
+		{"B", second.Hash},
+		{"c", first.Hash},
+		{"d", second.Hash},
+	}
+	for i, test := range tests {
+		l := b.Lines[i]
+		if l.Number != i+1 || l.Content != test.content || l.Hunk.Hash != test.hash {
+			t.Errorf("line %d: expected %q of %s, got %q of %s", i+1, test.content, test.hash, l.Content, l.Hunk.Hash)
+		}
+	}
+
+	parent, err := r.BlameParent(b.Lines[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parent.Lines) != 3 || parent.Lines[1].Content != "b" || parent.Lines[1].Hunk.Hash != first.Hash {
+		t.Error("unexpected blame of the parent")
+	}
+	if _, err := r.BlameParent(parent.Lines[0]); err != ErrNoParent {
+		t.Errorf("expected %v, got %v", ErrNoParent, err)
+	}
+}
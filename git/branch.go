@@ -1,7 +1,9 @@
 package git
 
 import (
+	"sort"
 	"strings"
+	"time"
 
 	lib "github.com/libgit2/git2go/v33"
 )
@@ -21,8 +23,35 @@ type Branch struct {
 	Ahead    int
 	Behind   int
 	Upstream *Branch
+	Gone     bool // the upstream is configured but no longer exists
 }
 
+// BranchSort defines the order of the branches
+type BranchSort uint8
+
+// Branches can be sorted by the date of their last commit, their names or
+// how far they are from their upstreams
+const (
+	BranchSortDate BranchSort = iota
+	BranchSortName
+	BranchSortAheadBehind
+)
+
+// BranchFilter defines which branches are listed
+type BranchFilter uint8
+
+// Branches can be filtered by whether HEAD contains them or their upstream
+// is gone
+const (
+	BranchFilterNone BranchFilter = iota
+	BranchFilterMerged
+	BranchFilterUnmerged
+	BranchFilterGone
+)
+
+// StaleBranchAge is the age of the last commit after which a branch is stale
+const StaleBranchAge = 90 * 24 * time.Hour
+
 // Branches loads branches with the lib's branch iterator
 // loads both remote and local branches
 func (r *Repository) Branches() ([]*Branch, error) {
@@ -31,10 +60,12 @@ func (r *Repository) Branches() ([]*Branch, error) {
 		return nil, err
 	}
 	defer branchIter.Free()
+	// the branches are loaded again after a branch is created or deleted
+	r.clearRefs(RefTypeBranch)
 	buffer := make([]*Branch, 0)
 
 	err = branchIter.ForEach(func(branch *lib.Branch, branchType lib.BranchType) error {
-		b, err := unpackRawBranch(r.essence, branch)
+		b, err := unpackRawBranch(r, branch)
 		if err != nil {
 			return err
 		}
@@ -59,7 +90,8 @@ func (r *Repository) Branches() ([]*Branch, error) {
 	return buffer, err
 }
 
-func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
+func unpackRawBranch(repo *Repository, branch *lib.Branch) (*Branch, error) {
+	r := repo.essence
 	name, err := branch.Name()
 	if err != nil {
 		return nil, err
@@ -81,10 +113,13 @@ func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
 	isHead, _ := branch.IsHead()
 
 	var upstream *Branch
+	var gone bool
 	if !isRemote {
 		us, err := branch.Upstream()
 		if err != nil || us == nil {
-			// upstream not found
+			// the upstream is gone if it is still configured
+			_, err := r.UpstreamName(fullname)
+			gone = err == nil
 		} else {
 			var err error
 			ahead, behind, err = r.AheadBehind(branch.Reference.Target(), us.Target())
@@ -98,6 +133,7 @@ func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
 				Hash:     us.Target().String(),
 				isRemote: true,
 				essence:  us.Branch(),
+				owner:    repo,
 			}
 		}
 	}
@@ -106,6 +142,7 @@ func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
 		Name:     name,
 		refType:  RefTypeBranch,
 		essence:  branch,
+		owner:    repo,
 		FullName: fullname,
 		Hash:     hash,
 		isRemote: isRemote,
@@ -113,6 +150,7 @@ func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
 		Upstream: upstream,
 		Ahead:    ahead,
 		Behind:   behind,
+		Gone:     gone,
 	}
 	if isHead, _ := branch.IsHead(); isHead {
 		b.refType = RefTypeHEAD
@@ -120,6 +158,176 @@ func unpackRawBranch(r *lib.Repository, branch *lib.Branch) (*Branch, error) {
 	return b, nil
 }
 
+// CreateBranch is the wrapper of "git branch <name> <commit>"
+func (r *Repository) CreateBranch(name string, c *Commit) error {
+	branch, err := r.essence.CreateBranch(name, c.essence, false)
+	if err != nil {
+		return err
+	}
+	branch.Free()
+	return nil
+}
+
+// SortBranches sorts the branches, the recently committed ones, the ones
+// that diverged most from their upstreams or the names come first
+func SortBranches(branches []*Branch, by BranchSort) {
+	switch by {
+	case BranchSortDate:
+		sort.SliceStable(branches, func(i, j int) bool {
+			return branches[i].when().After(branches[j].when())
+		})
+	case BranchSortName:
+		sort.SliceStable(branches, func(i, j int) bool {
+			return branches[i].Name < branches[j].Name
+		})
+	case BranchSortAheadBehind:
+		sort.SliceStable(branches, func(i, j int) bool {
+			if branches[i].Ahead != branches[j].Ahead {
+				return branches[i].Ahead > branches[j].Ahead
+			}
+			return branches[i].Behind > branches[j].Behind
+		})
+	}
+}
+
+// FilterBranches returns the branches that pass the filter
+func (r *Repository) FilterBranches(branches []*Branch, by BranchFilter) ([]*Branch, error) {
+	if by == BranchFilterNone {
+		return branches, nil
+	}
+	filtered := make([]*Branch, 0)
+	for _, b := range branches {
+		var ok bool
+		switch by {
+		case BranchFilterMerged, BranchFilterUnmerged:
+			if r.Head == nil {
+				return nil, ErrBranchNotFound
+			}
+			merged, err := r.reachable(b.Hash, r.Head.Hash)
+			if err != nil {
+				return nil, err
+			}
+			ok = merged == (by == BranchFilterMerged)
+		case BranchFilterGone:
+			ok = b.Gone
+		}
+		if ok {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered, nil
+}
+
+// Stale returns true if the last commit of the branch is older than the
+// StaleBranchAge
+func (b *Branch) Stale() bool {
+	return !b.when().IsZero() && time.Since(b.when()) > StaleBranchAge
+}
+
+// when returns the date of the last commit of the branch
+func (b *Branch) when() time.Time {
+	if b.target == nil || b.target.Author == nil {
+		return time.Time{}
+	}
+	return b.target.Author.When
+}
+
+// Rename is the wrapper of "git branch --move <branch> <name>"
+func (b *Branch) Rename(name string) error {
+	if b.isRemote {
+		return ErrRemoteBranch
+	}
+	moved, err := b.essence.Move(name, false)
+	if err != nil {
+		return err
+	}
+	moved.Free()
+	return nil
+}
+
+// SetUpstream is the wrapper of "git branch --set-upstream-to=<upstream>",
+// the upstream is the short name of a remote-tracking branch e.g. "origin/main"
+func (b *Branch) SetUpstream(upstream string) error {
+	if b.isRemote {
+		return ErrRemoteBranch
+	}
+	return b.essence.SetUpstream(upstream)
+}
+
+// UnsetUpstream is the wrapper of "git branch --unset-upstream"
+func (b *Branch) UnsetUpstream() error {
+	if b.isRemote {
+		return ErrRemoteBranch
+	}
+	if b.Upstream == nil {
+		return ErrBranchNotFound
+	}
+	// libgit2 unsets the upstream with a null name which git2go cannot pass
+	cfg, err := b.owner.essence.Config()
+	if err != nil {
+		return err
+	}
+	defer cfg.Free()
+	for _, key := range []string{"remote", "merge"} {
+		if err := cfg.Delete("branch." + b.Name + "." + key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete is the wrapper of "git branch --delete <branch>", a branch that is
+// not merged into its upstream, or HEAD if it has none, is deleted only if
+// forced like "git branch -D <branch>"
+func (b *Branch) Delete(force bool) error {
+	if b.isRemote {
+		return ErrRemoteBranch
+	}
+	if b.Head {
+		return ErrDeleteHead
+	}
+	if !force {
+		merged, err := b.merged()
+		if err != nil {
+			return err
+		}
+		if !merged {
+			return ErrBranchNotMerged
+		}
+	}
+	return b.essence.Delete()
+}
+
+// merged returns true if the branch is reachable from its upstream, or from
+// HEAD if it has no upstream
+func (b *Branch) merged() (bool, error) {
+	into := b.Upstream
+	if into == nil {
+		into = b.owner.Head
+	}
+	if into == nil {
+		return false, nil
+	}
+	return b.owner.reachable(b.Hash, into.Hash)
+}
+
+// reachable returns true if the commit is the other commit or one of its
+// ancestors
+func (r *Repository) reachable(hash, from string) (bool, error) {
+	if hash == from {
+		return true, nil
+	}
+	oid, err := lib.NewOid(hash)
+	if err != nil {
+		return false, err
+	}
+	target, err := lib.NewOid(from)
+	if err != nil {
+		return false, err
+	}
+	return r.essence.DescendantOf(target, oid)
+}
+
 // Type is the reference type of this ref
 func (b *Branch) Type() RefType {
 	return b.refType
@@ -138,3 +346,20 @@ func (b *Branch) String() string {
 func (b *Branch) IsRemote() bool {
 	return b.isRemote
 }
+
+// RemoteName returns the name of the remote that the branch belongs to, or
+// the remote of its upstream for local branches
+func (b *Branch) RemoteName() string {
+	name := b.FullName
+	if !b.isRemote {
+		if b.Upstream == nil {
+			return ""
+		}
+		name = b.Upstream.FullName
+	}
+	remote, err := b.owner.essence.RemoteName(name)
+	if err != nil {
+		return ""
+	}
+	return remote
+}
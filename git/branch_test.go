@@ -0,0 +1,206 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBranchDelete(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	second := commitTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.CreateBranch("feature", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateBranch("merged", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ResetHard(first); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := findBranch(t, r, "feature").Delete(false); err != ErrBranchNotMerged {
+		t.Errorf("expected %v, got %v", ErrBranchNotMerged, err)
+	}
+	if err := findBranch(t, r, "feature").Delete(true); err != nil {
+		t.Error(err)
+	}
+	if err := findBranch(t, r, "merged").Delete(false); err != nil {
+		t.Error(err)
+	}
+	if err := r.Head.Delete(true); err != ErrDeleteHead {
+		t.Errorf("expected %v, got %v", ErrDeleteHead, err)
+	}
+	branches, err := r.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 1 {
+		t.Errorf("expected only HEAD to remain, got %d branches", len(branches))
+	}
+}
+
+func TestBranchRename(t *testing.T) {
+	r := newTestRepository(t)
+	c := commitTestFile(t, r, "file.txt", "a\n")
+	if err := r.CreateBranch("feature", c); err != nil {
+		t.Fatal(err)
+	}
+	if err := findBranch(t, r, "feature").Rename("topic"); err != nil {
+		t.Fatal(err)
+	}
+	if b := findBranch(t, r, "topic"); b.Hash != c.Hash {
+		t.Errorf("expected topic at %s, got %s", c.Hash, b.Hash)
+	}
+}
+
+func TestMergeFastForward(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	second := commitTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.CreateBranch("feature", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ResetHard(first); err != nil {
+		t.Fatal(err)
+	}
+
+	feature := findBranch(t, r, "feature")
+	if err := r.Merge(feature); err != nil {
+		t.Fatal(err)
+	}
+	if r.Head.Hash != second.Hash {
+		t.Errorf("expected HEAD at %s, got %s", second.Hash, r.Head.Hash)
+	}
+	assertTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.Merge(feature); err != ErrAlreadyUpToDate {
+		t.Errorf("expected %v, got %v", ErrAlreadyUpToDate, err)
+	}
+}
+
+func TestMergeStagedChanges(t *testing.T) {
+	r := newTestRepository(t)
+	base := commitTestFile(t, r, "file.txt", "a\n")
+	feature := commitTestFile(t, r, "feature.txt", "feature\n")
+	if err := r.CreateBranch("feature", feature); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ResetHard(base); err != nil {
+		t.Fatal(err)
+	}
+	head := commitTestFile(t, r, "main.txt", "main\n")
+	writeTestFile(t, r.Path(), "staged.txt", "staged\n")
+	if err := r.AddToIndex(findEntry(t, r, "staged.txt", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Merge(findBranch(t, r, "feature")); err != ErrStagedChanges {
+		t.Fatalf("expected %v, got %v", ErrStagedChanges, err)
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Head.Hash != head.Hash {
+		t.Errorf("expected HEAD to stay at %s, got %s", head.Hash, r.Head.Hash)
+	}
+	if r.State() != StateNone {
+		t.Errorf("expected no merge in progress, got %v", r.State())
+	}
+	if findEntry(t, r, "staged.txt", true) == nil {
+		t.Error("expected staged.txt to stay staged")
+	}
+}
+
+func TestSortBranches(t *testing.T) {
+	now := time.Now()
+	branch := func(name string, ahead, behind int, age time.Duration) *Branch {
+		return &Branch{
+			Name:   name,
+			Ahead:  ahead,
+			Behind: behind,
+			target: &Commit{Author: &Signature{When: now.Add(-age)}},
+		}
+	}
+	branches := []*Branch{
+		branch("b", 0, 3, time.Hour),
+		branch("c", 2, 0, 100*24*time.Hour),
+		branch("a", 2, 1, time.Minute),
+	}
+	tests := []struct {
+		by       BranchSort
+		expected string
+	}{
+		{BranchSortDate, "abc"},
+		{BranchSortName, "abc"},
+		{BranchSortAheadBehind, "acb"},
+	}
+	for _, test := range tests {
+		SortBranches(branches, test.by)
+		var names string
+		for _, b := range branches {
+			names += b.Name
+		}
+		if names != test.expected {
+			t.Errorf("expected %s sorted by %d, got %s", test.expected, test.by, names)
+		}
+		// shuffle for the next sort
+		branches[0], branches[2] = branches[2], branches[0]
+	}
+	for _, b := range branches {
+		if b.Stale() != (b.Name == "c") {
+			t.Errorf("expected only c to be stale, %s is not", b.Name)
+		}
+	}
+}
+
+func TestFilterBranches(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	second := commitTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.CreateBranch("feature", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateBranch("merged", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ResetHard(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	branches, err := r.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmerged, err := r.FilterBranches(branches, BranchFilterUnmerged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmerged) != 1 || unmerged[0].Name != "feature" {
+		t.Errorf("expected only feature to be unmerged, got %v", unmerged)
+	}
+	merged, err := r.FilterBranches(branches, BranchFilterMerged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 {
+		t.Errorf("expected HEAD and merged to be merged, got %v", merged)
+	}
+}
+
+// findBranch returns the local or remote-tracking branch with the name
+func findBranch(t *testing.T, r *Repository, name string) *Branch {
+	t.Helper()
+	branches, err := r.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range branches {
+		if b.Name == name {
+			return b
+		}
+	}
+	t.Fatalf("branch %s not found", name)
+	return nil
+}
@@ -0,0 +1,123 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// CheckoutStrategy decides what happens to the local changes that a
+// checkout would overwrite
+type CheckoutStrategy int
+
+const (
+	// CheckoutSafe refuses to overwrite the local changes
+	CheckoutSafe CheckoutStrategy = iota
+	// CheckoutForce discards the local changes
+	CheckoutForce
+)
+
+// CheckoutError is returned when a checkout would overwrite the local
+// changes of the paths
+type CheckoutError struct {
+	Paths []string
+}
+
+func (e *CheckoutError) Error() string {
+	return fmt.Sprintf("local changes to %d file(s) would be overwritten by checkout", len(e.Paths))
+}
+
+// Checkout is the wrapper of "git checkout <branch>", the working tree is
+// updated to the branch and HEAD is attached to it. For a remote-tracking
+// branch the local branch of the same name is checked out, it is created to
+// track the remote-tracking branch if it does not exist.
+func (r *Repository) Checkout(b *Branch, strategy CheckoutStrategy) error {
+	if b.Head {
+		return nil
+	}
+	name := b.Name
+	if b.isRemote {
+		remote, err := r.essence.RemoteName(b.FullName)
+		if err != nil {
+			return err
+		}
+		name = strings.TrimPrefix(b.Name, remote+"/")
+	}
+	target, err := r.checkoutTarget(b, name)
+	if err != nil {
+		return err
+	}
+	defer target.Free()
+	if err := r.checkoutCommit(target, strategy); err != nil {
+		return err
+	}
+	if b.isRemote {
+		if err := r.trackBranch(name, b, target); err != nil {
+			return err
+		}
+	}
+	if err := r.essence.SetHead("refs/heads/" + name); err != nil {
+		return err
+	}
+	return r.LoadHead()
+}
+
+// checkoutTarget returns the commit of the local branch with the name, or
+// the commit of the branch if there is no such local branch
+func (r *Repository) checkoutTarget(b *Branch, name string) (*lib.Commit, error) {
+	oid, err := lib.NewOid(b.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if local, err := r.essence.LookupBranch(name, lib.BranchLocal); err == nil {
+		oid = local.Target()
+		defer local.Free()
+	}
+	return r.essence.LookupCommit(oid)
+}
+
+// trackBranch creates the local branch with the name at the target if it
+// does not exist yet and sets the remote-tracking branch as its upstream
+func (r *Repository) trackBranch(name string, upstream *Branch, target *lib.Commit) error {
+	if local, err := r.essence.LookupBranch(name, lib.BranchLocal); err == nil {
+		local.Free()
+		return nil
+	}
+	local, err := r.essence.CreateBranch(name, target, false)
+	if err != nil {
+		return err
+	}
+	defer local.Free()
+	return local.SetUpstream(upstream.Name)
+}
+
+// checkoutCommit updates the index and the working tree to the commit, the
+// paths of the local changes that would be overwritten are reported with a
+// CheckoutError
+func (r *Repository) checkoutCommit(c *lib.Commit, strategy CheckoutStrategy) error {
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	defer tree.Free()
+	paths := make([]string, 0)
+	opts := &lib.CheckoutOptions{
+		Strategy:    lib.CheckoutSafe,
+		NotifyFlags: lib.CheckoutNotifyConflict,
+		NotifyCallback: func(why lib.CheckoutNotifyType, path string, baseline, target, workdir lib.DiffFile) error {
+			paths = append(paths, path)
+			return nil
+		},
+	}
+	if strategy == CheckoutForce {
+		opts.Strategy = lib.CheckoutForce
+	}
+	if err := r.essence.CheckoutTree(tree, opts); err != nil {
+		if len(paths) > 0 {
+			return &CheckoutError{Paths: paths}
+		}
+		return err
+	}
+	return nil
+}
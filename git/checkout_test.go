@@ -0,0 +1,64 @@
+package git
+
+import (
+	"testing"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+func TestCheckout(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	commitTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.CreateBranch("feature", first); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, r.Path(), "file.txt", "changed\n")
+	err := r.Checkout(findBranch(t, r, "feature"), CheckoutSafe)
+	conflict, ok := err.(*CheckoutError)
+	if !ok {
+		t.Fatalf("expected a checkout error, got %v", err)
+	}
+	if len(conflict.Paths) != 1 || conflict.Paths[0] != "file.txt" {
+		t.Errorf("unexpected conflicts %v", conflict.Paths)
+	}
+	assertTestFile(t, r, "file.txt", "changed\n")
+
+	if err := r.Checkout(findBranch(t, r, "feature"), CheckoutForce); err != nil {
+		t.Fatal(err)
+	}
+	if r.Head.Name != "feature" {
+		t.Errorf("expected HEAD at feature, got %s", r.Head.Name)
+	}
+	assertTestFile(t, r, "file.txt", "a\n")
+}
+
+func TestCheckoutRemoteBranch(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	commitTestFile(t, r, "file.txt", "a\nb\n")
+	if _, err := r.AddRemote("origin", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	oid, err := lib.NewOid(first.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := r.essence.References.Create("refs/remotes/origin/feature", oid, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref.Free()
+
+	if err := r.Checkout(findBranch(t, r, "origin/feature"), CheckoutSafe); err != nil {
+		t.Fatal(err)
+	}
+	if r.Head.Name != "feature" || r.Head.Hash != first.Hash {
+		t.Errorf("expected HEAD at the local feature branch, got %s", r.Head.Name)
+	}
+	if r.Head.Upstream == nil || r.Head.Upstream.Name != "origin/feature" {
+		t.Error("expected feature to track origin/feature")
+	}
+	assertTestFile(t, r, "file.txt", "a\n")
+}
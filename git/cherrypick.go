@@ -0,0 +1,146 @@
+package git
+
+import (
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// CherryPick is the wrapper of "git cherry-pick <commit>", the changes of the
+// commit are applied to HEAD and committed with its message and author. The
+// changes of a merge are taken relative to its first parent. If the changes
+// conflict, the repository is left in StateCherrypick to resolve them.
+func (r *Repository) CherryPick(c *Commit) (*Commit, error) {
+	opts, err := lib.DefaultCherrypickOptions()
+	if err != nil {
+		return nil, err
+	}
+	if c.essence.ParentCount() > 1 {
+		opts.Mainline = 1
+	}
+	if err := r.checkCleanIndex(); err != nil {
+		return nil, err
+	}
+	if err := r.essence.Cherrypick(c.essence, opts); err != nil {
+		return nil, err
+	}
+	return r.commitOperation(c.Message, c.essence.Author())
+}
+
+// Revert is the wrapper of "git revert <commit>", the changes of the commit
+// are undone on HEAD and committed. If the changes conflict, the repository
+// is left in StateRevert to resolve them.
+func (r *Repository) Revert(c *Commit) (*Commit, error) {
+	opts, err := lib.DefaultRevertOptions()
+	if err != nil {
+		return nil, err
+	}
+	if c.essence.ParentCount() > 1 {
+		opts.Mainline = 1
+	}
+	if err := r.checkCleanIndex(); err != nil {
+		return nil, err
+	}
+	if err := r.essence.Revert(c.essence, &opts); err != nil {
+		return nil, err
+	}
+	message := "Revert \"" + c.Summary + "\"\n\nThis reverts commit " + c.Hash + ".\n"
+	return r.commitOperation(message, nil)
+}
+
+// checkCleanIndex refuses to start an operation when changes are staged,
+// the commit of the operation is written from the whole index so the staged
+// changes would be committed with it
+func (r *Repository) checkCleanIndex() error {
+	head, err := r.essence.Head()
+	if err != nil {
+		return err
+	}
+	defer head.Free()
+	commit, err := r.essence.LookupCommit(head.Target())
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	defer tree.Free()
+	index, err := r.essence.Index()
+	if err != nil {
+		return err
+	}
+	defer index.Free()
+	diff, err := r.essence.DiffTreeToIndex(tree, index, nil)
+	if err != nil {
+		return err
+	}
+	defer diff.Free()
+	n, err := diff.NumDeltas()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrStagedChanges
+	}
+	return nil
+}
+
+// commitOperation commits the index that a cherry-pick, revert or merge
+// prepared and cleans up its state. The committer is the user, the author is
+// the user if it is nil. The other parents are the merged commits.
+func (r *Repository) commitOperation(message string, author *lib.Signature, others ...*lib.Commit) (*Commit, error) {
+	index, err := r.essence.Index()
+	if err != nil {
+		return nil, err
+	}
+	defer index.Free()
+	if index.HasConflicts() {
+		return nil, ErrMergeConflicts
+	}
+	committer, err := r.essence.DefaultSignature()
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		author = committer
+	}
+	head, err := r.essence.Head()
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+	parent, err := r.essence.LookupCommit(head.Target())
+	if err != nil {
+		return nil, err
+	}
+	defer parent.Free()
+	treeid, err := index.WriteTree()
+	if err != nil {
+		return nil, err
+	}
+	if len(others) == 0 && treeid.Equal(parent.TreeId()) {
+		// the changes are already in HEAD
+		if err := r.essence.StateCleanup(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNothingToCommit
+	}
+	tree, err := r.essence.LookupTree(treeid)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+	parents := append([]*lib.Commit{parent}, others...)
+	oid, err := r.essence.CreateCommit("HEAD", author, committer, message, tree, parents...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.essence.StateCleanup(); err != nil {
+		return nil, err
+	}
+	commit, err := r.essence.LookupCommit(oid)
+	if err != nil {
+		return nil, err
+	}
+	return unpackRawCommit(r, commit), nil
+}
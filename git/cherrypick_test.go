@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCherryPickAndRevert(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "file.txt", "a\n")
+	added := commitTestFile(t, r, "file.txt", "a\nb\n")
+	commitTestFile(t, r, "other.txt", "other\n")
+
+	reverted, err := r.Revert(added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.Summary != "Revert \"update file.txt\"" {
+		t.Errorf("unexpected revert message %q", reverted.Summary)
+	}
+	assertTestFile(t, r, "file.txt", "a\n")
+
+	picked, err := r.CherryPick(added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked.Summary != added.Summary || picked.Author.Email != added.Author.Email {
+		t.Error("expected the message and author of the picked commit")
+	}
+	assertTestFile(t, r, "file.txt", "a\nb\n")
+	if _, err := r.CherryPick(added); err != ErrNothingToCommit {
+		t.Errorf("expected %v, got %v", ErrNothingToCommit, err)
+	}
+	if r.State() != StateNone {
+		t.Errorf("expected no operation in progress, got %v", r.State())
+	}
+}
+
+func TestRevertConflict(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "file.txt", "a\n")
+	changed := commitTestFile(t, r, "file.txt", "x\n")
+	commitTestFile(t, r, "file.txt", "y\n")
+
+	if _, err := r.Revert(changed); err != ErrMergeConflicts {
+		t.Fatalf("expected %v, got %v", ErrMergeConflicts, err)
+	}
+	if r.State() != StateRevert {
+		t.Errorf("expected %v, got %v", StateRevert, r.State())
+	}
+	if _, err := r.Conflict("file.txt"); err != nil {
+		t.Error(err)
+	}
+}
+
+func assertTestFile(t *testing.T, r *Repository, name, content string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(r.Path(), name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("expected %q in %s, got %q", content, name, string(data))
+	}
+}
+
+func TestCherryPickStagedChanges(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "file.txt", "a\n")
+	added := commitTestFile(t, r, "file.txt", "a\nb\n")
+	reverted, err := r.Revert(added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, r.Path(), "staged.txt", "staged\n")
+	if err := r.AddToIndex(findEntry(t, r, "staged.txt", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pick := range []func(*Commit) (*Commit, error){r.CherryPick, r.Revert} {
+		if _, err := pick(added); err != ErrStagedChanges {
+			t.Errorf("expected %v, got %v", ErrStagedChanges, err)
+		}
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Head.Hash != reverted.Hash {
+		t.Fatalf("expected HEAD to stay at %s, got %s", reverted.Hash, r.Head.Hash)
+	}
+	if r.State() != StateNone {
+		t.Errorf("expected no operation in progress, got %v", r.State())
+	}
+	if findEntry(t, r, "staged.txt", true) == nil {
+		t.Error("expected staged.txt to stay staged")
+	}
+	assertTestFile(t, r, "file.txt", "a\n")
+}
@@ -2,8 +2,11 @@ package git
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	lib "github.com/libgit2/git2go/v33"
 )
@@ -59,35 +62,10 @@ func (r *Repository) Commits() ([]*Commit, error) {
 	return buffer, err
 }
 
-// Commits returns commits as channel with given size
-func (r *Repository) CommitsChan(size int) (chan *Commit, error) {
-	head, err := r.essence.Head()
-	if err != nil {
-		return nil, err
-	}
-	walk, err := r.essence.Walk()
-	if err != nil {
-		return nil, err
-	}
-	if err := walk.Push(head.Target()); err != nil {
-		return nil, err
-	}
-	buffer := make(chan *Commit, size)
-
-	go func() {
-		defer walk.Free()
-		defer close(buffer)
-		err = walk.Iterate(func(commit *lib.Commit) bool {
-			buffer <- unpackRawCommit(r, commit)
-
-			return true
-		})
-		if err != nil {
-			panic(err)
-		}
-	}()
-
-	return buffer, nil
+// CommitsChan returns commits of the HEAD as channel with given size, the
+// error of the walk is sent to the error channel
+func (r *Repository) CommitsChan(size int) (chan *Commit, <-chan error, error) {
+	return r.Log(&LogOptions{}, size)
 }
 
 func unpackRawCommit(repo *Repository, raw *lib.Commit) *Commit {
@@ -113,20 +91,35 @@ func unpackRawCommit(repo *Repository, raw *lib.Commit) *Commit {
 	return c
 }
 
-// Commit adds a new commit onject to repository
+// Commit adds a new commit onject to repository, the author is resolved
+// from the config and the environment if it is not given
 // warning: this function does not check if the changes are indexed
 func (r *Repository) Commit(message string, author ...*Signature) (*Commit, error) {
 	repo := r.essence
-	head, err := repo.Head()
+	committer, err := r.committer()
 	if err != nil {
 		return nil, err
 	}
-	defer head.Free()
-	parent, err := repo.LookupCommit(head.Target())
-	if err != nil {
+	a := committer
+	if len(author) > 0 {
+		a = author[0]
+	} else if a, err = r.Author(); err != nil {
+		return nil, err
+	}
+	parents := make([]*lib.Commit, 0, 1)
+	head, err := repo.Head()
+	switch {
+	case err == nil:
+		defer head.Free()
+		parent, err := repo.LookupCommit(head.Target())
+		if err != nil {
+			return nil, err
+		}
+		defer parent.Free()
+		parents = append(parents, parent)
+	case !lib.IsErrorCode(err, lib.ErrorCodeUnbornBranch):
 		return nil, err
 	}
-	defer parent.Free()
 	index, err := repo.Index()
 	if err != nil {
 		return nil, err
@@ -141,7 +134,7 @@ func (r *Repository) Commit(message string, author ...*Signature) (*Commit, erro
 		return nil, err
 	}
 	defer tree.Free()
-	oid, err := repo.CreateCommit("HEAD", author[0].toNewLibSignature(), author[0].toNewLibSignature(), message, tree, parent)
+	oid, err := repo.CreateCommit("HEAD", a.toNewLibSignature(), committer.toNewLibSignature(), message, tree, parents...)
 	if err != nil {
 		return nil, err
 	}
@@ -152,13 +145,115 @@ func (r *Repository) Commit(message string, author ...*Signature) (*Commit, erro
 	return unpackRawCommit(r, commit), nil
 }
 
+// Author resolves the author of a new commit like git does, the user.name
+// and user.email of the config are overridden by the GIT_AUTHOR_NAME and
+// GIT_AUTHOR_EMAIL variables
+func (r *Repository) Author() (*Signature, error) {
+	return r.signatureFromEnv("GIT_AUTHOR")
+}
+
+// committer resolves the committer like the author by the GIT_COMMITTER_*
+// variables
+func (r *Repository) committer() (*Signature, error) {
+	return r.signatureFromEnv("GIT_COMMITTER")
+}
+
+func (r *Repository) signatureFromEnv(prefix string) (*Signature, error) {
+	s := &Signature{When: time.Now()}
+	if sig, err := r.essence.DefaultSignature(); err == nil {
+		s.Name, s.Email, s.When = sig.Name, sig.Email, sig.When
+	}
+	if name := os.Getenv(prefix + "_NAME"); len(name) > 0 {
+		s.Name = name
+	}
+	if email := os.Getenv(prefix + "_EMAIL"); len(email) > 0 {
+		s.Email = email
+	} else if email := os.Getenv("EMAIL"); len(email) > 0 && len(s.Email) == 0 {
+		s.Email = email
+	}
+	if len(s.Name) == 0 || len(s.Email) == 0 {
+		return nil, ErrUnknownIdentity
+	}
+	return s, nil
+}
+
+// CommitTemplate reads the file of the commit.template config, it is empty
+// if no template is configured
+func (r *Repository) CommitTemplate() (string, error) {
+	cfg, err := r.essence.Config()
+	if err != nil {
+		return "", err
+	}
+	defer cfg.Free()
+	path, err := cfg.LookupString("commit.template")
+	if err != nil || len(path) == 0 {
+		return "", nil
+	}
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.path, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// scissors is the line that everything below is removed from a message
+const scissors = "# ------------------------ >8 ------------------------"
+
+// CleanupMessage strips the comments, the trailing whitespace and the
+// repeated blank lines of a message like "git commit --cleanup=strip"
+func CleanupMessage(message string) string {
+	lines := make([]string, 0)
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if line == scissors {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if len(line) == 0 {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 func (c *Commit) String() string {
 	return c.Summary
 }
 
-// Amend updates the commit and returns NEW commit pointer
+// Amend updates the commit and returns NEW commit pointer, the author of the
+// commit is kept if it is not given
 func (c *Commit) Amend(message string, author ...*Signature) (*Commit, error) {
 	repo := c.owner.essence
+	committer, err := c.owner.committer()
+	if err != nil {
+		return nil, err
+	}
+	var a *lib.Signature
+	if len(author) > 0 {
+		a = author[0].toNewLibSignature()
+	}
 	index, err := repo.Index()
 	if err != nil {
 		return nil, err
@@ -173,7 +268,7 @@ func (c *Commit) Amend(message string, author ...*Signature) (*Commit, error) {
 		return nil, err
 	}
 	defer tree.Free()
-	oid, err := c.essence.Amend("HEAD", author[0].toNewLibSignature(), author[0].toNewLibSignature(), message, tree)
+	oid, err := c.essence.Amend("HEAD", a, committer.toNewLibSignature(), message, tree)
 	if err != nil {
 		return nil, err
 	}
@@ -181,10 +276,7 @@ func (c *Commit) Amend(message string, author ...*Signature) (*Commit, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Commit{
-		essence: commit,
-		owner:   c.owner,
-	}, nil
+	return unpackRawCommit(c.owner, commit), nil
 }
 
 // Diff has similar behavior to "git diff <commit>"
@@ -206,12 +298,17 @@ func (c *Commit) Diff() (*Diff, error) {
 		defer pTree.Free()
 	}
 
+	return c.owner.diffTrees(pTree, cTree, c)
+}
+
+// diffTrees creates a Diff between two trees, deltas are bound to the commit
+func (r *Repository) diffTrees(pTree, cTree *lib.Tree, c *Commit) (*Diff, error) {
 	opt, err := lib.DefaultDiffOptions()
 	if err != nil {
 		return nil, err
 	}
 
-	diff, err := c.owner.essence.DiffTreeToTree(pTree, cTree, &opt)
+	diff, err := r.essence.DiffTreeToTree(pTree, cTree, &opt)
 	if err != nil {
 		return nil, err
 	}
@@ -233,41 +330,13 @@ func (c *Commit) Diff() (*Diff, error) {
 		return nil, err
 	}
 
-	var patch *lib.Patch
-	var patchtext string
-
 	for i := 0; i < deltas; i++ {
-		if patch, err = diff.Patch(i); err != nil {
-			continue
-		}
-		var dd lib.DiffDelta
-		if dd, err = diff.GetDelta(i); err != nil {
-			continue
-		}
-		d := &DiffDelta{
-			Status: DeltaStatus(dd.Status),
-			NewFile: &DiffFile{
-				Path: dd.NewFile.Path,
-				Hash: dd.NewFile.Oid.String(),
-			},
-			OldFile: &DiffFile{
-				Path: dd.OldFile.Path,
-				Hash: dd.OldFile.Oid.String(),
-			},
-			Commit: c,
-		}
-
-		if patchtext, err = patch.String(); err != nil {
+		d, err := newDiffDelta(diff, i, c)
+		if err != nil {
 			continue
 		}
-		d.Patch = patchtext
-
 		ddeltas = append(ddeltas, d)
-		patchs = append(patchs, patchtext)
-
-		if err := patch.Free(); err != nil {
-			return nil, err
-		}
+		patchs = append(patchs, d.Patch)
 	}
 
 	d := &Diff{
@@ -278,6 +347,36 @@ func (c *Commit) Diff() (*Diff, error) {
 	return d, nil
 }
 
+// newDiffDelta creates the delta of the ith change of the diff with its patch
+func newDiffDelta(diff *lib.Diff, i int, c *Commit) (*DiffDelta, error) {
+	dd, err := diff.GetDelta(i)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := diff.Patch(i)
+	if err != nil {
+		return nil, err
+	}
+	defer patch.Free()
+	patchtext, err := patch.String()
+	if err != nil {
+		return nil, err
+	}
+	return &DiffDelta{
+		Status: DeltaStatus(dd.Status),
+		NewFile: &DiffFile{
+			Path: dd.NewFile.Path,
+			Hash: dd.NewFile.Oid.String(),
+		},
+		OldFile: &DiffFile{
+			Path: dd.OldFile.Path,
+			Hash: dd.OldFile.Oid.String(),
+		},
+		Patch:  patchtext,
+		Commit: c,
+	}, nil
+}
+
 // ParentID returns the commits parent hash.
 func (c *Commit) ParentID() (string, error) {
 	if c.essence.Parent(0) == nil {
@@ -285,3 +384,30 @@ func (c *Commit) ParentID() (string, error) {
 	}
 	return c.essence.Parent(0).AsObject().Id().String(), nil
 }
+
+// ParentHashes returns the hashes of the parents of the commit
+func (c *Commit) ParentHashes() []string {
+	hashes := make([]string, 0, c.essence.ParentCount())
+	for i := uint(0); i < c.essence.ParentCount(); i++ {
+		hashes = append(hashes, c.essence.ParentId(i).String())
+	}
+	return hashes
+}
+
+// LookupCommit resolves a revision such as "HEAD~2" or a tag name to a commit
+func (r *Repository) LookupCommit(rev string) (*Commit, error) {
+	obj, err := r.essence.RevparseSingle(rev)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Free()
+	peeled, err := obj.Peel(lib.ObjectCommit)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := peeled.AsCommit()
+	if err != nil {
+		return nil, err
+	}
+	return unpackRawCommit(r, commit), nil
+}
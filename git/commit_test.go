@@ -0,0 +1,51 @@
+package git
+
+import "testing"
+
+func TestCleanupMessage(t *testing.T) {
+	var tests = []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"# only a comment\n\n", ""},
+		{"subject", "subject\n"},
+		{"\n\nsubject  \n\n\n\nbody\t\n# comment\n\n", "subject\n\nbody\n"},
+		{"subject\n# comment\n\nbody\n" + scissors + "\ndiff --git a/file b/file\n", "subject\n\nbody\n"},
+	}
+	for _, test := range tests {
+		if output := CleanupMessage(test.input); output != test.output {
+			t.Errorf("input: %q\n expected %q, got %q", test.input, test.output, output)
+		}
+	}
+}
+
+func TestCommitAndAmend(t *testing.T) {
+	r := newTestRepository(t)
+	t.Setenv("GIT_AUTHOR_NAME", "someone")
+	t.Setenv("GIT_AUTHOR_EMAIL", "someone@example.com")
+	writeTestFile(t, r.Path(), "file.txt", "a\n")
+	if err := r.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Commit("add file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Author.Name != "someone" || c.Author.Email != "someone@example.com" {
+		t.Errorf("expected the author of the environment, got %s <%s>", c.Author.Name, c.Author.Email)
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+	amended, err := c.Amend("add the file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.Summary != "add the file" || amended.Author.Name != "someone" {
+		t.Errorf("expected the amended message by the same author, got %q by %s", amended.Summary, amended.Author.Name)
+	}
+	if head, err := r.LookupCommit("HEAD"); err != nil || head.Hash != amended.Hash {
+		t.Error("expected HEAD to be the amended commit")
+	}
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"strconv"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Comparison is the difference of two branches like "git log --left-right
+// A...B" with the changes between their tips
+type Comparison struct {
+	owner *Repository
+
+	A     *Branch
+	B     *Branch
+	Base  *Commit   // the merge base, nil if the histories are unrelated
+	OnlyA []*Commit // the commits of A that are not in B, newest first
+	OnlyB []*Commit // the commits of B that are not in A, newest first
+}
+
+func (c *Comparison) String() string {
+	return c.A.Name + "..." + c.B.Name + " (" + strconv.Itoa(len(c.OnlyA)) + " ahead, " +
+		strconv.Itoa(len(c.OnlyB)) + " behind)"
+}
+
+// Compare finds the commits that are only in one of the branches and their
+// merge base
+func (r *Repository) Compare(a, b *Branch) (*Comparison, error) {
+	aOid, err := lib.NewOid(a.Hash)
+	if err != nil {
+		return nil, err
+	}
+	bOid, err := lib.NewOid(b.Hash)
+	if err != nil {
+		return nil, err
+	}
+	c := &Comparison{owner: r, A: a, B: b}
+	if c.OnlyA, err = r.commitsOnlyIn(aOid, bOid); err != nil {
+		return nil, err
+	}
+	if c.OnlyB, err = r.commitsOnlyIn(bOid, aOid); err != nil {
+		return nil, err
+	}
+	base, err := r.essence.MergeBase(aOid, bOid)
+	if err != nil {
+		// unrelated histories have no merge base
+		return c, nil
+	}
+	commit, err := r.essence.LookupCommit(base)
+	if err != nil {
+		return nil, err
+	}
+	c.Base = unpackRawCommit(r, commit)
+	return c, nil
+}
+
+// commitsOnlyIn walks the commits of the tip that the other commit does not
+// contain
+func (r *Repository) commitsOnlyIn(tip, other *lib.Oid) ([]*Commit, error) {
+	walk, err := r.essence.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+	walk.Sorting(lib.SortTopological | lib.SortTime)
+	if err := walk.Push(tip); err != nil {
+		return nil, err
+	}
+	if err := walk.Hide(other); err != nil {
+		return nil, err
+	}
+	commits := make([]*Commit, 0)
+	err = walk.Iterate(func(commit *lib.Commit) bool {
+		commits = append(commits, unpackRawCommit(r, commit))
+		return true
+	})
+	return commits, err
+}
+
+// Diff returns the changes from the tip of A to the tip of B like
+// "git diff A B", the deltas are bound to the tip of B
+func (c *Comparison) Diff() (*Diff, error) {
+	r := c.owner
+	a, err := r.LookupCommit(c.A.Hash)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.LookupCommit(c.B.Hash)
+	if err != nil {
+		return nil, err
+	}
+	aTree, err := a.essence.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer aTree.Free()
+	bTree, err := b.essence.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer bTree.Free()
+	return r.diffTrees(aTree, bTree, b)
+}
@@ -0,0 +1,49 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	r := newTestRepository(t)
+	base := commitTestFile(t, r, "file.txt", "a\n")
+	if err := r.CreateBranch("feature", base); err != nil {
+		t.Fatal(err)
+	}
+	commitTestFile(t, r, "main.txt", "main\n")
+	head := r.Head.Name
+	if err := r.Checkout(findBranch(t, r, "feature"), CheckoutSafe); err != nil {
+		t.Fatal(err)
+	}
+	commitTestFile(t, r, "feature.txt", "feature\n")
+	commitTestFile(t, r, "file.txt", "b\n")
+
+	c, err := r.Compare(findBranch(t, r, head), findBranch(t, r, "feature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.OnlyA) != 1 || len(c.OnlyB) != 2 {
+		t.Errorf("expected 1 and 2 commits only in the branches, got %d and %d", len(c.OnlyA), len(c.OnlyB))
+	}
+	if c.Base == nil || c.Base.Hash != base.Hash {
+		t.Errorf("expected the merge base to be %s", base.Hash)
+	}
+	diff, err := c.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(map[string]DeltaStatus)
+	for _, d := range diff.Deltas() {
+		changed[d.NewFile.Path] = d.Status
+	}
+	expected := map[string]DeltaStatus{
+		"main.txt":    DeltaDeleted,
+		"feature.txt": DeltaAdded,
+		"file.txt":    DeltaModified,
+	}
+	for path, status := range expected {
+		if changed[path] != status {
+			t.Errorf("expected %s to be %v, got %v", path, status, changed[path])
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Conflict is an unmerged file of the index with its versions in the merge
+type Conflict struct {
+	Path     string
+	Ancestor *ConflictSide // nil if the file is added on both sides
+	Ours     *ConflictSide // nil if the file is deleted on our side
+	Theirs   *ConflictSide // nil if the file is deleted on their side
+}
+
+// ConflictSide is a version of the conflicted file
+type ConflictSide struct {
+	Name     string // "base", "ours" or "theirs"
+	Path     string
+	Hash     string
+	Conflict *Conflict
+	mode     lib.Filemode
+}
+
+func (s *ConflictSide) String() string {
+	return s.Name
+}
+
+// Sides returns the versions of the file that exist
+func (c *Conflict) Sides() []*ConflictSide {
+	sides := make([]*ConflictSide, 0, 3)
+	for _, s := range []*ConflictSide{c.Ancestor, c.Ours, c.Theirs} {
+		if s != nil {
+			sides = append(sides, s)
+		}
+	}
+	return sides
+}
+
+// Conflict returns the versions of the conflicted file from the index
+func (r *Repository) Conflict(path string) (*Conflict, error) {
+	index, err := r.essence.Index()
+	if err != nil {
+		return nil, err
+	}
+	defer index.Free()
+	ic, err := index.Conflict(path)
+	if err != nil {
+		return nil, ErrEntryNotConflicted
+	}
+	c := &Conflict{Path: path}
+	c.Ancestor = conflictSide(c, "base", ic.Ancestor)
+	c.Ours = conflictSide(c, "ours", ic.Our)
+	c.Theirs = conflictSide(c, "theirs", ic.Their)
+	return c, nil
+}
+
+func conflictSide(c *Conflict, name string, entry *lib.IndexEntry) *ConflictSide {
+	if entry == nil {
+		return nil
+	}
+	return &ConflictSide{
+		Name:     name,
+		Path:     entry.Path,
+		Hash:     entry.Id.String(),
+		Conflict: c,
+		mode:     entry.Mode,
+	}
+}
+
+// ConflictContent returns the content of the version of the file
+func (r *Repository) ConflictContent(s *ConflictSide) (string, error) {
+	oid, err := lib.NewOid(s.Hash)
+	if err != nil {
+		return "", err
+	}
+	return r.blobContent(oid)
+}
+
+// ResolveConflict is the wrapper of "git checkout --ours/--theirs -- <path>"
+// followed by "git add", the side is nil to resolve by deleting the file
+func (r *Repository) ResolveConflict(c *Conflict, s *ConflictSide) error {
+	abs := filepath.Join(r.essence.Workdir(), c.Path)
+	if s == nil {
+		if err := os.Remove(abs); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		content, err := r.ConflictContent(s)
+		if err != nil {
+			return err
+		}
+		if err := writeConflictSide(abs, content, s.mode); err != nil {
+			return err
+		}
+	}
+	return r.updateIndex(func(index *lib.Index) error {
+		return r.addPath(index, c.Path)
+	})
+}
+
+// writeConflictSide writes the content of a side with its mode, the content
+// of a symbolic link is its target
+func writeConflictSide(abs, content string, mode lib.Filemode) error {
+	info, err := os.Lstat(abs)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// a link is replaced rather than written through
+	if err == nil && (mode == lib.FilemodeLink || info.Mode()&os.ModeSymlink != 0) {
+		if err := os.Remove(abs); err != nil {
+			return err
+		}
+	}
+	if mode == lib.FilemodeLink {
+		return os.Symlink(content, abs)
+	}
+	perm := os.FileMode(0644)
+	if mode == lib.FilemodeBlobExecutable {
+		perm = 0755
+	}
+	if err := os.WriteFile(abs, []byte(content), perm); err != nil {
+		return err
+	}
+	// the permissions are applied only when the file is created
+	return os.Chmod(abs, perm)
+}
+
+// MarkResolved adds the conflicted file to the index as it is in the working
+// tree, unless it still has conflict markers
+func (r *Repository) MarkResolved(e *StatusEntry) error {
+	if e.EntryType != StatusEntryTypeConflicted {
+		return ErrEntryNotConflicted
+	}
+	f, err := os.Open(filepath.Join(r.essence.Workdir(), e.String()))
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+				return ErrConflictMarkers
+			}
+		}
+	}
+	return r.AddToIndex(e)
+}
+
+// String returns the name of the operation in progress
+func (s State) String() string {
+	switch s {
+	case StateMerge:
+		return "Merging"
+	case StateRevert:
+		return "Reverting"
+	case StateCherrypick:
+		return "Cherry-picking"
+	case StateBisect:
+		return "Bisecting"
+	case StateRebase, StateRebaseInteractive, StateRebaseMerge, StateApplyMailboxOrRebase:
+		return "Rebasing"
+	case StateApplyMailbox:
+		return "Applying patches"
+	}
+	return ""
+}
+
+// InProgress returns true if an operation is stopped by the conflicts or to
+// edit a commit
+func (s State) InProgress() bool {
+	return s != StateNone && s != StateUnknown
+}
+
+var stateMap = map[lib.RepositoryState]State{
+	lib.RepositoryStateNone:                 StateNone,
+	lib.RepositoryStateMerge:                StateMerge,
+	lib.RepositoryStateRevert:               StateRevert,
+	lib.RepositoryStateCherrypick:           StateCherrypick,
+	lib.RepositoryStateBisect:               StateBisect,
+	lib.RepositoryStateRebase:               StateRebase,
+	lib.RepositoryStateRebaseInteractive:    StateRebaseInteractive,
+	lib.RepositoryStateRebaseMerge:          StateRebaseMerge,
+	lib.RepositoryStateApplyMailbox:         StateApplyMailbox,
+	lib.RepositoryStateApplyMailboxOrRebase: StateApplyMailboxOrRebase,
+}
+
+// State returns the state of the repository, the states are mapped since
+// libgit2 counts from none
+func (r *Repository) State() State {
+	if s, ok := stateMap[r.essence.State()]; ok {
+		return s
+	}
+	return StateUnknown
+}
+
+// RebaseProgress returns the number of the commit that is being applied and
+// the number of commits to apply, zero if there is no rebase in progress
+func (r *Repository) RebaseProgress() (step, total int) {
+	gitDir := r.essence.Path()
+	for _, dir := range []struct{ name, step, total string }{
+		{"rebase-merge", "msgnum", "end"},
+		{"rebase-apply", "next", "last"},
+	} {
+		step = readNumber(filepath.Join(gitDir, dir.name, dir.step))
+		total = readNumber(filepath.Join(gitDir, dir.name, dir.total))
+		if total > 0 {
+			return step, total
+		}
+	}
+	return 0, 0
+}
+
+func readNumber(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return n
+}
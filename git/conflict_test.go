@@ -0,0 +1,134 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// addTestConflict records a conflict of the file in the index and writes it
+// with conflict markers to the working tree
+func addTestConflict(t *testing.T, r *Repository, name, base, ours, theirs string) {
+	t.Helper()
+	index, err := r.essence.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Free()
+	entries := make([]*lib.IndexEntry, 0, 3)
+	for _, content := range []string{base, ours, theirs} {
+		oid, err := r.essence.CreateBlobFromBuffer([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, &lib.IndexEntry{Path: name, Id: oid, Mode: lib.FilemodeBlob})
+	}
+	if err := index.AddConflict(entries[0], entries[1], entries[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Write(); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, r.Path(), name, "<<<<<<< ours\n"+ours+"=======\n"+theirs+">>>>>>> theirs\n")
+}
+
+func TestResolveConflict(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "file.txt", "base\n")
+	addTestConflict(t, r, "file.txt", "base\n", "ours\n", "theirs\n")
+
+	c, err := r.Conflict("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Sides()) != 3 {
+		t.Fatalf("expected 3 sides, got %d", len(c.Sides()))
+	}
+	content, err := r.ConflictContent(c.Ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "ours\n" {
+		t.Errorf("expected ours content, got %q", content)
+	}
+
+	entry := findEntry(t, r, "file.txt", false)
+	if entry == nil || entry.EntryType != StatusEntryTypeConflicted {
+		t.Fatal("expected a conflicted entry")
+	}
+	if err := r.MarkResolved(entry); err != ErrConflictMarkers {
+		t.Errorf("expected %v, got %v", ErrConflictMarkers, err)
+	}
+
+	if err := r.ResolveConflict(c, c.Theirs); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(r.Path(), "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "theirs\n" {
+		t.Errorf("expected theirs content, got %q", string(data))
+	}
+	if _, err := r.Conflict("file.txt"); err != ErrEntryNotConflicted {
+		t.Errorf("expected %v, got %v", ErrEntryNotConflicted, err)
+	}
+}
+
+func TestRebaseProgress(t *testing.T) {
+	r := newTestRepository(t)
+	if step, total := r.RebaseProgress(); step != 0 || total != 0 {
+		t.Errorf("expected no rebase, got %d/%d", step, total)
+	}
+	dir := filepath.Join(r.essence.Path(), "rebase-merge")
+	writeTestFile(t, dir, "msgnum", "3\n")
+	writeTestFile(t, dir, "end", "7\n")
+	if step, total := r.RebaseProgress(); step != 3 || total != 7 {
+		t.Errorf("expected 3/7, got %d/%d", step, total)
+	}
+}
+
+func TestWriteConflictSide(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "script.sh")
+	writeTestFile(t, filepath.Dir(abs), "script.sh", "<<<<<<< ours\n")
+	var tests = []struct {
+		content string
+		mode    lib.Filemode
+	}{
+		{"#!/bin/sh\n", lib.FilemodeBlobExecutable},
+		{"target.sh", lib.FilemodeLink},
+		{"plain\n", lib.FilemodeBlob},
+	}
+	for _, test := range tests {
+		if err := writeConflictSide(abs, test.content, test.mode); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Lstat(abs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch test.mode {
+		case lib.FilemodeLink:
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Fatalf("expected a symbolic link, got %v", info.Mode())
+			}
+			if target, err := os.Readlink(abs); err != nil || target != test.content {
+				t.Errorf("expected a link to %s, got %s, %v", test.content, target, err)
+			}
+			continue
+		case lib.FilemodeBlobExecutable:
+			if info.Mode()&0111 == 0 {
+				t.Errorf("expected an executable file, got %v", info.Mode())
+			}
+		default:
+			if info.Mode()&0111 != 0 {
+				t.Errorf("expected a regular file, got %v", info.Mode())
+			}
+		}
+		if data, err := os.ReadFile(abs); err != nil || string(data) != test.content {
+			t.Errorf("expected %q, got %q, %v", test.content, string(data), err)
+		}
+	}
+}
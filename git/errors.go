@@ -30,4 +30,64 @@ var (
 	ErrBranchNotFound Error = "cannot locate remote-tracking branch"
 	// ErrEntryNotIndexed is returned when the entry is not indexed
 	ErrEntryNotIndexed Error = "entry is not indexed"
+	// ErrEntryIndexed is returned when the entry is already indexed
+	ErrEntryIndexed Error = "entry is already indexed"
+	// ErrNoTextPatch is returned when the changes of an entry cannot be staged by lines
+	ErrNoTextPatch Error = "entry has no text changes"
+	// ErrEntryNotUntracked is returned when a tracked entry is going to be cleaned
+	ErrEntryNotUntracked Error = "entry is not untracked"
+	// ErrEntryConflicted is returned when the entry has unresolved conflicts
+	ErrEntryConflicted Error = "entry has unresolved conflicts"
+	// ErrEntryNotConflicted is returned when the entry has no conflicts to resolve
+	ErrEntryNotConflicted Error = "entry is not conflicted"
+	// ErrConflictMarkers is returned when a file is marked resolved with conflict markers in it
+	ErrConflictMarkers Error = "file still has conflict markers"
+	// ErrNothingToRebase is returned when HEAD has no commits that are not in the upstream
+	ErrNothingToRebase Error = "nothing to rebase"
+	// ErrNoPreviousCommit is returned when the first commit of a rebase is squashed
+	ErrNoPreviousCommit Error = "cannot squash without a previous commit"
+	// ErrMergeConflicts is returned when the changes of an operation conflict
+	ErrMergeConflicts Error = "changes conflict, resolve them to continue"
+	// ErrNothingToCommit is returned when the changes of an operation are already applied
+	ErrNothingToCommit Error = "nothing to commit, the changes are already applied"
+	// ErrRemoteBranch is returned when a remote-tracking branch is changed like a local one
+	ErrRemoteBranch Error = "not valid for a remote-tracking branch"
+	// ErrBranchNotMerged is returned when an unmerged branch is deleted without force
+	ErrBranchNotMerged Error = "branch is not fully merged"
+	// ErrDeleteHead is returned when the checked out branch is deleted
+	ErrDeleteHead Error = "cannot delete the checked out branch"
+	// ErrRebaseConflicts is returned when a rebase is aborted for conflicts
+	ErrRebaseConflicts Error = "commits conflict with the upstream, rebase aborted"
+	// ErrBranchCheckedOut is returned when the branch is checked out in another worktree
+	ErrBranchCheckedOut Error = "branch is already checked out in a worktree"
+	// ErrWorktreeExists is returned when a worktree is added to a path that is in use
+	ErrWorktreeExists Error = "worktree already exists"
+	// ErrWorktreeInUse is returned when the main or the current worktree is changed
+	ErrWorktreeInUse Error = "cannot change the main or the current worktree"
+	// ErrWorktreeLocked is returned when a locked worktree is removed or locked again
+	ErrWorktreeLocked Error = "worktree is locked"
+	// ErrWorktreeDirty is returned when a worktree with changes is removed without force
+	ErrWorktreeDirty Error = "worktree has changes"
+	// ErrWorktreeNotPrunable is returned when a worktree that still exists is pruned
+	ErrWorktreeNotPrunable Error = "worktree is not prunable"
+	// ErrSubmoduleNotCloned is returned when a submodule that is not cloned is opened
+	ErrSubmoduleNotCloned Error = "submodule is not cloned, update it first"
+	// ErrOperationInProgress is returned when an operation is started while another one is stopped
+	ErrOperationInProgress Error = "another operation is in progress"
+	// ErrNotBisecting is returned when there is no bisect in progress
+	ErrNotBisecting Error = "not bisecting"
+	// ErrBisectRange is returned when a good commit is not an ancestor of the bad commit
+	ErrBisectRange Error = "good commits must be ancestors of the bad commit"
+	// ErrUnknownIdentity is returned when the author or the committer has no name or email
+	ErrUnknownIdentity Error = "identity unknown, set user.name and user.email"
+	// ErrEmptyCommitMessage is returned when a commit message has no text but comments
+	ErrEmptyCommitMessage Error = "aborting commit due to empty commit message"
+	// ErrStagedChanges is returned when an operation that commits the index is started with staged changes
+	ErrStagedChanges Error = "your index contains uncommitted changes, commit or stash them first"
+	// ErrNestedRepository is returned when an untracked directory is a repository
+	ErrNestedRepository Error = "cannot clean a nested repository"
+	// ErrFileNotFound is returned when the file is not in the revision
+	ErrFileNotFound Error = "file not found in the revision"
+	// ErrNoParent is returned when the commit has no parent
+	ErrNoParent Error = "commit has no parent"
 )
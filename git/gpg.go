@@ -0,0 +1,167 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// SignatureStatus is the result of a signature verification
+type SignatureStatus uint8
+
+// The possible states of a signed object
+const (
+	SignatureNone SignatureStatus = iota
+	SignatureGood
+	SignatureBad
+	SignatureUnverified
+)
+
+var signatureHeaders = []string{
+	"-----BEGIN PGP SIGNATURE-----",
+	"-----BEGIN PGP MESSAGE-----",
+	"-----BEGIN SSH SIGNATURE-----",
+}
+
+// splitSignature separates the armored signature appended to a tag message
+func splitSignature(message string) (string, string) {
+	for _, header := range signatureHeaders {
+		if i := strings.Index(message, header); i >= 0 {
+			return message[:i], message[i:]
+		}
+	}
+	return message, ""
+}
+
+// rawObject reads the object content from the object database
+func (r *Repository) rawObject(oid *lib.Oid) ([]byte, error) {
+	odb, err := r.essence.Odb()
+	if err != nil {
+		return nil, err
+	}
+	defer odb.Free()
+	obj, err := odb.Read(oid)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Free()
+	data := obj.Data()
+	buf := make([]byte, len(data))
+	copy(buf, data) // data points to unmanaged memory
+	return buf, nil
+}
+
+// SignatureVerifier verifies the signatures with the programs configured for
+// the repository. It only runs the programs, so it can be used in another
+// goroutine than the repository.
+type SignatureVerifier struct {
+	GPGProgram     string
+	SSHProgram     string
+	AllowedSigners string // the ssh signatures are not verified without it
+}
+
+// SignatureVerifier returns a verifier with the gpg.program (or the
+// gpg.openpgp.program), gpg.ssh.program and gpg.ssh.allowedSignersFile
+func (r *Repository) SignatureVerifier() *SignatureVerifier {
+	v := &SignatureVerifier{
+		GPGProgram: "gpg",
+		SSHProgram: "ssh-keygen",
+	}
+	cfg, err := r.essence.Config()
+	if err != nil {
+		return v
+	}
+	defer cfg.Free()
+	lookup := func(name string) string {
+		s, _ := cfg.LookupString(name)
+		return s
+	}
+	if p := lookup("gpg.openpgp.program"); len(p) > 0 {
+		v.GPGProgram = p
+	} else if p := lookup("gpg.program"); len(p) > 0 {
+		v.GPGProgram = p
+	}
+	if p := lookup("gpg.ssh.program"); len(p) > 0 {
+		v.SSHProgram = p
+	}
+	if path := lookup("gpg.ssh.allowedSignersFile"); len(path) > 0 {
+		if strings.HasPrefix(path, "~/") {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, path[2:])
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.path, path)
+		}
+		v.AllowedSigners = path
+	}
+	return v
+}
+
+// Verify checks the detached signature of the payload with gpg or ssh-keygen
+// by the format of the signature, the signer is returned if it is good
+func (v *SignatureVerifier) Verify(payload, signature []byte) (SignatureStatus, string) {
+	if len(signature) == 0 {
+		return SignatureNone, ""
+	}
+	file, err := os.CreateTemp("", "gitin-signature-")
+	if err != nil {
+		return SignatureUnverified, ""
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(signature); err != nil {
+		file.Close()
+		return SignatureUnverified, ""
+	}
+	file.Close()
+	if bytes.HasPrefix(signature, []byte("-----BEGIN SSH SIGNATURE-----")) {
+		return v.verifySSH(payload, file.Name())
+	}
+	return v.verifyGPG(payload, file.Name())
+}
+
+// verifyGPG runs gpg to verify an OpenPGP signature
+func (v *SignatureVerifier) verifyGPG(payload []byte, signature string) (SignatureStatus, string) {
+	cmd := exec.Command(v.GPGProgram, "--status-fd=1", "--verify", signature, "-")
+	cmd.Stdin = bytes.NewReader(payload)
+	out, _ := cmd.Output() // exit status is not zero for bad signatures
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimPrefix(scanner.Text(), "[GNUPG:] "), " ", 3)
+		switch fields[0] {
+		case "GOODSIG":
+			if len(fields) > 2 {
+				return SignatureGood, fields[2]
+			}
+			return SignatureGood, ""
+		case "BADSIG":
+			return SignatureBad, ""
+		}
+	}
+	return SignatureUnverified, ""
+}
+
+// verifySSH runs ssh-keygen to verify an ssh signature against the allowed
+// signers like "git verify-tag" does
+func (v *SignatureVerifier) verifySSH(payload []byte, signature string) (SignatureStatus, string) {
+	if len(v.AllowedSigners) == 0 {
+		return SignatureUnverified, ""
+	}
+	out, err := exec.Command(v.SSHProgram, "-Y", "find-principals", "-f", v.AllowedSigners, "-s", signature).Output()
+	if err != nil {
+		// the key is not one of the allowed signers
+		return SignatureUnverified, ""
+	}
+	principal := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	cmd := exec.Command(v.SSHProgram, "-Y", "verify", "-n", "git", "-f", v.AllowedSigners, "-I", principal, "-s", signature)
+	cmd.Stdin = bytes.NewReader(payload)
+	if err := cmd.Run(); err != nil {
+		return SignatureBad, ""
+	}
+	return SignatureGood, principal
+}
@@ -0,0 +1,36 @@
+package git
+
+import "testing"
+
+func TestSplitSignature(t *testing.T) {
+	message, signature := splitSignature("v1.0.0\n-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n")
+	if message != "v1.0.0\n" {
+		t.Errorf("unexpected message %q", message)
+	}
+	if signature != "-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n" {
+		t.Errorf("unexpected signature %q", signature)
+	}
+	if _, signature := splitSignature("v1.0.0\n"); len(signature) != 0 {
+		t.Errorf("expected no signature, got %q", signature)
+	}
+}
+
+func TestVerifySignatureFormat(t *testing.T) {
+	// the programs do not exist, so none of the signatures can be verified
+	v := &SignatureVerifier{GPGProgram: "gitin-no-such-gpg", SSHProgram: "gitin-no-such-ssh-keygen"}
+	if status, _ := v.Verify([]byte("payload"), nil); status != SignatureNone {
+		t.Errorf("expected no signature, got %v", status)
+	}
+	ssh := []byte("-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n")
+	if status, _ := v.Verify([]byte("payload"), ssh); status != SignatureUnverified {
+		t.Errorf("expected an unverified ssh signature without allowed signers, got %v", status)
+	}
+	v.AllowedSigners = "allowed_signers"
+	if status, _ := v.Verify([]byte("payload"), ssh); status != SignatureUnverified {
+		t.Errorf("expected an unverified ssh signature, got %v", status)
+	}
+	pgp := []byte("-----BEGIN PGP SIGNATURE-----\n\niQEz\n-----END PGP SIGNATURE-----\n")
+	if status, _ := v.Verify([]byte("payload"), pgp); status != SignatureUnverified {
+		t.Errorf("expected an unverified gpg signature, got %v", status)
+	}
+}
@@ -0,0 +1,252 @@
+package git
+
+// GraphCell is a symbol of the commit graph, the lane is the column of the
+// branch line that the symbol belongs to
+type GraphCell struct {
+	Symbol rune
+	Lane   int
+}
+
+// GraphRow is the graph drawn to the left of a commit or on a connector row
+// below it, every lane takes two cells: the lane symbol and the connector to
+// the next lane
+type GraphRow []GraphCell
+
+// The symbols of the graph
+const (
+	GraphCommit     = '●'
+	GraphMerge      = '◎'
+	GraphLine       = '│'
+	GraphHorizontal = '─'
+	GraphCross      = '┼'
+	GraphForkRight  = '╮'
+	GraphForkLeft   = '╭'
+	GraphJoinRight  = '╯'
+	GraphJoinLeft   = '╰'
+	GraphTeeRight   = '┤'
+	GraphTeeLeft    = '├'
+	GraphJoinCross  = '┴'
+	GraphForkCross  = '┬'
+)
+
+// graphCrossings are the symbols of the corners that a line passes through
+var graphCrossings = map[rune]rune{
+	GraphJoinRight: GraphJoinCross,
+	GraphJoinLeft:  GraphJoinCross,
+	GraphForkRight: GraphForkCross,
+	GraphForkLeft:  GraphForkCross,
+	GraphTeeRight:  GraphCross,
+	GraphTeeLeft:   GraphCross,
+}
+
+// Graph computes the lanes of the commits one by one, the commits should be
+// added in topological order i.e. children before their parents
+type Graph struct {
+	lanes []string // hash of the commit that is expected on each lane
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{}
+}
+
+// Add places the commit on a lane and returns the rows to draw for it
+func (g *Graph) Add(c *Commit) []GraphRow {
+	return g.AddHash(c.Hash, c.ParentHashes())
+}
+
+// AddHash places the commit with the given parents on a lane and returns the
+// rows to draw for it. The first row is the commit's, it may be followed by a
+// connector row if the commit both joins and branches out lanes.
+func (g *Graph) AddHash(hash string, parents []string) []GraphRow {
+	before := make([]string, len(g.lanes))
+	copy(before, g.lanes)
+
+	col := -1
+	joins := make([]int, 0)
+	for i, lane := range g.lanes {
+		if lane != hash {
+			continue
+		}
+		if col < 0 {
+			col = i
+		} else {
+			joins = append(joins, i)
+		}
+	}
+	if col < 0 {
+		col = g.freeLane(-1)
+	}
+	g.lanes[col] = ""
+	forks := make([]int, 0)
+	tees := make([]int, 0)
+	for i, p := range parents {
+		if i == 0 {
+			g.lanes[col] = p
+			continue
+		}
+		if j := g.laneOf(p); j >= 0 {
+			if j != col {
+				tees = append(tees, j)
+			}
+			continue
+		}
+		j := g.freeLane(col)
+		g.lanes[j] = p
+		forks = append(forks, j)
+	}
+	// joined lanes are freed after the forks so that they are not reused on
+	// the same row
+	for _, j := range joins {
+		g.lanes[j] = ""
+	}
+
+	width := len(g.lanes)
+	if len(before) > width {
+		width = len(before)
+	}
+	row := newGraphRow(width)
+	for i := range before {
+		if len(before[i]) > 0 && before[i] != hash {
+			row[2*i].Symbol = GraphLine
+		}
+	}
+	symbol := GraphCommit
+	if len(parents) > 1 {
+		symbol = GraphMerge
+	}
+	row[2*col].Symbol = symbol
+	for _, j := range joins {
+		row.connect(col, j, GraphJoinRight, GraphJoinLeft)
+	}
+	rows := []GraphRow{row}
+	branches := row
+	if len(joins) > 0 && len(forks)+len(tees) > 0 {
+		// the joined and the branched out lanes would run into each other, so
+		// the lanes branch out on a connector row below the commit
+		branches = newGraphRow(width)
+		for i, lane := range g.lanes {
+			if len(lane) > 0 {
+				branches[2*i].Symbol = GraphLine
+			}
+		}
+		rows = append(rows, branches)
+	}
+	for _, j := range tees {
+		branches.connect(col, j, GraphTeeRight, GraphTeeLeft)
+	}
+	for _, j := range forks {
+		branches.connect(col, j, GraphForkRight, GraphForkLeft)
+	}
+	if len(rows) > 1 {
+		branches[2*col].Symbol = teeSymbol(col, append(tees, forks...))
+	}
+	g.trim()
+	for i := range rows {
+		rows[i] = rows[i].trim()
+	}
+	return rows
+}
+
+// newGraphRow creates an empty row with the given number of lanes
+func newGraphRow(width int) GraphRow {
+	row := make(GraphRow, 2*width)
+	for i := 0; i < width; i++ {
+		row[2*i] = GraphCell{Symbol: ' ', Lane: i}
+		row[2*i+1] = GraphCell{Symbol: ' ', Lane: i}
+	}
+	return row
+}
+
+// connect draws the symbol of the lane j and a line to it from the lane of
+// the commit, right or left is used depending on the side of the lane
+func (row GraphRow) connect(col, j int, right, left rune) {
+	symbol, start, end := left, 2*j+1, 2*col
+	if j > col {
+		symbol, start, end = right, 2*col+1, 2*j
+	}
+	// a line to a farther lane already runs through the symbol
+	if s := row[2*j].Symbol; s == GraphHorizontal || s == GraphCross {
+		symbol = graphCrossings[symbol]
+	}
+	row[2*j] = GraphCell{Symbol: symbol, Lane: j}
+	row.horizontal(start, end, j)
+}
+
+// teeSymbol returns the symbol of the commit's lane on a connector row that
+// branches out to the lanes
+func teeSymbol(col int, lanes []int) rune {
+	left, right := false, false
+	for _, j := range lanes {
+		if j > col {
+			right = true
+		} else {
+			left = true
+		}
+	}
+	switch {
+	case left && right:
+		return GraphCross
+	case left:
+		return GraphTeeRight
+	default:
+		return GraphTeeLeft
+	}
+}
+
+// horizontal draws a line from start to end (exclusive) in the color of the
+// lane, the lines and the corners that are passed through are crossed
+func (row GraphRow) horizontal(start, end, lane int) {
+	for i := start; i < end; i++ {
+		switch row[i].Symbol {
+		case ' ':
+			row[i] = GraphCell{Symbol: GraphHorizontal, Lane: lane}
+		case GraphLine:
+			row[i].Symbol = GraphCross
+		default:
+			if crossing, ok := graphCrossings[row[i].Symbol]; ok {
+				row[i].Symbol = crossing
+			}
+		}
+	}
+}
+
+// trim removes the trailing spaces of the row
+func (row GraphRow) trim() GraphRow {
+	end := len(row)
+	for end > 0 && row[end-1].Symbol == ' ' {
+		end--
+	}
+	return row[:end]
+}
+
+// laneOf returns the lane that expects the commit or -1
+func (g *Graph) laneOf(hash string) int {
+	for i, lane := range g.lanes {
+		if lane == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// freeLane returns the first empty lane after the column, a new lane is
+// appended if there is none
+func (g *Graph) freeLane(after int) int {
+	for i := after + 1; i < len(g.lanes); i++ {
+		if len(g.lanes[i]) == 0 {
+			return i
+		}
+	}
+	g.lanes = append(g.lanes, "")
+	return len(g.lanes) - 1
+}
+
+// trim removes the empty lanes at the end
+func (g *Graph) trim() {
+	end := len(g.lanes)
+	for end > 0 && len(g.lanes[end-1]) == 0 {
+		end--
+	}
+	g.lanes = g.lanes[:end]
+}
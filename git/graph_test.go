@@ -0,0 +1,92 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+// graphSymbols returns the symbols of the rows, one line for each row
+func graphSymbols(rows []GraphRow) string {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		symbols := make([]rune, len(row))
+		for j, c := range row {
+			symbols[j] = c.Symbol
+		}
+		lines[i] = string(symbols)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestGraph(t *testing.T) {
+	var tests = []struct {
+		hash    string
+		parents []string
+		rows    string
+	}{
+		{"a", []string{"b", "c"}, "◎─╮"},
+		{"b", []string{"d"}, "● │"},
+		{"e", []string{"c"}, "│ │ ●"},
+		{"c", []string{"d"}, "│ ●─╯"},
+		{"d", []string{"f", "g"}, "◎─╯\n├───╮"},
+		{"f", []string{}, "●   │"},
+		{"g", []string{}, "    ●"},
+	}
+	g := NewGraph()
+	for _, test := range tests {
+		if rows := graphSymbols(g.AddHash(test.hash, test.parents)); rows != test.rows {
+			t.Errorf("%s: expected %q, got %q", test.hash, test.rows, rows)
+		}
+	}
+	if len(g.lanes) != 0 {
+		t.Errorf("expected no lanes left, got %q", g.lanes)
+	}
+}
+
+func TestGraphJoinAndFork(t *testing.T) {
+	var commits = []struct {
+		hash    string
+		parents []string
+	}{
+		{"a", []string{"c"}},
+		{"b", []string{"d", "c"}},
+		{"e", []string{"c"}},
+		{"c", []string{"f", "g", "d"}},
+		{"d", []string{"f"}},
+		{"g", []string{"f"}},
+		{"f", []string{}},
+	}
+	// the corners end the line of their lane, a line running on past a
+	// corner would make the lanes on the row indistinguishable
+	ends := map[rune]int{
+		GraphJoinRight: 1, GraphForkRight: 1,
+		GraphJoinLeft: -1, GraphForkLeft: -1,
+	}
+	g := NewGraph()
+	for _, c := range commits {
+		for _, row := range g.AddHash(c.hash, c.parents) {
+			joins, branches := false, false
+			for i, cell := range row {
+				switch cell.Symbol {
+				case GraphJoinRight, GraphJoinLeft:
+					joins = true
+				case GraphForkRight, GraphForkLeft, GraphTeeRight, GraphTeeLeft:
+					branches = true
+				}
+				side, ok := ends[cell.Symbol]
+				if !ok || i+side < 0 || i+side >= len(row) {
+					continue
+				}
+				if next := row[i+side].Symbol; next == GraphHorizontal || next == GraphCross {
+					t.Errorf("%s: the line of lane %d runs past its corner in %q", c.hash, cell.Lane, graphSymbols([]GraphRow{row}))
+				}
+			}
+			if joins && branches {
+				t.Errorf("%s: lanes are joined and branched out on the same row %q", c.hash, graphSymbols([]GraphRow{row}))
+			}
+		}
+	}
+	if len(g.lanes) != 0 {
+		t.Errorf("expected no lanes left, got %q", g.lanes)
+	}
+}
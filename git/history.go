@@ -0,0 +1,156 @@
+package git
+
+import (
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// FileRevision is a commit that changed a file, the delta is the change of
+// that file only
+type FileRevision struct {
+	Commit *Commit
+	Delta  *DiffDelta
+	Path   string // path of the file in the commit
+}
+
+func (f *FileRevision) String() string {
+	return f.Commit.String()
+}
+
+// Renamed returns true if the file is renamed in the commit
+func (f *FileRevision) Renamed() bool {
+	return f.Delta.Status == DeltaRenamed
+}
+
+// FileHistory walks the commits that changed the file starting from the
+// revision (or HEAD if it is empty) and sends them to the channel with given
+// size. Renames are followed like "git log --follow".
+func (r *Repository) FileHistory(rev, path string, size int) (chan *FileRevision, error) {
+	opts := &LogOptions{}
+	if len(rev) > 0 {
+		opts.Revisions = []string{rev}
+	}
+	walk, err := r.walk(opts)
+	if err != nil {
+		return nil, err
+	}
+	buffer := make(chan *FileRevision, size)
+
+	go func() {
+		defer walk.Free()
+		defer close(buffer)
+		_ = walk.Iterate(func(commit *lib.Commit) bool {
+			revision, err := r.fileRevision(commit, path)
+			if err != nil {
+				return false
+			}
+			if revision != nil {
+				buffer <- revision
+				// older commits have the file with its name before the rename
+				path = revision.Delta.OldFile.Path
+			}
+			return true
+		})
+	}()
+
+	return buffer, nil
+}
+
+// fileRevision returns the change of the file in the commit or nil if the
+// commit does not change it. The commit that deletes the file is a revision
+// as well.
+func (r *Repository) fileRevision(commit *lib.Commit, path string) (*FileRevision, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+	id := treeEntryID(tree, path)
+	if id == nil && commit.ParentCount() == 0 {
+		return nil, nil
+	}
+	var parentTree *lib.Tree
+	for i := uint(0); i < commit.ParentCount(); i++ {
+		parent := commit.Parent(i)
+		pt, err := parent.Tree()
+		parent.Free()
+		if err != nil {
+			return nil, err
+		}
+		// the merges that take the file from a parent as it is do not change
+		// it, a missing file is not deleted unless the parents have it
+		pid := treeEntryID(pt, path)
+		if (pid == nil && id == nil) || (pid != nil && id != nil && pid.Equal(id)) {
+			pt.Free()
+			if parentTree != nil {
+				parentTree.Free()
+			}
+			return nil, nil
+		}
+		if i == 0 {
+			parentTree = pt
+		} else {
+			pt.Free()
+		}
+	}
+	if parentTree != nil {
+		defer parentTree.Free()
+	}
+
+	opts, err := lib.DefaultDiffOptions()
+	if err != nil {
+		return nil, err
+	}
+	added := treeEntryID(parentTree, path) == nil
+	if !added {
+		opts.Pathspec = []string{path}
+		opts.Flags |= lib.DiffDisablePathspecMatch
+	}
+	diff, err := r.essence.DiffTreeToTree(parentTree, tree, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer diff.Free()
+	if added && parentTree != nil {
+		// the file may be renamed, so the whole tree is compared
+		findOpts, err := lib.DefaultDiffFindOptions()
+		if err != nil {
+			return nil, err
+		}
+		findOpts.Flags = lib.DiffFindRenames
+		if err := diff.FindSimilar(&findOpts); err != nil {
+			return nil, err
+		}
+	}
+	n, err := diff.NumDeltas()
+	if err != nil {
+		return nil, err
+	}
+	c := unpackRawCommit(r, commit)
+	for i := 0; i < n; i++ {
+		dd, err := diff.GetDelta(i)
+		if err != nil {
+			return nil, err
+		}
+		if dd.NewFile.Path != path {
+			continue
+		}
+		delta, err := newDiffDelta(diff, i, c)
+		if err != nil {
+			return nil, err
+		}
+		return &FileRevision{Commit: c, Delta: delta, Path: path}, nil
+	}
+	return nil, nil
+}
+
+// treeEntryID returns the id of the entry at the path or nil if there is none
+func treeEntryID(tree *lib.Tree, path string) *lib.Oid {
+	if tree == nil {
+		return nil
+	}
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		return nil
+	}
+	return entry.Id
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileHistory(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "old.txt", "a\nb\nc\nd\ne\n")
+	commitTestFile(t, r, "other.txt", "x\n")
+
+	// rename old.txt to new.txt
+	if err := os.Remove(filepath.Join(r.Path(), "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, r.Path(), "new.txt", "a\nb\nc\nd\ne\n")
+	if err := r.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Commit("rename", &Signature{Name: "gitin", Email: "gitin@example.com", When: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	last := commitTestFile(t, r, "new.txt", "a\nb\nc\nd\ne\nf\n")
+
+	revisions, err := r.FileHistory("", "new.txt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]*FileRevision, 0)
+	for rev := range revisions {
+		got = append(got, rev)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(got))
+	}
+	var tests = []struct {
+		path    string
+		renamed bool
+	}{
+		{"new.txt", false},
+		{"new.txt", true},
+		{"old.txt", false},
+	}
+	for i, test := range tests {
+		if got[i].Path != test.path || got[i].Renamed() != test.renamed {
+			t.Errorf("revision %d: expected %s (renamed: %t), got %s (renamed: %t)",
+				i, test.path, test.renamed, got[i].Path, got[i].Renamed())
+		}
+	}
+	if got[0].Commit.Hash != last.Hash || got[2].Commit.Hash != first.Hash {
+		t.Error("unexpected commits in the history")
+	}
+}
+
+func TestFileHistoryDeleted(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "deleted.txt", "a\n")
+	commitTestFile(t, r, "deleted.txt", "a\nb\n")
+	if err := os.Remove(filepath.Join(r.Path(), "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	deletion, err := r.Commit("delete", &Signature{Name: "gitin", Email: "gitin@example.com", When: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	commitTestFile(t, r, "other.txt", "x\n")
+
+	revisions, err := r.FileHistory("", "deleted.txt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]*FileRevision, 0)
+	for rev := range revisions {
+		got = append(got, rev)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(got))
+	}
+	if got[0].Commit.Hash != deletion.Hash || got[0].Delta.Status != DeltaDeleted {
+		t.Errorf("expected the deletion %s first, got %s (%s)", deletion.Hash[:7], got[0].Commit.Hash[:7], got[0].Delta.DeltaStatusString())
+	}
+	if got[0].Path != "deleted.txt" {
+		t.Errorf("expected deleted.txt, got %s", got[0].Path)
+	}
+}
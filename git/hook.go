@@ -0,0 +1,86 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HookError is returned when a hook rejects an operation, the output of the
+// hook usually tells why
+type HookError struct {
+	Hook   string
+	Output string
+}
+
+func (e *HookError) Error() string {
+	message := e.Hook + " hook failed"
+	if lines := strings.SplitN(e.Output, "\n", 2); len(lines[0]) > 0 {
+		message += ": " + lines[0]
+	}
+	return message
+}
+
+// hookPath returns the executable of the hook in the hooks directory or in
+// the core.hooksPath, it is empty if there is no such hook
+func (r *Repository) hookPath(name string) string {
+	dir := filepath.Join(r.commonDir(), "hooks")
+	if cfg, err := r.essence.Config(); err == nil {
+		if path, err := cfg.LookupString("core.hooksPath"); err == nil && len(path) > 0 {
+			if strings.HasPrefix(path, "~/") {
+				home, _ := os.UserHomeDir()
+				path = filepath.Join(home, path[2:])
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(r.path, path)
+			}
+			dir = path
+		}
+		cfg.Free()
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return ""
+	}
+	return path
+}
+
+// RunHook runs the hook with the arguments in the root of the working tree
+// like git does, a missing hook succeeds
+func (r *Repository) RunHook(name string, args ...string) error {
+	path := r.hookPath(name)
+	if len(path) == 0 {
+		return nil
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Dir = r.path
+	cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(r.essence.Path(), "index"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return &HookError{Hook: name, Output: strings.TrimSpace(string(out))}
+	}
+	return nil
+}
+
+// RunCommitMsgHook runs the commit-msg hook on the message, the message is
+// written to COMMIT_EDITMSG since the hook can edit it. The cleaned up
+// message is returned.
+func (r *Repository) RunCommitMsgHook(message string) (string, error) {
+	path := filepath.Join(r.essence.Path(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(path, []byte(message), 0644); err != nil {
+		return "", err
+	}
+	if err := r.RunHook("commit-msg", path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	message = CleanupMessage(string(data))
+	if len(message) == 0 {
+		return "", ErrEmptyCommitMessage
+	}
+	return message, nil
+}
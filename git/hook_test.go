@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestHook(t *testing.T, r *Repository, name, script string) {
+	t.Helper()
+	dir := filepath.Join(r.commonDir(), "hooks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	r := newTestRepository(t)
+	if err := r.RunHook("pre-commit"); err != nil {
+		t.Errorf("expected a missing hook to succeed, got %v", err)
+	}
+	writeTestHook(t, r, "pre-commit", "echo 'trailing whitespace'\necho 'in file.txt'\nexit 1\n")
+	err := r.RunHook("pre-commit")
+	hookErr, ok := err.(*HookError)
+	if !ok {
+		t.Fatalf("expected a hook error, got %v", err)
+	}
+	if hookErr.Output != "trailing whitespace\nin file.txt" {
+		t.Errorf("expected the output of the hook, got %q", hookErr.Output)
+	}
+	if err.Error() != "pre-commit hook failed: trailing whitespace" {
+		t.Errorf("unexpected error %q", err.Error())
+	}
+}
+
+func TestRunCommitMsgHook(t *testing.T) {
+	r := newTestRepository(t)
+	writeTestHook(t, r, "commit-msg", "printf '\\nSigned-off-by: gitin <gitin@example.com>\\n' >> \"$1\"\n")
+	message, err := r.RunCommitMsgHook("subject\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "subject\n\nSigned-off-by: gitin <gitin@example.com>\n"; message != expected {
+		t.Errorf("expected %q, got %q", expected, message)
+	}
+	writeTestHook(t, r, "commit-msg", "exit 1\n")
+	if _, err := r.RunCommitMsgHook("subject\n"); err == nil {
+		t.Error("expected the hook to reject the message")
+	}
+}
@@ -0,0 +1,194 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// AddToIndex is the wrapper of "git add -- <path>", deleted files are removed
+// from the index and conflicts of the path are marked as resolved
+func (r *Repository) AddToIndex(e *StatusEntry) error {
+	return r.updateIndex(func(index *lib.Index) error {
+		for _, path := range e.paths() {
+			if err := r.addPath(index, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveFromIndex is the wrapper of "git reset HEAD -- <path>", the staged
+// changes are undone while the working tree is left untouched
+func (r *Repository) RemoveFromIndex(e *StatusEntry) error {
+	if !e.Indexed() {
+		return ErrEntryNotIndexed
+	}
+	tree, err := r.headTree()
+	if err != nil {
+		return err
+	}
+	if tree != nil {
+		defer tree.Free()
+	}
+	return r.updateIndex(func(index *lib.Index) error {
+		for _, path := range e.paths() {
+			if err := resetPath(index, tree, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddAll is the wrapper of "git add --all"
+func (r *Repository) AddAll() error {
+	return r.updateIndex(func(index *lib.Index) error {
+		if err := index.AddAll(nil, lib.IndexAddDefault, nil); err != nil {
+			return err
+		}
+		// stage the deleted files as well
+		return index.UpdateAll(nil, nil)
+	})
+}
+
+// ResetAll is the wrapper of "git reset --mixed"
+func (r *Repository) ResetAll() error {
+	unborn, err := r.essence.IsHeadUnborn()
+	if err != nil {
+		return err
+	}
+	if unborn {
+		return r.updateIndex(func(index *lib.Index) error {
+			return index.Clear()
+		})
+	}
+	head, err := r.essence.Head()
+	if err != nil {
+		return err
+	}
+	defer head.Free()
+	commit, err := r.essence.LookupCommit(head.Target())
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+	return r.essence.ResetToCommit(commit, lib.ResetMixed, nil)
+}
+
+// ResetHard is the wrapper of "git reset --hard <commit>", the changes in the
+// index and the working tree are discarded
+func (r *Repository) ResetHard(c *Commit) error {
+	return r.essence.ResetToCommit(c.essence, lib.ResetHard, &lib.CheckoutOptions{
+		Strategy: lib.CheckoutForce,
+	})
+}
+
+// DiscardEntry is the wrapper of "git checkout -- <path>", the unstaged
+// changes are overwritten by the staged version of the file
+func (r *Repository) DiscardEntry(e *StatusEntry) error {
+	switch e.EntryType {
+	case StatusEntryTypeConflicted:
+		return ErrEntryConflicted
+	case StatusEntryTypeUntracked:
+		return r.CleanEntry(e)
+	}
+	return r.essence.CheckoutIndex(nil, &lib.CheckoutOptions{
+		Strategy: lib.CheckoutForce | lib.CheckoutDisablePathspecMatch,
+		Paths:    e.paths(),
+	})
+}
+
+// CleanEntry is the wrapper of "git clean --force -- <path>", nested
+// repositories are not removed
+func (r *Repository) CleanEntry(e *StatusEntry) error {
+	if e.EntryType != StatusEntryTypeUntracked {
+		return ErrEntryNotUntracked
+	}
+	for _, path := range e.paths() {
+		abs := filepath.Join(r.essence.Workdir(), path)
+		if _, err := os.Lstat(filepath.Join(abs, ".git")); err == nil {
+			return ErrNestedRepository
+		}
+		if err := os.RemoveAll(abs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateIndex applies the changes to the repository index and writes it
+func (r *Repository) updateIndex(apply func(*lib.Index) error) error {
+	index, err := r.essence.Index()
+	if err != nil {
+		return err
+	}
+	defer index.Free()
+	if err := apply(index); err != nil {
+		return err
+	}
+	return index.Write()
+}
+
+// addPath stages the file on disk, or every file under the directory
+func (r *Repository) addPath(index *lib.Index, path string) error {
+	info, err := os.Lstat(filepath.Join(r.essence.Workdir(), path))
+	switch {
+	case os.IsNotExist(err):
+		return index.RemoveByPath(path)
+	case err != nil:
+		return err
+	case info.IsDir():
+		// untracked directories are listed with a trailing slash
+		return index.AddAll([]string{strings.TrimSuffix(path, "/")}, lib.IndexAddDefault, nil)
+	}
+	return index.AddByPath(path)
+}
+
+// resetPath replaces the index entry with the one in the tree, the entry is
+// removed if the path does not exist in the tree. The tree is nil if the
+// HEAD is unborn.
+func resetPath(index *lib.Index, tree *lib.Tree, path string) error {
+	if tree == nil {
+		return index.RemoveByPath(path)
+	}
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		return index.RemoveByPath(path)
+	}
+	// stage 0 cannot be added while the path has conflicts
+	if index.HasConflicts() {
+		if _, err := index.Conflict(path); err == nil {
+			if err := index.RemoveConflict(path); err != nil {
+				return err
+			}
+		}
+	}
+	return index.Add(&lib.IndexEntry{
+		Mode: entry.Filemode,
+		Id:   entry.Id,
+		Path: path,
+	})
+}
+
+// headTree returns the tree of HEAD, nil is returned if the HEAD is unborn
+func (r *Repository) headTree() (*lib.Tree, error) {
+	unborn, err := r.essence.IsHeadUnborn()
+	if err != nil || unborn {
+		return nil, err
+	}
+	head, err := r.essence.Head()
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+	commit, err := r.essence.LookupCommit(head.Target())
+	if err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+	return commit.Tree()
+}
@@ -0,0 +1,203 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// findEntry returns the staged or unstaged status entry of the path
+func findEntry(t *testing.T, r *Repository, path string, indexed bool) *StatusEntry {
+	t.Helper()
+	st, err := r.LoadStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range st.Entities {
+		if e.String() == path && e.Indexed() == indexed {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestAddToIndexAndRemoveFromIndex(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "deleted.txt", "to be deleted\n")
+	writeTestFile(t, r.Path(), "README.md", "# gitin\nmodified\n")
+	writeTestFile(t, r.Path(), "new.txt", "new\n")
+	if err := os.Remove(filepath.Join(r.Path(), "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		path      string
+		staged    StatusEntryType
+		unstaged  StatusEntryType
+		untracked bool
+	}{
+		{"README.md", StatusEntryTypeModified, StatusEntryTypeModified, false},
+		{"new.txt", StatusEntryTypeNew, StatusEntryTypeUntracked, true},
+		{"deleted.txt", StatusEntryTypeDeleted, StatusEntryTypeDeleted, false},
+	}
+	for _, test := range tests {
+		e := findEntry(t, r, test.path, false)
+		if e == nil {
+			t.Fatalf("%s: unstaged entry not found", test.path)
+		}
+		if err := r.AddToIndex(e); err != nil {
+			t.Fatalf("%s: could not add: %v", test.path, err)
+		}
+		e = findEntry(t, r, test.path, true)
+		if e == nil || e.EntryType != test.staged {
+			t.Fatalf("%s: expected staged entry of type %d, got %v", test.path, test.staged, e)
+		}
+		if findEntry(t, r, test.path, false) != nil {
+			t.Errorf("%s: unstaged entry left after add", test.path)
+		}
+		if err := r.RemoveFromIndex(e); err != nil {
+			t.Fatalf("%s: could not reset: %v", test.path, err)
+		}
+		if findEntry(t, r, test.path, true) != nil {
+			t.Errorf("%s: staged entry left after reset", test.path)
+		}
+		e = findEntry(t, r, test.path, false)
+		if e == nil || e.EntryType != test.unstaged {
+			t.Errorf("%s: expected unstaged entry of type %d, got %v", test.path, test.unstaged, e)
+		}
+		if err := r.RemoveFromIndex(e); err != ErrEntryNotIndexed {
+			t.Errorf("%s: expected %v, got %v", test.path, ErrEntryNotIndexed, err)
+		}
+	}
+}
+
+func TestResetRenamedEntry(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "old.txt", "some content\nthat is long enough\nto be a rename\n")
+	if err := os.Rename(filepath.Join(r.Path(), "old.txt"), filepath.Join(r.Path(), "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	e := findEntry(t, r, "new.txt", true)
+	if e == nil || e.EntryType != StatusEntryTypeRenamed || e.OldPath() != "old.txt" {
+		t.Fatalf("expected a staged rename from old.txt, got %v", e)
+	}
+	if err := r.RemoveFromIndex(e); err != nil {
+		t.Fatal(err)
+	}
+	if e := findEntry(t, r, "old.txt", false); e == nil || e.EntryType != StatusEntryTypeDeleted {
+		t.Errorf("expected old.txt to be deleted in the working tree, got %v", e)
+	}
+	if e := findEntry(t, r, "new.txt", false); e == nil || e.EntryType != StatusEntryTypeUntracked {
+		t.Errorf("expected new.txt to be untracked, got %v", e)
+	}
+}
+
+func TestAddAllAndResetAll(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "deleted.txt", "to be deleted\n")
+	writeTestFile(t, r.Path(), "README.md", "# gitin\nmodified\n")
+	writeTestFile(t, r.Path(), "dir/new.txt", "new\n")
+	if err := os.Remove(filepath.Join(r.Path(), "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	st, err := r.LoadStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Entities) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(st.Entities))
+	}
+	for _, e := range st.Entities {
+		if !e.Indexed() {
+			t.Errorf("%s is not staged", e)
+		}
+	}
+	if err := r.ResetAll(); err != nil {
+		t.Fatal(err)
+	}
+	st, err = r.LoadStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range st.Entities {
+		if e.Indexed() {
+			t.Errorf("%s is staged after reset", e)
+		}
+	}
+}
+
+func TestDiscardAndCleanEntry(t *testing.T) {
+	r := newTestRepository(t)
+	writeTestFile(t, r.Path(), "README.md", "# gitin\nmodified\n")
+	writeTestFile(t, r.Path(), "dir/untracked.txt", "untracked\n")
+
+	e := findEntry(t, r, "README.md", false)
+	if err := r.CleanEntry(e); err != ErrEntryNotUntracked {
+		t.Errorf("expected %v, got %v", ErrEntryNotUntracked, err)
+	}
+	if err := r.DiscardEntry(e); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(r.Path(), "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# gitin\n" {
+		t.Errorf("changes are not discarded: %q", data)
+	}
+
+	e = findEntry(t, r, "dir/", false)
+	if e == nil {
+		t.Fatal("untracked directory not found")
+	}
+	if err := r.CleanEntry(e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(r.Path(), "dir")); !os.IsNotExist(err) {
+		t.Errorf("untracked directory is not removed: %v", err)
+	}
+}
+
+func TestAddToIndexResolvesConflict(t *testing.T) {
+	r := newTestRepository(t)
+	index, err := r.essence.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Free()
+	entry := func(content string) *lib.IndexEntry {
+		oid, err := r.essence.CreateBlobFromBuffer([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &lib.IndexEntry{Path: "README.md", Mode: lib.FilemodeBlob, Id: oid}
+	}
+	if err := index.AddConflict(entry("base\n"), entry("ours\n"), entry("theirs\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Write(); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, r.Path(), "README.md", "resolved\n")
+
+	e := findEntry(t, r, "README.md", false)
+	if e == nil || e.EntryType != StatusEntryTypeConflicted {
+		t.Fatalf("expected a conflicted entry, got %v", e)
+	}
+	if err := r.DiscardEntry(e); err != ErrEntryConflicted {
+		t.Errorf("expected %v, got %v", ErrEntryConflicted, err)
+	}
+	if err := r.AddToIndex(e); err != nil {
+		t.Fatal(err)
+	}
+	if e := findEntry(t, r, "README.md", true); e == nil || e.EntryType != StatusEntryTypeModified {
+		t.Errorf("expected the resolution to be staged, got %v", e)
+	}
+}
@@ -0,0 +1,297 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a problem of a commit message found by a lint rule
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// LintRule checks a cleaned up commit message
+type LintRule interface {
+	Name() string
+	Check(message string) []*Violation
+}
+
+// Linter checks commit messages with its rules, more rules can be appended
+// to the ones that are configured
+type Linter struct {
+	Rules []LintRule
+}
+
+// Lint returns the violations of all rules, it is empty if the message is fine
+func (l *Linter) Lint(message string) []*Violation {
+	violations := make([]*Violation, 0)
+	for _, rule := range l.Rules {
+		violations = append(violations, rule.Check(message)...)
+	}
+	return violations
+}
+
+// SubjectLength is the configured length limit of the subject, it is zero
+// if the length is not checked
+func (l *Linter) SubjectLength() int {
+	for _, rule := range l.Rules {
+		if s, ok := rule.(*SubjectLengthRule); ok {
+			return s.Max
+		}
+	}
+	return 0
+}
+
+// DefaultCommitTypes are the types of the conventional commits specification
+// and the ones commonly used with it
+var DefaultCommitTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// Linter returns the lint rules configured in the gitin.lint section of the
+// git config. The rules are disabled unless they are configured:
+//
+//	gitin.lint.subjectLength   maximum length of the subject
+//	gitin.lint.blankSecondLine require a blank line after the subject
+//	gitin.lint.conventional    require a "type(scope): description" subject
+//	gitin.lint.types           comma separated types, defaults to DefaultCommitTypes
+//	gitin.lint.scopes          comma separated scopes, any scope is allowed if empty
+//	gitin.lint.requireScope    require a scope in the subject
+//	gitin.lint.issuePattern    require a trailer with an issue key matching the regexp
+//	gitin.lint.issueTrailers   comma separated trailer keys, defaults to "Refs"
+//	gitin.lint.forbiddenWords  comma separated words that cannot be in the message
+func (r *Repository) Linter() (*Linter, error) {
+	cfg, err := r.essence.Config()
+	if err != nil {
+		return nil, err
+	}
+	defer cfg.Free()
+	lookupString := func(name string) string {
+		s, _ := cfg.LookupString("gitin.lint." + name)
+		return s
+	}
+	lookupBool := func(name string) bool {
+		b, _ := cfg.LookupBool("gitin.lint." + name)
+		return b
+	}
+	l := &Linter{}
+	if n, err := cfg.LookupInt32("gitin.lint.subjectLength"); err == nil && n > 0 {
+		l.Rules = append(l.Rules, &SubjectLengthRule{Max: int(n)})
+	}
+	if lookupBool("blankSecondLine") {
+		l.Rules = append(l.Rules, &BlankSecondLineRule{})
+	}
+	if lookupBool("conventional") {
+		types := splitList(lookupString("types"))
+		if len(types) == 0 {
+			types = DefaultCommitTypes
+		}
+		l.Rules = append(l.Rules, &ConventionalRule{
+			Types:        types,
+			Scopes:       splitList(lookupString("scopes")),
+			RequireScope: lookupBool("requireScope"),
+		})
+	}
+	if pattern := lookupString("issuePattern"); len(pattern) > 0 {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("gitin.lint.issuePattern: %w", err)
+		}
+		keys := splitList(lookupString("issueTrailers"))
+		if len(keys) == 0 {
+			keys = []string{"Refs"}
+		}
+		l.Rules = append(l.Rules, &IssueTrailerRule{Keys: keys, Pattern: re})
+	}
+	if words := splitList(lookupString("forbiddenWords")); len(words) > 0 {
+		l.Rules = append(l.Rules, &ForbiddenWordsRule{Words: words})
+	}
+	return l, nil
+}
+
+// splitList splits a comma separated config value
+func splitList(s string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func subject(message string) string {
+	return strings.SplitN(message, "\n", 2)[0]
+}
+
+// SubjectLengthRule limits the length of the first line
+type SubjectLengthRule struct {
+	Max int
+}
+
+// Name of the rule
+func (s *SubjectLengthRule) Name() string {
+	return "subject-length"
+}
+
+// Check the length of the subject
+func (s *SubjectLengthRule) Check(message string) []*Violation {
+	if n := utf8.RuneCountInString(subject(message)); n > s.Max {
+		return []*Violation{{
+			Rule:    s.Name(),
+			Message: fmt.Sprintf("subject is %d characters, the limit is %d", n, s.Max),
+		}}
+	}
+	return nil
+}
+
+// BlankSecondLineRule requires the body to be separated from the subject
+type BlankSecondLineRule struct{}
+
+// Name of the rule
+func (b *BlankSecondLineRule) Name() string {
+	return "blank-second-line"
+}
+
+// Check the second line of the message
+func (b *BlankSecondLineRule) Check(message string) []*Violation {
+	lines := strings.SplitN(message, "\n", 3)
+	if len(lines) > 1 && len(strings.TrimSpace(lines[1])) > 0 {
+		return []*Violation{{
+			Rule:    b.Name(),
+			Message: "the second line should be blank",
+		}}
+	}
+	return nil
+}
+
+var conventionalSubject = regexp.MustCompile(`^([a-zA-Z]+)(\(([^()]*)\))?!?: \S`)
+
+// ConventionalRule requires the subject to be a conventional commit header
+// e.g. "feat(parser): support arrays"
+type ConventionalRule struct {
+	Types        []string
+	Scopes       []string // any scope is allowed if it is empty
+	RequireScope bool
+}
+
+// Name of the rule
+func (c *ConventionalRule) Name() string {
+	return "conventional"
+}
+
+// Check the type and the scope of the subject
+func (c *ConventionalRule) Check(message string) []*Violation {
+	violation := func(format string, a ...interface{}) []*Violation {
+		return []*Violation{{Rule: c.Name(), Message: fmt.Sprintf(format, a...)}}
+	}
+	matches := conventionalSubject.FindStringSubmatch(subject(message))
+	if matches == nil {
+		return violation("subject should be like \"type(scope): description\"")
+	}
+	violations := make([]*Violation, 0)
+	if !containsString(c.Types, matches[1]) {
+		violations = append(violations, violation("unknown type %q, use one of %s", matches[1], strings.Join(c.Types, ", "))...)
+	}
+	scope := matches[3]
+	switch {
+	case len(matches[2]) == 0 && c.RequireScope:
+		violations = append(violations, violation("scope is missing")...)
+	case len(matches[2]) > 0 && len(c.Scopes) > 0 && !containsString(c.Scopes, scope):
+		violations = append(violations, violation("unknown scope %q, use one of %s", scope, strings.Join(c.Scopes, ", "))...)
+	}
+	return violations
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueTrailerRule requires a trailer that refers to an issue e.g.
+// "Refs: PROJ-123"
+type IssueTrailerRule struct {
+	Keys    []string
+	Pattern *regexp.Regexp
+}
+
+// Name of the rule
+func (i *IssueTrailerRule) Name() string {
+	return "issue-trailer"
+}
+
+// Check the trailers of the message
+func (i *IssueTrailerRule) Check(message string) []*Violation {
+	for key, values := range Trailers(message) {
+		for _, k := range i.Keys {
+			if !strings.EqualFold(key, k) {
+				continue
+			}
+			for _, value := range values {
+				if i.Pattern.MatchString(value) {
+					return nil
+				}
+			}
+		}
+	}
+	return []*Violation{{
+		Rule:    i.Name(),
+		Message: fmt.Sprintf("add a trailer like \"%s: <issue>\" matching %s", i.Keys[0], i.Pattern),
+	}}
+}
+
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9-]+): (.*)$`)
+
+// Trailers returns the values of the trailers in the last paragraph of the
+// message, the paragraph is not a trailer block unless all lines are trailers
+func Trailers(message string) map[string][]string {
+	trailers := make(map[string][]string)
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		// the subject cannot be a trailer
+		return trailers
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		matches := trailerLine.FindStringSubmatch(line)
+		if matches == nil {
+			return make(map[string][]string)
+		}
+		trailers[matches[1]] = append(trailers[matches[1]], strings.TrimSpace(matches[2]))
+	}
+	return trailers
+}
+
+// ForbiddenWordsRule rejects the messages containing any of the words, the
+// words are matched case-insensitively
+type ForbiddenWordsRule struct {
+	Words []string
+}
+
+// Name of the rule
+func (f *ForbiddenWordsRule) Name() string {
+	return "forbidden-words"
+}
+
+// Check the words of the message
+func (f *ForbiddenWordsRule) Check(message string) []*Violation {
+	violations := make([]*Violation, 0)
+	for _, word := range f.Words {
+		re := regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(word) + `($|\W)`)
+		if re.MatchString(message) {
+			violations = append(violations, &Violation{
+				Rule:    f.Name(),
+				Message: fmt.Sprintf("%q is not allowed in the message", word),
+			})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	var tests = []struct {
+		rule     LintRule
+		message  string
+		expected int
+	}{
+		{&SubjectLengthRule{Max: 10}, "fix typo", 0},
+		{&SubjectLengthRule{Max: 10}, "fix the typo in the readme", 1},
+		{&SubjectLengthRule{Max: 10}, "düzeltildi", 0},
+		{&BlankSecondLineRule{}, "fix typo\n\nbody", 0},
+		{&BlankSecondLineRule{}, "fix typo\nbody", 1},
+		{&BlankSecondLineRule{}, "fix typo", 0},
+		{&ConventionalRule{Types: DefaultCommitTypes}, "fix(parser): handle arrays", 0},
+		{&ConventionalRule{Types: DefaultCommitTypes}, "feat!: drop the old api", 0},
+		{&ConventionalRule{Types: DefaultCommitTypes}, "handle arrays", 1},
+		{&ConventionalRule{Types: DefaultCommitTypes}, "bug(parser): handle arrays", 1},
+		{&ConventionalRule{Types: DefaultCommitTypes, RequireScope: true}, "fix: handle arrays", 1},
+		{&ConventionalRule{Types: DefaultCommitTypes, Scopes: []string{"cli"}}, "fix(parser): handle arrays", 1},
+		{&ConventionalRule{Types: DefaultCommitTypes, Scopes: []string{"cli"}}, "bug(parser): handle arrays", 2},
+		{&IssueTrailerRule{Keys: []string{"Refs"}, Pattern: regexp.MustCompile(`^[A-Z]+-\d+$`)}, "fix typo\n\nRefs: GIT-12", 0},
+		{&IssueTrailerRule{Keys: []string{"Refs"}, Pattern: regexp.MustCompile(`^[A-Z]+-\d+$`)}, "fix typo\n\nrefs: GIT-12", 0},
+		{&IssueTrailerRule{Keys: []string{"Refs"}, Pattern: regexp.MustCompile(`^[A-Z]+-\d+$`)}, "fix typo\n\nRefs: 12", 1},
+		{&IssueTrailerRule{Keys: []string{"Refs"}, Pattern: regexp.MustCompile(`^[A-Z]+-\d+$`)}, "Refs: GIT-12", 1},
+		{&ForbiddenWordsRule{Words: []string{"wip", "fixup"}}, "WIP: fix typo", 1},
+		{&ForbiddenWordsRule{Words: []string{"wip", "fixup"}}, "wipe the cache", 0},
+		{&ForbiddenWordsRule{Words: []string{"wip", "fixup"}}, "fixup wip", 2},
+	}
+	for _, test := range tests {
+		if violations := test.rule.Check(test.message); len(violations) != test.expected {
+			t.Errorf("%s: %q has %d violations, expected %d", test.rule.Name(), test.message, len(violations), test.expected)
+		}
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	trailers := Trailers("fix typo\n\nbody\n\nRefs: GIT-1\nRefs: GIT-2\nSigned-off-by: gitin <gitin@example.com>\n")
+	if len(trailers["Refs"]) != 2 || trailers["Refs"][1] != "GIT-2" {
+		t.Errorf("unexpected Refs trailers: %v", trailers["Refs"])
+	}
+	if len(trailers["Signed-off-by"]) != 1 {
+		t.Errorf("unexpected Signed-off-by trailers: %v", trailers["Signed-off-by"])
+	}
+	if trailers := Trailers("fix typo\n\nRefs: GIT-1\nnot a trailer"); len(trailers) != 0 {
+		t.Errorf("expected no trailers, got %v", trailers)
+	}
+}
+
+func TestLinter(t *testing.T) {
+	r := newTestRepository(t)
+	cfg, err := r.essence.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Free()
+	l, err := r.Linter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Rules) != 0 {
+		t.Errorf("expected no rules without configuration, got %d", len(l.Rules))
+	}
+	if err := cfg.SetInt32("gitin.lint.subjectLength", 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetBool("gitin.lint.conventional", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetString("gitin.lint.forbiddenWords", "wip, tmp"); err != nil {
+		t.Fatal(err)
+	}
+	l, err = r.Linter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.SubjectLength() != 20 {
+		t.Errorf("expected subject length 20, got %d", l.SubjectLength())
+	}
+	if violations := l.Lint("fix: handle arrays"); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+	if violations := l.Lint("wip handle arrays in the parser"); len(violations) != 3 {
+		t.Errorf("expected 3 violations, got %d", len(violations))
+	}
+	if err := cfg.SetString("gitin.lint.issuePattern", "("); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Linter(); err == nil {
+		t.Error("expected an error for an invalid issue pattern")
+	}
+}
@@ -0,0 +1,312 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// LogOptions selects the commits of the history like the arguments of
+// "git log", the HEAD is walked if no revisions are given
+type LogOptions struct {
+	Revisions   []string // revisions and ranges e.g. "main", "a..b", "a...b" or "^a"
+	All         bool     // walk every reference
+	Branches    bool     // walk every local branch
+	Author      string   // regular expression matched against "name <email>"
+	Grep        string   // regular expression matched against the message
+	Since       time.Time
+	Until       time.Time
+	NoMerges    bool
+	FirstParent bool
+	Paths       []string // commits that change the paths
+}
+
+// Filtered returns true if some commits of the walk are skipped, so that the
+// commits may not be connected to their parents
+func (o *LogOptions) Filtered() bool {
+	return len(o.Author) > 0 || len(o.Grep) > 0 || !o.Since.IsZero() || !o.Until.IsZero() ||
+		o.NoMerges || len(o.Paths) > 0
+}
+
+// logFilter is the compiled form of the options that filters the commits
+type logFilter struct {
+	opts   *LogOptions
+	author *regexp.Regexp
+	grep   *regexp.Regexp
+}
+
+func newLogFilter(opts *LogOptions) (*logFilter, error) {
+	f := &logFilter{opts: opts}
+	var err error
+	if len(opts.Author) > 0 {
+		if f.author, err = regexp.Compile(opts.Author); err != nil {
+			return nil, fmt.Errorf("invalid author pattern: %v", err)
+		}
+	}
+	if len(opts.Grep) > 0 {
+		if f.grep, err = regexp.Compile(opts.Grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %v", err)
+		}
+	}
+	return f, nil
+}
+
+// match returns true if the commit should be listed
+func (f *logFilter) match(r *Repository, c *lib.Commit) bool {
+	if f.opts.NoMerges && c.ParentCount() > 1 {
+		return false
+	}
+	when := c.Committer().When
+	if !f.opts.Since.IsZero() && when.Before(f.opts.Since) {
+		return false
+	}
+	if !f.opts.Until.IsZero() && when.After(f.opts.Until) {
+		return false
+	}
+	if f.author != nil {
+		author := c.Author()
+		if !f.author.MatchString(author.Name + " <" + author.Email + ">") {
+			return false
+		}
+	}
+	if f.grep != nil && !f.grep.MatchString(c.Message()) {
+		return false
+	}
+	if len(f.opts.Paths) > 0 {
+		return r.touchesPaths(c, f.opts.Paths)
+	}
+	return true
+}
+
+// touchesPaths returns true if the commit changes the paths compared to its
+// first parent
+func (r *Repository) touchesPaths(c *lib.Commit, paths []string) bool {
+	tree, err := c.Tree()
+	if err != nil {
+		return false
+	}
+	defer tree.Free()
+	var parentTree *lib.Tree
+	if c.ParentCount() > 0 {
+		parent := c.Parent(0)
+		defer parent.Free()
+		if parentTree, err = parent.Tree(); err != nil {
+			return false
+		}
+		defer parentTree.Free()
+	}
+	opts, err := lib.DefaultDiffOptions()
+	if err != nil {
+		return false
+	}
+	opts.Pathspec = paths
+	diff, err := r.essence.DiffTreeToTree(parentTree, tree, &opts)
+	if err != nil {
+		return false
+	}
+	defer diff.Free()
+	n, err := diff.NumDeltas()
+	return err == nil && n > 0
+}
+
+// walk creates a revision walk of the options
+func (r *Repository) walk(opts *LogOptions) (*lib.RevWalk, error) {
+	walk, err := r.essence.Walk()
+	if err != nil {
+		return nil, err
+	}
+	walk.Sorting(lib.SortTopological | lib.SortTime)
+	if opts.FirstParent {
+		walk.SimplifyFirstParent()
+	}
+	if err := r.pushRevisions(walk, opts); err != nil {
+		walk.Free()
+		return nil, err
+	}
+	return walk, nil
+}
+
+func (r *Repository) pushRevisions(walk *lib.RevWalk, opts *LogOptions) error {
+	if opts.All {
+		if err := walk.PushGlob("*"); err != nil {
+			return err
+		}
+		// a detached HEAD is not a reference, an unborn one has no commits
+		_ = walk.PushHead()
+	}
+	if opts.Branches {
+		if err := walk.PushGlob("refs/heads/*"); err != nil {
+			return err
+		}
+	}
+	positive := opts.All || opts.Branches
+	for _, rev := range opts.Revisions {
+		if strings.HasPrefix(rev, "^") {
+			oid, err := r.revisionID(rev[1:])
+			if err != nil {
+				return err
+			}
+			if err := walk.Hide(oid); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.Contains(rev, "..") {
+			oid, err := r.revisionID(rev)
+			if err != nil {
+				return err
+			}
+			if err := walk.Push(oid); err != nil {
+				return err
+			}
+			positive = true
+			continue
+		}
+		if err := r.pushRange(walk, rev); err != nil {
+			return err
+		}
+		positive = true
+	}
+	if !positive {
+		return walk.PushHead()
+	}
+	return nil
+}
+
+// pushRange pushes the commits of "a..b" (reachable from b but not a) or
+// "a...b" (reachable from either but not both), an omitted side is HEAD
+func (r *Repository) pushRange(walk *lib.RevWalk, rev string) error {
+	symmetric := strings.Contains(rev, "...")
+	sep := ".."
+	if symmetric {
+		sep = "..."
+	}
+	sides := strings.SplitN(rev, sep, 2)
+	for i := range sides {
+		if len(sides[i]) == 0 {
+			sides[i] = "HEAD"
+		}
+	}
+	from, err := r.revisionID(sides[0])
+	if err != nil {
+		return err
+	}
+	to, err := r.revisionID(sides[1])
+	if err != nil {
+		return err
+	}
+	if err := walk.Push(to); err != nil {
+		return err
+	}
+	if !symmetric {
+		return walk.Hide(from)
+	}
+	if err := walk.Push(from); err != nil {
+		return err
+	}
+	base, err := r.essence.MergeBase(from, to)
+	if err != nil {
+		// unrelated histories have no common commits to hide
+		return nil
+	}
+	return walk.Hide(base)
+}
+
+// revisionID resolves the revision to the id of a commit
+func (r *Repository) revisionID(rev string) (*lib.Oid, error) {
+	obj, err := r.essence.RevparseSingle(rev)
+	if err != nil {
+		return nil, fmt.Errorf("bad revision %q: %v", rev, err)
+	}
+	defer obj.Free()
+	peeled, err := obj.Peel(lib.ObjectCommit)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a commit: %v", rev, err)
+	}
+	defer peeled.Free()
+	return peeled.Id(), nil
+}
+
+// Log walks the commits selected by the options and sends them to the
+// channel with given size. If the walk fails, the error is sent to the error
+// channel before the commits channel is closed.
+func (r *Repository) Log(opts *LogOptions, size int) (chan *Commit, <-chan error, error) {
+	filter, err := newLogFilter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	walk, err := r.walk(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	buffer := make(chan *Commit, size)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer walk.Free()
+		err := walk.Iterate(func(commit *lib.Commit) bool {
+			if filter.match(r, commit) {
+				buffer <- unpackRawCommit(r, commit)
+			}
+			return true
+		})
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+		close(buffer)
+	}()
+
+	return buffer, errs, nil
+}
+
+// ParseDate parses the dates of "--since" and "--until", the date can be
+// absolute like "2006-01-02" or relative to now like "2 weeks ago"
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		y, m, d := now.AddDate(0, 0, -1).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	s = strings.ToLower(s)
+	fields := strings.Fields(strings.TrimSuffix(s, " ago"))
+	if len(fields) != 2 || !strings.HasSuffix(s, " ago") {
+		return time.Time{}, fmt.Errorf("cannot parse date %q", s)
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse date %q", s)
+	}
+	switch strings.TrimSuffix(fields[1], "s") {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "day":
+		return now.AddDate(0, 0, -n), nil
+	case "week":
+		return now.AddDate(0, 0, -7*n), nil
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	case "year":
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date %q", s)
+}
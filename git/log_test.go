@@ -0,0 +1,90 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	r := newTestRepository(t)
+	a := commitTestFile(t, r, "a.txt", "a\n")
+	b := commitTestFile(t, r, "b.txt", "b\n")
+	c := commitTestFile(t, r, "a.txt", "aa\n")
+
+	var tests = []struct {
+		name     string
+		opts     *LogOptions
+		expected []*Commit
+	}{
+		{"head", &LogOptions{}, []*Commit{c, b, a}},
+		{"revision", &LogOptions{Revisions: []string{"HEAD~1"}}, []*Commit{b, a}},
+		{"range", &LogOptions{Revisions: []string{a.Hash + "..HEAD"}}, []*Commit{c, b}},
+		{"open range", &LogOptions{Revisions: []string{b.Hash + ".."}}, []*Commit{c}},
+		{"symmetric", &LogOptions{Revisions: []string{a.Hash + "..." + c.Hash}}, []*Commit{c, b}},
+		{"hide", &LogOptions{Revisions: []string{"HEAD", "^" + b.Hash}}, []*Commit{c}},
+		{"paths", &LogOptions{Paths: []string{"a.txt"}}, []*Commit{c, a}},
+		{"grep", &LogOptions{Grep: "b\\.txt$"}, []*Commit{b}},
+		{"author", &LogOptions{Author: "nobody"}, []*Commit{}},
+		{"until", &LogOptions{Until: time.Now().Add(-time.Hour)}, []*Commit{}},
+	}
+	for _, test := range tests {
+		commits, errs, err := r.Log(test.opts, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		got := make([]string, 0)
+		for commit := range commits {
+			got = append(got, commit.Hash)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		expected := make([]string, 0)
+		for _, commit := range test.expected {
+			expected = append(expected, commit.Hash)
+		}
+		if len(got) != len(expected) {
+			t.Errorf("%s: expected %d commits, got %d", test.name, len(expected), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Errorf("%s: expected %s at %d, got %s", test.name, expected[i][:7], i, got[i][:7])
+			}
+		}
+	}
+	if _, _, err := r.Log(&LogOptions{Revisions: []string{"nope"}}, 0); err == nil {
+		t.Error("expected an error for a bad revision")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2019, 5, 20, 15, 30, 0, 0, time.UTC)
+	var tests = []struct {
+		input    string
+		expected time.Time
+	}{
+		{"2019-01-02", time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2019-01-02 10:20", time.Date(2019, 1, 2, 10, 20, 0, 0, time.UTC)},
+		{"2019-01-02T10:20:30Z", time.Date(2019, 1, 2, 10, 20, 30, 0, time.UTC)},
+		{"yesterday", time.Date(2019, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"3 hours ago", now.Add(-3 * time.Hour)},
+		{"2 weeks ago", time.Date(2019, 5, 6, 15, 30, 0, 0, time.UTC)},
+		{"1 Month Ago", time.Date(2019, 4, 20, 15, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, err := ParseDate(test.input, now)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if !got.Equal(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.input, test.expected, got)
+		}
+	}
+	for _, input := range []string{"", "soon", "two days ago", "2 fortnights ago"} {
+		if _, err := ParseDate(input, now); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Merge is the wrapper of "git merge <branch>", HEAD is fast-forwarded to
+// the branch if possible, otherwise a merge commit is created. If the changes
+// conflict, the repository is left in StateMerge to resolve them.
+func (r *Repository) Merge(b *Branch) error {
+	oid, err := lib.NewOid(b.Hash)
+	if err != nil {
+		return err
+	}
+	theirs, err := r.essence.LookupAnnotatedCommit(oid)
+	if err != nil {
+		return err
+	}
+	defer theirs.Free()
+	heads := []*lib.AnnotatedCommit{theirs}
+	analysis, _, err := r.essence.MergeAnalysis(heads)
+	if err != nil {
+		return err
+	}
+	if analysis&lib.MergeAnalysisUpToDate != 0 {
+		return ErrAlreadyUpToDate
+	}
+	if analysis&lib.MergeAnalysisFastForward != 0 && r.Head != nil {
+		return r.fastForward(r.Head, oid, "merge "+b.Name+": Fast-forward")
+	}
+	if err := r.checkCleanIndex(); err != nil {
+		return err
+	}
+	opts, err := lib.DefaultMergeOptions()
+	if err != nil {
+		return err
+	}
+	if err := r.essence.Merge(heads, &opts, &lib.CheckoutOptions{
+		Strategy: lib.CheckoutSafe,
+	}); err != nil {
+		return err
+	}
+	commit, err := r.essence.LookupCommit(oid)
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+	message := "Merge branch '" + b.Name + "'"
+	if b.isRemote {
+		message = "Merge remote-tracking branch '" + b.Name + "'"
+	}
+	if _, err := r.commitOperation(message, nil, commit); err != nil {
+		return err
+	}
+	return r.LoadHead()
+}
+
+// Rebase is the wrapper of "git rebase <branch>", the commits of HEAD that
+// are not in the branch are applied on top of it. The rebase is aborted if a
+// commit conflicts since git cannot continue a rebase that libgit2 started.
+func (r *Repository) Rebase(onto *Branch) error {
+	oid, err := lib.NewOid(onto.Hash)
+	if err != nil {
+		return err
+	}
+	head, err := r.essence.Head()
+	if err != nil {
+		return err
+	}
+	defer head.Free()
+	if head.Target().Equal(oid) {
+		return ErrAlreadyUpToDate
+	}
+	if ok, err := r.essence.DescendantOf(head.Target(), oid); err != nil {
+		return err
+	} else if ok {
+		return ErrAlreadyUpToDate
+	}
+	upstream, err := r.essence.LookupAnnotatedCommit(oid)
+	if err != nil {
+		return err
+	}
+	defer upstream.Free()
+	rebase, err := r.essence.InitRebase(nil, upstream, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rebase.Free()
+	committer, err := r.essence.DefaultSignature()
+	if err != nil {
+		rebase.Abort()
+		return err
+	}
+	for {
+		op, err := rebase.Next()
+		if lib.IsErrorCode(err, lib.ErrorCodeIterOver) {
+			break
+		}
+		if err != nil {
+			rebase.Abort()
+			return err
+		}
+		if err := r.commitRebaseOperation(rebase, op, committer); err != nil {
+			rebase.Abort()
+			return err
+		}
+	}
+	if err := rebase.Finish(); err != nil {
+		return err
+	}
+	return r.LoadHead()
+}
+
+// commitRebaseOperation commits the applied commit with its message and
+// author, the commits that are already in the upstream are skipped
+func (r *Repository) commitRebaseOperation(rebase *lib.Rebase, op *lib.RebaseOperation, committer *lib.Signature) error {
+	index, err := r.essence.Index()
+	if err != nil {
+		return err
+	}
+	conflicts := index.HasConflicts()
+	index.Free()
+	if conflicts {
+		return ErrRebaseConflicts
+	}
+	commit, err := r.essence.LookupCommit(op.Id)
+	if err != nil {
+		return err
+	}
+	defer commit.Free()
+	// an empty message would be written as is instead of reusing the original
+	err = rebase.Commit(op.Id, commit.Author(), committer, commit.Message())
+	if lib.IsErrorCode(err, lib.ErrorCodeApplied) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package git
+
+import (
+	"unicode"
+)
+
+// LinePair is a row of a side-by-side diff, Old or New is nil if the other
+// side has no counterpart
+type LinePair struct {
+	Old *DiffLine
+	New *DiffLine
+}
+
+// maximum number of words that are compared to find the changed words
+const maxWordDiffTokens = 256
+
+// PairLines aligns the lines of the hunk for a side-by-side view. Context
+// lines are paired with themselves and the deletions are paired with the
+// additions that follow them in order.
+func PairLines(h *Hunk) []*LinePair {
+	pairs := make([]*LinePair, 0, len(h.Lines))
+	var dels, adds []*DiffLine
+	flush := func() {
+		for i := 0; i < len(dels) || i < len(adds); i++ {
+			pair := &LinePair{}
+			if i < len(dels) {
+				pair.Old = dels[i]
+			}
+			if i < len(adds) {
+				pair.New = adds[i]
+			}
+			pairs = append(pairs, pair)
+		}
+		dels, adds = nil, nil
+	}
+	for _, l := range h.Lines {
+		switch l.Type {
+		case DiffLineDeletion:
+			if len(adds) > 0 {
+				// a deletion after additions starts a new block
+				flush()
+			}
+			dels = append(dels, l)
+		case DiffLineAddition:
+			adds = append(adds, l)
+		default:
+			flush()
+			pairs = append(pairs, &LinePair{Old: l, New: l})
+		}
+	}
+	flush()
+	return pairs
+}
+
+// Changed returns true if the pair is a modified line
+func (p *LinePair) Changed() bool {
+	return p.Old != nil && p.New != nil && p.Old != p.New
+}
+
+// WordDiff compares the old and the new version of a line word by word, the
+// returned masks have an element for every rune of the lines that is true if
+// the rune belongs to a changed word
+func WordDiff(old, new string) ([]bool, []bool) {
+	a, b := splitWords([]rune(old)), splitWords([]rune(new))
+	oldMask, newMask := make([]bool, len([]rune(old))), make([]bool, len([]rune(new)))
+	if len(a) > maxWordDiffTokens || len(b) > maxWordDiffTokens {
+		// too expensive to compare, the whole lines are changed
+		fill(oldMask, 0, len(oldMask))
+		fill(newMask, 0, len(newMask))
+		return oldMask, newMask
+	}
+	// longest common subsequence of the words
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i].text == b[j].text:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			fill(oldMask, a[i].start, a[i].end)
+			i++
+		default:
+			fill(newMask, b[j].start, b[j].end)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		fill(oldMask, a[i].start, a[i].end)
+	}
+	for ; j < len(b); j++ {
+		fill(newMask, b[j].start, b[j].end)
+	}
+	return oldMask, newMask
+}
+
+type word struct {
+	text       string
+	start, end int // rune offsets
+}
+
+// splitWords splits the line into identifiers, runs of spaces and single
+// punctuation characters
+func splitWords(runes []rune) []word {
+	words := make([]word, 0)
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	for start := 0; start < len(runes); {
+		end := start + 1
+		if c := class(runes[start]); c != 0 {
+			for end < len(runes) && class(runes[end]) == c {
+				end++
+			}
+		}
+		words = append(words, word{text: string(runes[start:end]), start: start, end: end})
+		start = end
+	}
+	return words
+}
+
+func fill(mask []bool, start, end int) {
+	for i := start; i < end && i < len(mask); i++ {
+		mask[i] = true
+	}
+}
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestPairLines(t *testing.T) {
+	hunk := newTestHunk(1, 1, " a", "-b", "-c", "+B", " d", "+e", "-f", "+F", "+G", "-h")
+	var tests = []struct {
+		old, new string
+	}{
+		{"a", "a"},
+		{"b", "B"},
+		{"c", ""},
+		{"d", "d"},
+		{"", "e"},
+		{"f", "F"},
+		{"", "G"},
+		{"h", ""},
+	}
+	pairs := PairLines(hunk)
+	if len(pairs) != len(tests) {
+		t.Fatalf("expected %d pairs, got %d", len(tests), len(pairs))
+	}
+	text := func(l *DiffLine) string {
+		if l == nil {
+			return ""
+		}
+		return l.String()
+	}
+	for i, test := range tests {
+		if got := pairs[i]; text(got.Old) != test.old || text(got.New) != test.new {
+			t.Errorf("pair %d: expected %q|%q, got %q|%q", i, test.old, test.new, text(got.Old), text(got.New))
+		}
+	}
+	if pairs[0].Changed() || !pairs[1].Changed() || pairs[2].Changed() {
+		t.Error("only the pairs of a deletion and an addition should be changed")
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	var tests = []struct {
+		old, new         string
+		oldMask, newMask string
+	}{
+		{"return a + b", "return a + b", "            ", "            "},
+		{"x := foo(1)", "x := bar(1)", "     ^^^   ", "     ^^^   "},
+		{"if ok {", "if ok && 日本 {", "       ", "      ^^^^^^ "},
+		{"abc", "", "^^^", ""},
+	}
+	mask := func(m []bool) string {
+		s := make([]rune, len(m))
+		for i, changed := range m {
+			s[i] = ' '
+			if changed {
+				s[i] = '^'
+			}
+		}
+		return string(s)
+	}
+	for _, test := range tests {
+		oldMask, newMask := WordDiff(test.old, test.new)
+		if mask(oldMask) != test.oldMask || mask(newMask) != test.newMask {
+			t.Errorf("%q → %q: expected %q|%q, got %q|%q", test.old, test.new,
+				test.oldMask, test.newMask, mask(oldMask), mask(newMask))
+		}
+	}
+}
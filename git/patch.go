@@ -0,0 +1,482 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// DiffLineType is the origin of a line in a hunk
+type DiffLineType int
+
+// The types of the lines in a hunk
+const (
+	DiffLineContext DiffLineType = iota
+	DiffLineAddition
+	DiffLineDeletion
+)
+
+// DiffLine is a single line of a hunk, line numbers are -1 if the line does
+// not exist on that side
+type DiffLine struct {
+	Type      DiffLineType
+	Content   string
+	OldLineno int
+	NewLineno int
+}
+
+// Hunk is a contiguous block of changes in a file
+type Hunk struct {
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []*DiffLine
+}
+
+// FilePatch holds the hunks of the staged or unstaged changes of a status
+// entry, it is the base of staging individual hunks and lines
+type FilePatch struct {
+	entry *StatusEntry
+	hunks []*Hunk // the hunks as libgit2 created them
+	old   string  // the content that the hunks are applied to
+	mode  lib.Filemode
+
+	Path   string
+	Staged bool
+	Hunks  []*Hunk
+}
+
+// LoadPatch creates the patch of the entry, the patch is between HEAD and
+// the index if the entry is staged, otherwise between the index and the
+// working tree
+func (r *Repository) LoadPatch(e *StatusEntry) (*FilePatch, error) {
+	if e.EntryType == StatusEntryTypeConflicted {
+		return nil, ErrEntryConflicted
+	}
+	path := e.String()
+	if strings.HasSuffix(path, "/") {
+		return nil, ErrNoTextPatch
+	}
+	opts, err := lib.DefaultDiffOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts.Pathspec = []string{path}
+	opts.Flags |= lib.DiffDisablePathspecMatch
+	index, err := r.essence.Index()
+	if err != nil {
+		return nil, err
+	}
+	defer index.Free()
+
+	p := &FilePatch{
+		entry:  e,
+		Path:   path,
+		Staged: e.Indexed(),
+	}
+	var diff *lib.Diff
+	if p.Staged {
+		tree, err := r.headTree()
+		if err != nil {
+			return nil, err
+		}
+		if tree != nil {
+			defer tree.Free()
+		}
+		if p.old, err = r.treeBlob(tree, path); err != nil {
+			return nil, err
+		}
+		diff, err = r.essence.DiffTreeToIndex(tree, index, &opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if p.old, p.mode, err = r.indexBlob(index, path); err != nil {
+			return nil, err
+		}
+		opts.Flags |= lib.DiffIncludeUntracked | lib.DiffShowUntrackedContent
+		diff, err = r.essence.DiffIndexToWorkdir(index, &opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer diff.Free()
+
+	var binary bool
+	err = diff.ForEach(func(delta lib.DiffDelta, progress float64) (lib.DiffForEachHunkCallback, error) {
+		if delta.Flags&lib.DiffFlagBinary != 0 {
+			binary = true
+		}
+		// the mode of the index entry is kept while the lines are staged
+		if p.Staged || p.mode == 0 {
+			p.mode = lib.Filemode(delta.NewFile.Mode)
+		}
+		return func(h lib.DiffHunk) (lib.DiffForEachLineCallback, error) {
+			hunk := &Hunk{
+				Header:   strings.TrimSpace(h.Header),
+				OldStart: h.OldStart,
+				OldLines: h.OldLines,
+				NewStart: h.NewStart,
+				NewLines: h.NewLines,
+			}
+			p.hunks = append(p.hunks, hunk)
+			return func(l lib.DiffLine) error {
+				var t DiffLineType
+				switch l.Origin {
+				case lib.DiffLineContext:
+					t = DiffLineContext
+				case lib.DiffLineAddition:
+					t = DiffLineAddition
+				case lib.DiffLineDeletion:
+					t = DiffLineDeletion
+				default:
+					// the end of file markers are already reflected in the contents
+					return nil
+				}
+				hunk.Lines = append(hunk.Lines, &DiffLine{
+					Type:      t,
+					Content:   l.Content,
+					OldLineno: l.OldLineno,
+					NewLineno: l.NewLineno,
+				})
+				return nil
+			}, nil
+		}, nil
+	}, lib.DiffDetailLines)
+	if err != nil {
+		return nil, err
+	}
+	if binary || len(p.hunks) == 0 {
+		return nil, ErrNoTextPatch
+	}
+	p.Hunks = p.hunks
+	return p, nil
+}
+
+// StageLines adds the given lines of an unstaged patch to the index
+func (r *Repository) StageLines(p *FilePatch, lines []*DiffLine) error {
+	if p.Staged {
+		return ErrEntryIndexed
+	}
+	selected := lineSet(lines)
+	if p.covers(selected) {
+		return r.AddToIndex(p.entry)
+	}
+	return r.writeIndexBlob(p, p.apply(func(l *DiffLine) bool {
+		return selected[l]
+	}))
+}
+
+// UnstageLines removes the given lines of a staged patch from the index
+func (r *Repository) UnstageLines(p *FilePatch, lines []*DiffLine) error {
+	if !p.Staged {
+		return ErrEntryNotIndexed
+	}
+	selected := lineSet(lines)
+	if p.covers(selected) {
+		return r.RemoveFromIndex(p.entry)
+	}
+	// the index becomes HEAD with the changes that are not selected
+	return r.writeIndexBlob(p, p.apply(func(l *DiffLine) bool {
+		return !selected[l]
+	}))
+}
+
+// apply builds the content by applying the changes that are included to the
+// old content of the patch
+func (p *FilePatch) apply(included func(*DiffLine) bool) string {
+	old := strings.SplitAfter(p.old, "\n")
+	if len(old) > 0 && old[len(old)-1] == "" {
+		old = old[:len(old)-1]
+	}
+	var b strings.Builder
+	next := 0 // next line of the old content to be copied
+	for _, h := range p.hunks {
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart // the hunk is inserted after the line
+		}
+		for ; next < start && next < len(old); next++ {
+			b.WriteString(old[next])
+		}
+		for _, l := range h.Lines {
+			switch l.Type {
+			case DiffLineContext:
+				b.WriteString(l.Content)
+				next++
+			case DiffLineDeletion:
+				if !included(l) {
+					b.WriteString(l.Content)
+				}
+				next++
+			case DiffLineAddition:
+				if included(l) {
+					b.WriteString(l.Content)
+				}
+			}
+		}
+	}
+	for ; next < len(old); next++ {
+		b.WriteString(old[next])
+	}
+	return b.String()
+}
+
+// covers returns true if every change of the patch is selected
+func (p *FilePatch) covers(selected map[*DiffLine]bool) bool {
+	for _, h := range p.hunks {
+		for _, l := range h.Lines {
+			if l.Type != DiffLineContext && !selected[l] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeIndexBlob replaces the index entry of the patch with the content
+func (r *Repository) writeIndexBlob(p *FilePatch, content string) error {
+	oid, err := r.essence.CreateBlobFromBuffer([]byte(content))
+	if err != nil {
+		return err
+	}
+	return r.updateIndex(func(index *lib.Index) error {
+		return index.Add(&lib.IndexEntry{
+			Mode: p.mode,
+			Size: uint32(len(content)),
+			Id:   oid,
+			Path: p.Path,
+		})
+	})
+}
+
+// indexBlob returns the content and the mode of the path in the index, the
+// mode of the file on disk is returned if the path is not in the index
+func (r *Repository) indexBlob(index *lib.Index, path string) (string, lib.Filemode, error) {
+	entry, err := index.EntryByPath(path, 0)
+	if err != nil {
+		info, err := os.Lstat(filepath.Join(r.essence.Workdir(), path))
+		if err != nil {
+			return "", 0, nil
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			return "", lib.FilemodeLink, nil
+		case info.Mode()&0111 != 0:
+			return "", lib.FilemodeBlobExecutable, nil
+		}
+		return "", lib.FilemodeBlob, nil
+	}
+	content, err := r.blobContent(entry.Id)
+	return content, entry.Mode, err
+}
+
+// treeBlob returns the content of the path in the tree, an empty content is
+// returned if the path is not in the tree
+func (r *Repository) treeBlob(tree *lib.Tree, path string) (string, error) {
+	if tree == nil {
+		return "", nil
+	}
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		return "", nil
+	}
+	return r.blobContent(entry.Id)
+}
+
+func (r *Repository) blobContent(oid *lib.Oid) (string, error) {
+	blob, err := r.essence.LookupBlob(oid)
+	if err != nil {
+		return "", err
+	}
+	defer blob.Free()
+	return string(blob.Contents()), nil
+}
+
+func lineSet(lines []*DiffLine) map[*DiffLine]bool {
+	set := make(map[*DiffLine]bool)
+	for _, l := range lines {
+		set[l] = true
+	}
+	return set
+}
+
+// Changes returns the added and deleted lines of the hunk
+func (h *Hunk) Changes() []*DiffLine {
+	changes := make([]*DiffLine, 0)
+	for _, l := range h.Lines {
+		if l.Type != DiffLineContext {
+			changes = append(changes, l)
+		}
+	}
+	return changes
+}
+
+// Split divides the hunk into smaller hunks at the context lines between the
+// changes, the lines are shared with the original hunk
+func (h *Hunk) Split() []*Hunk {
+	hunks := make([]*Hunk, 0)
+	var current *Hunk
+	oldLine, newLine := h.OldStart, h.NewStart
+	if h.OldLines == 0 {
+		oldLine++
+	}
+	if h.NewLines == 0 {
+		newLine++
+	}
+	changed := false // the current hunk has changes
+	for i, l := range h.Lines {
+		// a new hunk starts at the context lines that follow a change and
+		// precede another change
+		if current == nil || (l.Type == DiffLineContext && changed && i > 0 &&
+			h.Lines[i-1].Type != DiffLineContext && hasChangeAfter(h.Lines[i:])) {
+			current = &Hunk{OldStart: oldLine, NewStart: newLine}
+			hunks = append(hunks, current)
+			changed = false
+		}
+		current.Lines = append(current.Lines, l)
+		switch l.Type {
+		case DiffLineContext:
+			current.OldLines++
+			current.NewLines++
+			oldLine++
+			newLine++
+		case DiffLineDeletion:
+			current.OldLines++
+			oldLine++
+			changed = true
+		case DiffLineAddition:
+			current.NewLines++
+			newLine++
+			changed = true
+		}
+	}
+	if len(hunks) < 2 {
+		return []*Hunk{h}
+	}
+	for _, sub := range hunks {
+		// unified diff ranges start before the hunk if they are empty
+		if sub.OldLines == 0 {
+			sub.OldStart--
+		}
+		if sub.NewLines == 0 {
+			sub.NewStart--
+		}
+		sub.Header = fmt.Sprintf("@@ -%d,%d +%d,%d @@", sub.OldStart, sub.OldLines, sub.NewStart, sub.NewLines)
+	}
+	return hunks
+}
+
+func hasChangeAfter(lines []*DiffLine) bool {
+	for _, l := range lines {
+		if l.Type != DiffLineContext {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitHunk replaces the hunk with the smaller hunks it can be divided into,
+// false is returned if the hunk cannot be split
+func (p *FilePatch) SplitHunk(h *Hunk) bool {
+	parts := h.Split()
+	if len(parts) < 2 {
+		return false
+	}
+	hunks := make([]*Hunk, 0, len(p.Hunks)+len(parts)-1)
+	for _, hunk := range p.Hunks {
+		if hunk == h {
+			hunks = append(hunks, parts...)
+		} else {
+			hunks = append(hunks, hunk)
+		}
+	}
+	p.Hunks = hunks
+	return true
+}
+
+func (h *Hunk) String() string {
+	return h.Header
+}
+
+func (l *DiffLine) String() string {
+	return strings.TrimRight(l.Content, "\n")
+}
+
+// ParsePatch parses a unified diff of a single file, the lines before the
+// first hunk are returned as the header
+func ParsePatch(patch string) ([]string, []*Hunk) {
+	header := make([]string, 0)
+	hunks := make([]*Hunk, 0)
+	var hunk *Hunk
+	var oldLine, newLine int
+	lines := strings.SplitAfter(patch, "\n")
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			hunk = parseHunkHeader(strings.TrimRight(line, "\n"))
+			hunks = append(hunks, hunk)
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+		if hunk == nil {
+			header = append(header, strings.TrimRight(line, "\n"))
+			continue
+		}
+		l := &DiffLine{Content: line[1:], OldLineno: -1, NewLineno: -1}
+		switch line[0] {
+		case ' ':
+			l.Type = DiffLineContext
+			l.OldLineno, l.NewLineno = oldLine, newLine
+			oldLine++
+			newLine++
+		case '-':
+			l.Type = DiffLineDeletion
+			l.OldLineno = oldLine
+			oldLine++
+		case '+':
+			l.Type = DiffLineAddition
+			l.NewLineno = newLine
+			newLine++
+		case '\\':
+			// "\ No newline at end of file", the last line has no line break
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].Content = strings.TrimSuffix(hunk.Lines[n-1].Content, "\n")
+			}
+			continue
+		default:
+			continue
+		}
+		hunk.Lines = append(hunk.Lines, l)
+	}
+	return header, hunks
+}
+
+// parseHunkHeader parses headers such as "@@ -1,7 +1,8 @@ func main() {"
+func parseHunkHeader(header string) *Hunk {
+	h := &Hunk{Header: header, OldLines: 1, NewLines: 1}
+	fields := strings.Fields(header)
+	for _, field := range fields[1:] {
+		if field == "@@" {
+			break
+		}
+		start, count := &h.OldStart, &h.OldLines
+		if strings.HasPrefix(field, "+") {
+			start, count = &h.NewStart, &h.NewLines
+		}
+		parts := strings.SplitN(field[1:], ",", 2)
+		*start, _ = strconv.Atoi(parts[0])
+		if len(parts) == 2 {
+			*count, _ = strconv.Atoi(parts[1])
+		}
+	}
+	return h
+}
@@ -0,0 +1,218 @@
+package git
+
+import (
+	"testing"
+)
+
+// newTestHunk creates a hunk from lines prefixed like a unified diff
+func newTestHunk(oldStart, newStart int, lines ...string) *Hunk {
+	h := &Hunk{OldStart: oldStart, NewStart: newStart}
+	oldLine, newLine := oldStart, newStart
+	if oldStart == 0 {
+		oldLine = 1
+	}
+	if newStart == 0 {
+		newLine = 1
+	}
+	for _, line := range lines {
+		l := &DiffLine{Content: line[1:] + "\n", OldLineno: -1, NewLineno: -1}
+		switch line[0] {
+		case ' ':
+			l.Type = DiffLineContext
+			l.OldLineno, l.NewLineno = oldLine, newLine
+			h.OldLines++
+			h.NewLines++
+			oldLine++
+			newLine++
+		case '-':
+			l.Type = DiffLineDeletion
+			l.OldLineno = oldLine
+			h.OldLines++
+			oldLine++
+		case '+':
+			l.Type = DiffLineAddition
+			l.NewLineno = newLine
+			h.NewLines++
+			newLine++
+		}
+		h.Lines = append(h.Lines, l)
+	}
+	return h
+}
+
+func TestFilePatchApply(t *testing.T) {
+	hunk := newTestHunk(1, 1, " a", "-b", "+B", " c", " d", " e", "-f", "+F", " g")
+	p := &FilePatch{
+		old:   "a\nb\nc\nd\ne\nf\ng\nh\n",
+		hunks: []*Hunk{hunk},
+	}
+	first := lineSet(hunk.Lines[1:3])
+	var tests = []struct {
+		name     string
+		included func(*DiffLine) bool
+		expected string
+	}{
+		{"none", func(*DiffLine) bool { return false }, "a\nb\nc\nd\ne\nf\ng\nh\n"},
+		{"all", func(*DiffLine) bool { return true }, "a\nB\nc\nd\ne\nF\ng\nh\n"},
+		{"first", func(l *DiffLine) bool { return first[l] }, "a\nB\nc\nd\ne\nf\ng\nh\n"},
+		{"deletion", func(l *DiffLine) bool { return l == hunk.Lines[6] }, "a\nb\nc\nd\ne\ng\nh\n"},
+		{"addition", func(l *DiffLine) bool { return l == hunk.Lines[7] }, "a\nb\nc\nd\ne\nf\nF\ng\nh\n"},
+	}
+	for _, test := range tests {
+		if got := p.apply(test.included); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+
+	// untracked files have no old content
+	hunk = newTestHunk(0, 1, "+x", "+y")
+	p = &FilePatch{hunks: []*Hunk{hunk}}
+	if got := p.apply(func(l *DiffLine) bool { return l == hunk.Lines[0] }); got != "x\n" {
+		t.Errorf("untracked: expected %q, got %q", "x\n", got)
+	}
+}
+
+func TestHunkSplit(t *testing.T) {
+	hunk := newTestHunk(1, 1, " a", "-b", "+B", " c", " d", " e", "-f", "+F", " g")
+	parts := hunk.Split()
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(parts))
+	}
+	var tests = []struct {
+		header string
+		lines  int
+	}{
+		{"@@ -1,2 +1,2 @@", 3},
+		{"@@ -3,5 +3,5 @@", 6},
+	}
+	for i, test := range tests {
+		if parts[i].Header != test.header || len(parts[i].Lines) != test.lines {
+			t.Errorf("hunk %d: expected %q with %d lines, got %q with %d lines",
+				i, test.header, test.lines, parts[i].Header, len(parts[i].Lines))
+		}
+	}
+	if parts[0].Lines[1] != hunk.Lines[1] {
+		t.Error("split hunks should share the lines of the original hunk")
+	}
+
+	p := &FilePatch{hunks: []*Hunk{hunk}, Hunks: []*Hunk{hunk}}
+	if !p.SplitHunk(hunk) || len(p.Hunks) != 2 {
+		t.Errorf("expected the patch to have 2 hunks after split, got %d", len(p.Hunks))
+	}
+	if p.SplitHunk(p.Hunks[0]) {
+		t.Error("a hunk with a single change block should not be split")
+	}
+}
+
+func TestStageAndUnstageLines(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "file.txt", "a\nb\nc\nd\ne\nf\ng\n")
+	writeTestFile(t, r.Path(), "file.txt", "a\nB\nc\nd\ne\nF\ng\n")
+
+	p, err := r.LoadPatch(findEntry(t, r, "file.txt", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Hunks) != 1 || !p.SplitHunk(p.Hunks[0]) {
+		t.Fatalf("expected a single hunk that can be split, got %d", len(p.Hunks))
+	}
+	if err := r.StageLines(p, p.Hunks[0].Changes()); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexContent(t, r, "file.txt"); got != "a\nB\nc\nd\ne\nf\ng\n" {
+		t.Errorf("unexpected index content after staging: %q", got)
+	}
+
+	staged := findEntry(t, r, "file.txt", true)
+	if staged == nil {
+		t.Fatal("staged entry not found")
+	}
+	p, err = r.LoadPatch(staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StageLines(p, p.Hunks[0].Changes()); err != ErrEntryIndexed {
+		t.Errorf("expected %v, got %v", ErrEntryIndexed, err)
+	}
+	if err := r.UnstageLines(p, p.Hunks[0].Changes()); err != nil {
+		t.Fatal(err)
+	}
+	if findEntry(t, r, "file.txt", true) != nil {
+		t.Error("staged entry left after unstaging every line")
+	}
+
+	// a single line of an untracked file
+	writeTestFile(t, r.Path(), "new.txt", "x\ny\n")
+	p, err = r.LoadPatch(findEntry(t, r, "new.txt", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StageLines(p, p.Hunks[0].Lines[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexContent(t, r, "new.txt"); got != "x\n" {
+		t.Errorf("unexpected index content of the untracked file: %q", got)
+	}
+}
+
+func indexContent(t *testing.T, r *Repository, path string) string {
+	t.Helper()
+	index, err := r.essence.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Free()
+	entry, err := index.EntryByPath(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := r.blobContent(entry.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestParsePatch(t *testing.T) {
+	patch := "diff --git a/main.go b/main.go\n" +
+		"index 1a2b3c4..5d6e7f8 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,3 +1,3 @@ package main\n" +
+		" a\n" +
+		"-b\n" +
+		"+B\n" +
+		" c\n" +
+		"@@ -10 +10,2 @@\n" +
+		" x\n" +
+		"+y\n" +
+		"\\ No newline at end of file\n"
+	header, hunks := ParsePatch(patch)
+	if len(header) != 4 || header[0] != "diff --git a/main.go b/main.go" {
+		t.Errorf("unexpected header: %q", header)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	h := hunks[1]
+	if h.OldStart != 10 || h.OldLines != 1 || h.NewStart != 10 || h.NewLines != 2 {
+		t.Errorf("unexpected range: -%d,%d +%d,%d", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+	var tests = []struct {
+		line                 *DiffLine
+		typ                  DiffLineType
+		content              string
+		oldLineno, newLineno int
+	}{
+		{hunks[0].Lines[1], DiffLineDeletion, "b\n", 2, -1},
+		{hunks[0].Lines[2], DiffLineAddition, "B\n", -1, 2},
+		{hunks[0].Lines[3], DiffLineContext, "c\n", 3, 3},
+		{h.Lines[1], DiffLineAddition, "y", -1, 11},
+	}
+	for _, test := range tests {
+		l := test.line
+		if l.Type != test.typ || l.Content != test.content || l.OldLineno != test.oldLineno || l.NewLineno != test.newLineno {
+			t.Errorf("unexpected line: %+v", l)
+		}
+	}
+}
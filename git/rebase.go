@@ -0,0 +1,91 @@
+package git
+
+import (
+	"strings"
+)
+
+// RebaseAction is the command of a commit in an interactive rebase
+type RebaseAction int
+
+// The actions of the commits as in the todo list of "git rebase -i"
+const (
+	RebasePick RebaseAction = iota
+	RebaseReword
+	RebaseEdit
+	RebaseSquash
+	RebaseFixup
+	RebaseDrop
+)
+
+func (a RebaseAction) String() string {
+	switch a {
+	case RebasePick:
+		return "pick"
+	case RebaseReword:
+		return "reword"
+	case RebaseEdit:
+		return "edit"
+	case RebaseSquash:
+		return "squash"
+	case RebaseFixup:
+		return "fixup"
+	case RebaseDrop:
+		return "drop"
+	}
+	return ""
+}
+
+// RebaseStep is a commit of the rebase with the action to apply it
+type RebaseStep struct {
+	Action RebaseAction
+	Commit *Commit
+}
+
+func (s *RebaseStep) String() string {
+	return s.Commit.String()
+}
+
+// RebasePlan returns the commits of HEAD that are not in the upstream, oldest
+// first, to be picked by an interactive rebase. The merges are left out like
+// "git rebase -i" does.
+func (r *Repository) RebasePlan(upstream string) ([]*RebaseStep, error) {
+	commits, errs, err := r.Log(&LogOptions{
+		Revisions: []string{upstream + "..HEAD"},
+		NoMerges:  true,
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]*RebaseStep, 0)
+	for c := range commits {
+		steps = append([]*RebaseStep{{Action: RebasePick, Commit: c}}, steps...)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, ErrNothingToRebase
+	}
+	return steps, nil
+}
+
+// RebaseTodo returns the todo list of "git rebase -i" for the steps. The
+// first commit that is kept cannot be squashed since there is nothing to meld
+// it into.
+func RebaseTodo(steps []*RebaseStep) (string, error) {
+	var b strings.Builder
+	kept := false
+	for _, s := range steps {
+		switch s.Action {
+		case RebaseSquash, RebaseFixup:
+			if !kept {
+				return "", ErrNoPreviousCommit
+			}
+		case RebaseDrop:
+		default:
+			kept = true
+		}
+		b.WriteString(s.Action.String() + " " + s.Commit.Hash + " " + s.Commit.Summary + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestRebasePlan(t *testing.T) {
+	r := newTestRepository(t)
+	base := commitTestFile(t, r, "file.txt", "a\n")
+	first := commitTestFile(t, r, "file.txt", "a\nb\n")
+	second := commitTestFile(t, r, "file.txt", "a\nb\nc\n")
+
+	steps, err := r.RebasePlan(base.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Commit.Hash != first.Hash || steps[1].Commit.Hash != second.Hash {
+		t.Fatal("expected the commits after the upstream, oldest first")
+	}
+	if _, err := r.RebasePlan("HEAD"); err != ErrNothingToRebase {
+		t.Errorf("expected %v, got %v", ErrNothingToRebase, err)
+	}
+}
+
+func TestRebaseTodo(t *testing.T) {
+	a := &Commit{Hash: "aaaaaaa", Summary: "first"}
+	b := &Commit{Hash: "bbbbbbb", Summary: "second"}
+	var tests = []struct {
+		steps []*RebaseStep
+		todo  string
+		err   error
+	}{
+		{
+			[]*RebaseStep{{RebasePick, b}, {RebaseFixup, a}},
+			"pick bbbbbbb second\nfixup aaaaaaa first\n",
+			nil,
+		},
+		{
+			[]*RebaseStep{{RebaseReword, a}, {RebaseDrop, b}},
+			"reword aaaaaaa first\ndrop bbbbbbb second\n",
+			nil,
+		},
+		{
+			[]*RebaseStep{{RebaseSquash, a}, {RebasePick, b}},
+			"",
+			ErrNoPreviousCommit,
+		},
+		{
+			[]*RebaseStep{{RebaseDrop, a}, {RebaseSquash, b}},
+			"",
+			ErrNoPreviousCommit,
+		},
+	}
+	for _, test := range tests {
+		todo, err := RebaseTodo(test.steps)
+		if todo != test.todo || err != test.err {
+			t.Errorf("expected %q (%v), got %q (%v)", test.todo, test.err, todo, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReflogEntry is a change of a reference recorded in its reflog
+type ReflogEntry struct {
+	Ref       string // short name of the reference e.g. "HEAD" or "main"
+	Index     int    // the n of "ref@{n}", zero is the latest change
+	Old       string
+	New       string
+	Committer *Signature
+	Message   string
+}
+
+func (e *ReflogEntry) String() string {
+	return e.Message
+}
+
+// Selector returns the revision of the entry e.g. "HEAD@{2}"
+func (e *ReflogEntry) Selector() string {
+	return fmt.Sprintf("%s@{%d}", e.Ref, e.Index)
+}
+
+// Reflog is the wrapper of "git reflog show <ref>", the entries of HEAD are
+// returned if the ref is empty. The latest change is the first entry.
+func (r *Repository) Reflog(ref string) ([]*ReflogEntry, error) {
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	reference, err := r.essence.References.Dwim(ref)
+	if err != nil {
+		return nil, fmt.Errorf("bad reference %q: %v", ref, err)
+	}
+	name := reference.Name()
+	short := reference.Shorthand()
+	reference.Free()
+
+	// libgit2 has reflogs but git2go does not bind them, they are read from
+	// the logs directory that has the same layout in every repository
+	data, err := os.ReadFile(r.reflogPath(name))
+	if os.IsNotExist(err) {
+		return []*ReflogEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	entries := make([]*ReflogEntry, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		e, err := parseReflogLine(lines[i])
+		if err != nil {
+			continue
+		}
+		e.Ref = short
+		e.Index = len(entries)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// reflogPath returns the reflog file of the reference, the references other
+// than HEAD are shared by the worktrees in the common directory
+func (r *Repository) reflogPath(name string) string {
+	dir := r.essence.Path()
+	if name != "HEAD" {
+		dir = r.commonDir()
+	}
+	return filepath.Join(dir, "logs", filepath.FromSlash(name))
+}
+
+// parseReflogLine parses a line of a reflog file in the format of
+// "<old> <new> <name> <<email>> <unix time> <zone>\t<message>"
+func parseReflogLine(line string) (*ReflogEntry, error) {
+	header, message := line, ""
+	if i := strings.IndexByte(line, '\t'); i >= 0 {
+		header, message = line[:i], line[i+1:]
+	}
+	fields := strings.SplitN(header, " ", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+	start := strings.LastIndexByte(fields[2], '<')
+	end := strings.LastIndexByte(fields[2], '>')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+	when := strings.Fields(fields[2][end+1:])
+	if len(when) != 2 {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+	seconds, err := strconv.ParseInt(when[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+	zone, err := time.Parse("-0700", when[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+	return &ReflogEntry{
+		Old: fields[0],
+		New: fields[1],
+		Committer: &Signature{
+			Name:  strings.TrimSpace(fields[2][:start]),
+			Email: fields[2][start+1 : end],
+			When:  time.Unix(seconds, 0).In(zone.Location()),
+		},
+		Message: message,
+	}, nil
+}
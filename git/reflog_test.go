@@ -0,0 +1,64 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReflogLine(t *testing.T) {
+	line := "1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 " +
+		"Jane Doe <jane@example.com> 1600000000 +0200\tcommit: fix the parser"
+	e, err := parseReflogLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Old[:4] != "1111" || e.New[:4] != "2222" || e.Message != "commit: fix the parser" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.Committer.Name != "Jane Doe" || e.Committer.Email != "jane@example.com" {
+		t.Errorf("unexpected committer %+v", e.Committer)
+	}
+	if !e.Committer.When.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("unexpected time %v", e.Committer.When)
+	}
+	if _, offset := e.Committer.When.Zone(); offset != 2*60*60 {
+		t.Errorf("expected +0200, got offset %d", offset)
+	}
+	for _, invalid := range []string{"", "a b", "a b name 1600000000 +0200\tmessage", "a b name <e> x +0200\tm"} {
+		if _, err := parseReflogLine(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestReflog(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	second := commitTestFile(t, r, "file.txt", "b\n")
+
+	entries, err := r.Reflog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) < 2 {
+		t.Fatalf("expected at least 2 entries, got %d", len(entries))
+	}
+	if entries[0].New != second.Hash || entries[0].Old != first.Hash || entries[0].Selector() != "HEAD@{0}" {
+		t.Errorf("unexpected latest entry %+v", entries[0])
+	}
+
+	if err := r.ResetHard(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateBranch("rescue", second); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.LookupCommit("rescue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hash != second.Hash {
+		t.Error("expected the branch at the lost commit")
+	}
+	assertTestFile(t, r, "file.txt", "a\n")
+}
@@ -0,0 +1,382 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Remote is the wrapper of lib.Remote
+type Remote struct {
+	owner *Repository
+
+	Name          string
+	URL           string
+	PushURL       string
+	FetchRefspecs []string
+}
+
+// Progress is reported while transferring objects from or to a remote
+type Progress struct {
+	Phase   string
+	Current int
+	Total   int
+	Message string
+}
+
+// Remotes loads the remotes of the repository
+func (r *Repository) Remotes() ([]*Remote, error) {
+	names, err := r.essence.Remotes.List()
+	if err != nil {
+		return nil, err
+	}
+	buffer := make([]*Remote, 0)
+	for _, name := range names {
+		remote, err := r.lookupRemote(name)
+		if err != nil {
+			return nil, err
+		}
+		buffer = append(buffer, remote)
+	}
+	return buffer, nil
+}
+
+func (r *Repository) lookupRemote(name string) (*Remote, error) {
+	if len(name) == 0 {
+		return nil, ErrNoRemoteName
+	}
+	raw, err := r.essence.Remotes.Lookup(name)
+	if err != nil {
+		return nil, ErrNotValidRemoteName
+	}
+	defer raw.Free()
+	refspecs, err := raw.FetchRefspecs()
+	if err != nil {
+		return nil, err
+	}
+	return &Remote{
+		owner:         r,
+		Name:          raw.Name(),
+		URL:           raw.Url(),
+		PushURL:       raw.PushUrl(),
+		FetchRefspecs: refspecs,
+	}, nil
+}
+
+// AddRemote is the wrapper of "git remote add <name> <url>"
+func (r *Repository) AddRemote(name, url string) (*Remote, error) {
+	raw, err := r.essence.Remotes.Create(name, url)
+	if err != nil {
+		return nil, err
+	}
+	raw.Free()
+	return r.lookupRemote(name)
+}
+
+// Delete is the wrapper of "git remote remove <name>"
+func (rm *Remote) Delete() error {
+	return rm.owner.essence.Remotes.Delete(rm.Name)
+}
+
+// Rename is the wrapper of "git remote rename <old> <new>"
+func (rm *Remote) Rename(name string) error {
+	problems, err := rm.owner.essence.Remotes.Rename(rm.Name, name)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("could not rename refspecs: %s", strings.Join(problems, ", "))
+	}
+	rm.Name = name
+	return nil
+}
+
+// SetURL is the wrapper of "git remote set-url <name> <url>"
+func (rm *Remote) SetURL(url string) error {
+	if err := rm.owner.essence.Remotes.SetUrl(rm.Name, url); err != nil {
+		return err
+	}
+	rm.URL = url
+	return nil
+}
+
+// Fetch is the wrapper of "git fetch <remote>", progress is closed when the
+// operation ends. The progress channel can be nil.
+func (r *Repository) Fetch(remote string, progress chan<- *Progress) error {
+	defer closeProgress(progress)
+	return r.fetch(remote, progress)
+}
+
+func (r *Repository) fetch(remote string, progress chan<- *Progress) error {
+	if len(remote) == 0 {
+		return ErrNoRemoteName
+	}
+	raw, err := r.essence.Remotes.Lookup(remote)
+	if err != nil {
+		return ErrNotValidRemoteName
+	}
+	defer raw.Free()
+	opts := &lib.FetchOptions{
+		RemoteCallbacks: remoteCallbacks(progress),
+		UpdateFetchhead: true,
+	}
+	if err := raw.Fetch(nil, opts, ""); err != nil {
+		return authError(err)
+	}
+	return nil
+}
+
+// Push is the wrapper of "git push <remote> <branch>", the upstream of the
+// branch is used if it is set. Otherwise the branch is pushed to the only
+// remote and set as the upstream.
+func (r *Repository) Push(b *Branch, progress chan<- *Progress) error {
+	defer closeProgress(progress)
+	if b.IsRemote() {
+		return fmt.Errorf("%s", "cannot push a remote-tracking branch")
+	}
+	remote, dst, err := r.pushTarget(b)
+	if err != nil {
+		return err
+	}
+	return r.push(remote, []string{b.FullName + ":" + dst}, progress, func() error {
+		if b.Upstream != nil {
+			return nil
+		}
+		local, err := r.essence.LookupBranch(b.Name, lib.BranchLocal)
+		if err != nil {
+			return err
+		}
+		defer local.Free()
+		return local.SetUpstream(remote + "/" + b.Name)
+	})
+}
+
+// DeleteRemoteBranch is the wrapper of "git push <remote> --delete <branch>",
+// the remote-tracking branch is deleted after the remote accepts the push
+func (r *Repository) DeleteRemoteBranch(b *Branch, progress chan<- *Progress) error {
+	defer closeProgress(progress)
+	if !b.IsRemote() {
+		return fmt.Errorf("%s", "not a remote-tracking branch")
+	}
+	remote, err := r.essence.RemoteName(b.FullName)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(b.Name, remote+"/")
+	return r.push(remote, []string{":refs/heads/" + name}, progress, func() error {
+		// the remote-tracking branch is pruned by the next fetch otherwise,
+		// it is looked up since the branch may belong to another handle
+		ref, err := r.essence.References.Lookup(b.FullName)
+		if err != nil {
+			return err
+		}
+		defer ref.Free()
+		return ref.Delete()
+	})
+}
+
+func (r *Repository) push(remote string, refspecs []string, progress chan<- *Progress, after func() error) error {
+	raw, err := r.essence.Remotes.Lookup(remote)
+	if err != nil {
+		return ErrNotValidRemoteName
+	}
+	defer raw.Free()
+	callbacks := remoteCallbacks(progress)
+	callbacks.PushUpdateReferenceCallback = func(refname, status string) error {
+		if len(status) > 0 {
+			return fmt.Errorf("%s rejected: %s", refname, status)
+		}
+		return nil
+	}
+	if err := raw.Push(refspecs, &lib.PushOptions{
+		RemoteCallbacks: callbacks,
+	}); err != nil {
+		return authError(err)
+	}
+	return after()
+}
+
+// pushTarget returns the remote name and the destination ref of a branch
+func (r *Repository) pushTarget(b *Branch) (string, string, error) {
+	if b.Upstream != nil {
+		remote, err := r.essence.RemoteName(b.Upstream.FullName)
+		if err != nil {
+			return "", "", err
+		}
+		dst := "refs/heads/" + strings.TrimPrefix(b.Upstream.Name, remote+"/")
+		return remote, dst, nil
+	}
+	names, err := r.essence.Remotes.List()
+	if err != nil {
+		return "", "", err
+	}
+	if len(names) != 1 {
+		return "", "", ErrNoRemoteName
+	}
+	return names[0], b.FullName, nil
+}
+
+// Pull fetches the upstream of the branch and fast-forwards the branch to it,
+// if the branch is the HEAD, the working tree is updated as well. Branches
+// that have diverged from their upstream are not merged.
+func (r *Repository) Pull(b *Branch, progress chan<- *Progress) error {
+	if err := r.FetchUpstream(b, progress); err != nil {
+		return err
+	}
+	return r.FastForwardUpstream(b)
+}
+
+// FetchUpstream fetches the remote of the upstream of the branch, it is the
+// network part of Pull. The progress channel is closed when it ends.
+func (r *Repository) FetchUpstream(b *Branch, progress chan<- *Progress) error {
+	defer closeProgress(progress)
+	if b.Upstream == nil {
+		return ErrBranchNotFound
+	}
+	remote, err := r.essence.RemoteName(b.Upstream.FullName)
+	if err != nil {
+		return err
+	}
+	return r.fetch(remote, progress)
+}
+
+// FastForwardUpstream fast-forwards the branch to its fetched upstream, it is
+// the local part of Pull
+func (r *Repository) FastForwardUpstream(b *Branch) error {
+	if b.Upstream == nil {
+		return ErrBranchNotFound
+	}
+	upstream, err := r.essence.References.Lookup(b.Upstream.FullName)
+	if err != nil {
+		return ErrBranchNotFound
+	}
+	defer upstream.Free()
+	return r.fastForward(b, upstream.Target(), "pull: Fast-forward")
+}
+
+// fastForward moves the branch to the target, checking out the target if
+// the branch is the HEAD
+func (r *Repository) fastForward(b *Branch, target *lib.Oid, msg string) error {
+	local, err := r.essence.References.Lookup(b.FullName)
+	if err != nil {
+		return err
+	}
+	defer local.Free()
+	if local.Target().Equal(target) {
+		return ErrAlreadyUpToDate
+	}
+	if ok, err := r.essence.DescendantOf(local.Target(), target); err != nil {
+		return err
+	} else if ok {
+		return ErrAlreadyUpToDate
+	}
+	if ok, err := r.essence.DescendantOf(target, local.Target()); err != nil {
+		return err
+	} else if !ok {
+		return ErrFastForwardOnly
+	}
+	if b.Head {
+		commit, err := r.essence.LookupCommit(target)
+		if err != nil {
+			return err
+		}
+		defer commit.Free()
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		defer tree.Free()
+		if err := r.essence.CheckoutTree(tree, &lib.CheckoutOptions{
+			Strategy: lib.CheckoutSafe,
+		}); err != nil {
+			return err
+		}
+	}
+	ref, err := local.SetTarget(target, msg)
+	if err != nil {
+		return err
+	}
+	ref.Free()
+	if b.Head {
+		return r.LoadHead()
+	}
+	return nil
+}
+
+func remoteCallbacks(progress chan<- *Progress) lib.RemoteCallbacks {
+	var attempts int
+	var percent int
+	report := func(phase string, current, total int) {
+		if progress == nil || total == 0 {
+			return
+		}
+		// report only if the percentage changes to avoid flooding the receiver
+		if p := current * 100 / total; p != percent || current == total {
+			percent = p
+			progress <- &Progress{Phase: phase, Current: current, Total: total}
+		}
+	}
+	return lib.RemoteCallbacks{
+		CredentialsCallback: func(url, username string, allowed lib.CredentialType) (*lib.Credential, error) {
+			attempts++
+			if attempts > 1 {
+				// libgit2 asks again if the credentials are not accepted
+				return nil, ErrAuthenticationRequired
+			}
+			if allowed&lib.CredentialTypeSSHKey != 0 {
+				return lib.NewCredentialSSHKeyFromAgent(username)
+			}
+			if allowed&lib.CredentialTypeDefault != 0 {
+				return lib.NewCredentialDefault()
+			}
+			return nil, ErrAuthenticationType
+		},
+		CertificateCheckCallback: func(cert *lib.Certificate, valid bool, hostname string) error {
+			if !valid {
+				return fmt.Errorf("certificate of %s is not valid", hostname)
+			}
+			return nil
+		},
+		SidebandProgressCallback: func(str string) error {
+			if progress != nil {
+				progress <- &Progress{Message: strings.TrimSpace(str)}
+			}
+			return nil
+		},
+		TransferProgressCallback: func(stats lib.TransferProgress) error {
+			if stats.ReceivedObjects < stats.TotalObjects {
+				report("Receiving objects", int(stats.ReceivedObjects), int(stats.TotalObjects))
+			} else if stats.TotalDeltas > 0 {
+				// git2go does not expose the indexed deltas, libgit2 counts a
+				// resolved delta as an indexed object so the objects that are
+				// not indexed yet are the unresolved deltas
+				pending := int(stats.TotalObjects) - int(stats.IndexedObjects)
+				report("Resolving deltas", int(stats.TotalDeltas)-pending, int(stats.TotalDeltas))
+			}
+			return nil
+		},
+		PushTransferProgressCallback: func(current, total uint32, bytes uint) error {
+			report("Writing objects", int(current), int(total))
+			return nil
+		},
+	}
+}
+
+func closeProgress(progress chan<- *Progress) {
+	if progress != nil {
+		close(progress)
+	}
+}
+
+// authError replaces the libgit2 authentication errors with the package's errors
+func authError(err error) error {
+	if gitErr, ok := err.(*lib.GitError); ok && gitErr.Code == lib.ErrorCodeAuth {
+		return ErrAuthenticationRequired
+	}
+	return err
+}
+
+func (rm *Remote) String() string {
+	return rm.Name
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"testing"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+func TestPushFetchPull(t *testing.T) {
+	bare := t.TempDir()
+	raw, err := lib.InitRepository(bare, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Free()
+
+	local := newTestRepository(t)
+	if _, err := local.AddRemote("origin", bare); err != nil {
+		t.Fatal(err)
+	}
+	remotes, err := local.Remotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 1 || remotes[0].Name != "origin" || remotes[0].URL != bare {
+		t.Fatalf("unexpected remotes: %v", remotes)
+	}
+	if err := local.Push(local.Head, nil); err != nil {
+		t.Fatalf("could not push: %v", err)
+	}
+	// the upstream should be set by the first push
+	if err := local.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	if local.Head.Upstream == nil {
+		t.Fatal("upstream is not set after push")
+	}
+
+	clone := t.TempDir()
+	cloned, err := lib.Clone(bare, clone, &lib.CloneOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloned.Free()
+	other, err := Open(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := commitTestFile(t, local, "main.go", "package main\n")
+	if err := local.Push(local.Head, nil); err != nil {
+		t.Fatalf("could not push: %v", err)
+	}
+
+	progress := make(chan *Progress)
+	go func() {
+		for range progress {
+		}
+	}()
+	if err := other.Pull(other.Head, progress); err != nil {
+		t.Fatalf("could not pull: %v", err)
+	}
+	if other.Head.Hash != c.Hash {
+		t.Errorf("expected HEAD to be %s, got %s", c.Hash, other.Head.Hash)
+	}
+	if err := other.Pull(other.Head, nil); err != ErrAlreadyUpToDate {
+		t.Errorf("expected %v, got %v", ErrAlreadyUpToDate, err)
+	}
+	if err := other.Fetch("upstream", nil); err != ErrNotValidRemoteName {
+		t.Errorf("expected %v, got %v", ErrNotValidRemoteName, err)
+	}
+}
@@ -2,7 +2,10 @@ package git
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	lib "github.com/libgit2/git2go/v33"
 )
@@ -33,6 +36,24 @@ type Ref interface {
 	String() string
 }
 
+// clearRefs removes the refs of the type from the RefMap, so that they can
+// be loaded again without duplicates or the deleted ones
+func (r *Repository) clearRefs(t RefType) {
+	for hash, refs := range r.RefMap {
+		kept := make([]Ref, 0, len(refs))
+		for _, ref := range refs {
+			if ref.Type() != t {
+				kept = append(kept, ref)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.RefMap, hash)
+		} else {
+			r.RefMap[hash] = kept
+		}
+	}
+}
+
 // Open load the repository from the filesystem
 func Open(path string) (*Repository, error) {
 	repo, realpath, err := initRepoFromPath(path)
@@ -48,19 +69,51 @@ func Open(path string) (*Repository, error) {
 	return r, nil
 }
 
+// Reopen opens another handle of the repository, a libgit2 handle must not
+// be used from two goroutines at once so the background jobs use their own
+func (r *Repository) Reopen() (*Repository, error) {
+	return Open(r.path)
+}
+
+// Free releases the libgit2 handle of the repository
+func (r *Repository) Free() {
+	r.essence.Free()
+}
+
+// initRepoFromPath opens the repository of the nearest parent directory
+// that has a .git directory, or a .git file that links a worktree to its
+// repository, and returns the root of its working tree
 func initRepoFromPath(path string) (*lib.Repository, string, error) {
-	walk := path
-	for {
-		r, err := lib.OpenRepository(walk)
-		if err == nil {
-			return r, walk, err
-		}
-		walk = filepath.Dir(walk)
-		if walk == "/" {
-			break
-		}
+	r, err := lib.OpenRepositoryExtended(path, 0, "")
+	if err != nil {
+		return nil, path, errors.New("cannot load a git repository from " + path)
 	}
-	return nil, walk, errors.New("cannot load a git repository from " + path)
+	root := r.Workdir()
+	if len(root) == 0 {
+		// a bare repository has no working tree
+		return r, filepath.Clean(r.Path()), nil
+	}
+	return r, filepath.Clean(root), nil
+}
+
+// commonDir returns the directory that the worktrees share, it is the git
+// directory of the main worktree
+func (r *Repository) commonDir() string {
+	dir := filepath.Clean(r.essence.Path())
+	common, err := os.ReadFile(filepath.Join(dir, "commondir"))
+	if err != nil {
+		return dir
+	}
+	c := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(c) {
+		c = filepath.Join(dir, c)
+	}
+	return filepath.Clean(c)
+}
+
+// IsWorktree returns true if the repository is opened from a linked worktree
+func (r *Repository) IsWorktree() bool {
+	return r.commonDir() != filepath.Clean(r.essence.Path())
 }
 
 // LoadHead can be used to refresh HEAD ref
@@ -69,7 +122,7 @@ func (r *Repository) LoadHead() error {
 	if err != nil {
 		return err
 	}
-	branch, err := unpackRawBranch(r.essence, head.Branch())
+	branch, err := unpackRawBranch(r, head.Branch())
 	if err != nil {
 		return err
 	}
@@ -86,7 +139,35 @@ func (r *Repository) LoadHead() error {
 	return nil
 }
 
+// HeadDetached returns true if HEAD points to a commit rather than a branch,
+// the Head is not reloaded then and refers to the last checked out branch
+func (r *Repository) HeadDetached() bool {
+	detached, err := r.essence.IsHeadDetached()
+	return err == nil && detached
+}
+
 // Path returns the filesystem location of the repository
 func (r *Repository) Path() string {
 	return r.path
 }
+
+// RelativePath converts a path relative to the working directory of the
+// process to a path relative to the root of the repository
+func (r *Repository) RelativePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(r.path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is outside of the repository", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
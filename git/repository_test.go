@@ -2,7 +2,11 @@ package git
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	lib "github.com/libgit2/git2go/v33"
 )
 
 func TestOpen(t *testing.T) {
@@ -21,3 +25,92 @@ func TestOpen(t *testing.T) {
 		}
 	}
 }
+
+// newTestRepository initializes a repository in a temporary directory with
+// an initial commit
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := lib.InitRepository(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Free()
+	if err := cfg.SetString("user.name", "gitin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetString("user.email", "gitin@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "README.md", "# gitin\n")
+	index, err := repo.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Free()
+	if err := index.AddByPath("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Write(); err != nil {
+		t.Fatal(err)
+	}
+	treeID, err := index.WriteTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.LookupTree(treeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Free()
+	sig := &lib.Signature{Name: "gitin", Email: "gitin@example.com", When: time.Now()}
+	if _, err := repo.CreateCommit("HEAD", sig, sig, "initial commit", tree); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitTestFile writes the file, adds it to the index and commits it
+func commitTestFile(t *testing.T, r *Repository, name, content string) *Commit {
+	t.Helper()
+	writeTestFile(t, r.Path(), name, content)
+	index, err := r.essence.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Free()
+	if err := index.AddByPath(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Write(); err != nil {
+		t.Fatal(err)
+	}
+	sig := &Signature{Name: "gitin", Email: "gitin@example.com", When: time.Now()}
+	c, err := r.Commit("update "+name, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadHead(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,404 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Stash is a saved state of the working directory and the index
+type Stash struct {
+	target *Commit
+	owner  *Repository
+
+	Index   int
+	Message string
+	Branch  string
+	Hash    string
+}
+
+// Stashes loads the stash entries, the latest entry comes first
+func (r *Repository) Stashes() ([]*Stash, error) {
+	buffer := make([]*Stash, 0)
+	err := r.essence.Stashes.Foreach(func(index int, message string, id *lib.Oid) error {
+		commit, err := r.essence.LookupCommit(id)
+		if err != nil {
+			return err
+		}
+		buffer = append(buffer, &Stash{
+			target:  unpackRawCommit(r, commit),
+			owner:   r,
+			Index:   index,
+			Message: message,
+			Branch:  stashBranch(message),
+			Hash:    id.String(),
+		})
+		return nil
+	})
+	return buffer, err
+}
+
+// stashBranch extracts the branch name from messages such as
+// "WIP on master: 1a2b3c4 summary" or "On master: message"
+func stashBranch(message string) string {
+	for _, prefix := range []string{"WIP on ", "On "} {
+		if strings.HasPrefix(message, prefix) {
+			message = strings.TrimPrefix(message, prefix)
+			if i := strings.Index(message, ":"); i > 0 {
+				return message[:i]
+			}
+		}
+	}
+	return ""
+}
+
+// StashAll is the wrapper of "git stash push --include-untracked -m <message>"
+func (r *Repository) StashAll(message string) error {
+	sig, err := r.essence.DefaultSignature()
+	if err != nil {
+		return err
+	}
+	_, err = r.essence.Stashes.Save(sig, message, lib.StashIncludeUntracked)
+	return err
+}
+
+// StashEntries is the wrapper of "git stash push -m <message> -- <paths>"
+// libgit2 can only stash the whole working tree, so the stash commits are
+// created by hand and the stashed paths are reverted to HEAD afterwards.
+func (r *Repository) StashEntries(message string, entries []*StatusEntry) error {
+	repo := r.essence
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	defer head.Free()
+	headCommit, err := repo.LookupCommit(head.Target())
+	if err != nil {
+		return err
+	}
+	defer headCommit.Free()
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return err
+	}
+	defer headTree.Free()
+	sig, err := repo.DefaultSignature()
+	if err != nil {
+		return err
+	}
+
+	branch := head.Shorthand()
+	if detached, _ := repo.IsHeadDetached(); detached {
+		branch = "(no branch)"
+	}
+	base := fmt.Sprintf("%s: %s %s", branch, headCommit.Id().String()[:7], headCommit.Summary())
+	if len(message) == 0 {
+		message = "WIP on " + base
+	} else {
+		message = "On " + branch + ": " + message
+	}
+
+	tracked, untracked := stashPaths(entries)
+	index, err := repo.Index()
+	if err != nil {
+		return err
+	}
+	defer index.Free()
+
+	// the index commit is HEAD with the staged state of the selected paths
+	iIndex, err := lib.NewIndex()
+	if err != nil {
+		return err
+	}
+	defer iIndex.Free()
+	if err := iIndex.ReadTree(headTree); err != nil {
+		return err
+	}
+	for _, path := range tracked {
+		entry, err := index.EntryByPath(path, 0)
+		if err == nil {
+			err = iIndex.Add(entry)
+		} else {
+			err = iIndex.RemoveByPath(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	iCommit, err := r.commitIndex(iIndex, sig, "index on "+base+"\n", headCommit)
+	if err != nil {
+		return err
+	}
+	defer iCommit.Free()
+	iTree, err := iCommit.Tree()
+	if err != nil {
+		return err
+	}
+	defer iTree.Free()
+
+	// the work tree commit is the index commit with the files on disk
+	wIndex, err := lib.NewIndex()
+	if err != nil {
+		return err
+	}
+	defer wIndex.Free()
+	if err := wIndex.ReadTree(iTree); err != nil {
+		return err
+	}
+	for _, path := range tracked {
+		if err := r.addWorkdirPath(wIndex, path); err != nil {
+			return err
+		}
+	}
+	parents := []*lib.Commit{headCommit, iCommit}
+
+	// untracked files are kept in a parentless third commit
+	if len(untracked) > 0 {
+		uIndex, err := lib.NewIndex()
+		if err != nil {
+			return err
+		}
+		defer uIndex.Free()
+		for _, path := range untracked {
+			if err := r.addWorkdirPath(uIndex, path); err != nil {
+				return err
+			}
+		}
+		uCommit, err := r.commitIndex(uIndex, sig, "untracked files on "+base+"\n")
+		if err != nil {
+			return err
+		}
+		defer uCommit.Free()
+		parents = append(parents, uCommit)
+	}
+	wCommit, err := r.commitIndex(wIndex, sig, message+"\n", parents...)
+	if err != nil {
+		return err
+	}
+	defer wCommit.Free()
+
+	if err := repo.References.EnsureLog("refs/stash"); err != nil {
+		return err
+	}
+	ref, err := repo.References.Create("refs/stash", wCommit.Id(), true, message)
+	if err != nil {
+		return err
+	}
+	ref.Free()
+
+	// finally, revert the stashed paths
+	if len(tracked) > 0 {
+		if err := repo.CheckoutHead(&lib.CheckoutOptions{
+			Strategy: lib.CheckoutForce | lib.CheckoutDisablePathspecMatch,
+			Paths:    tracked,
+		}); err != nil {
+			return err
+		}
+		if err := repo.ResetDefaultToCommit(headCommit, tracked); err != nil {
+			return err
+		}
+	}
+	for _, path := range untracked {
+		if err := os.RemoveAll(filepath.Join(repo.Workdir(), path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stashPaths collects the distinct paths of the entries
+func stashPaths(entries []*StatusEntry) (tracked, untracked []string) {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		for _, path := range e.paths() {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			if e.EntryType == StatusEntryTypeUntracked {
+				untracked = append(untracked, path)
+			} else {
+				tracked = append(tracked, path)
+			}
+		}
+	}
+	return tracked, untracked
+}
+
+// addWorkdirPath writes the file (or files under the directory) to the odb and
+// updates the in-memory index, a missing file is removed from the index
+func (r *Repository) addWorkdirPath(index *lib.Index, path string) error {
+	root := r.essence.Workdir()
+	abs := filepath.Join(root, path)
+	info, err := os.Lstat(abs)
+	if os.IsNotExist(err) {
+		return index.RemoveByPath(path)
+	} else if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return filepath.Walk(abs, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			return r.addWorkdirPath(index, filepath.ToSlash(rel))
+		})
+	}
+	var data []byte
+	mode := lib.FilemodeBlob
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(abs)
+		if err != nil {
+			return err
+		}
+		data = []byte(link)
+		mode = lib.FilemodeLink
+	default:
+		if data, err = os.ReadFile(abs); err != nil {
+			return err
+		}
+		if info.Mode()&0111 != 0 {
+			mode = lib.FilemodeBlobExecutable
+		}
+	}
+	oid, err := r.essence.CreateBlobFromBuffer(data)
+	if err != nil {
+		return err
+	}
+	return index.Add(&lib.IndexEntry{
+		Path: path,
+		Mode: mode,
+		Size: uint32(len(data)),
+		Id:   oid,
+	})
+}
+
+// commitIndex writes the index as a tree and creates a dangling commit of it
+func (r *Repository) commitIndex(index *lib.Index, sig *lib.Signature, message string, parents ...*lib.Commit) (*lib.Commit, error) {
+	treeid, err := index.WriteTreeTo(r.essence)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := r.essence.LookupTree(treeid)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+	oid, err := r.essence.CreateCommit("", sig, sig, message, tree, parents...)
+	if err != nil {
+		return nil, err
+	}
+	return r.essence.LookupCommit(oid)
+}
+
+// Apply is the wrapper of "git stash apply stash@{n}"
+func (s *Stash) Apply() error {
+	opts, err := stashApplyOptions()
+	if err != nil {
+		return err
+	}
+	return s.owner.essence.Stashes.Apply(s.Index, opts)
+}
+
+// Pop is the wrapper of "git stash pop stash@{n}"
+func (s *Stash) Pop() error {
+	opts, err := stashApplyOptions()
+	if err != nil {
+		return err
+	}
+	return s.owner.essence.Stashes.Pop(s.Index, opts)
+}
+
+// Drop is the wrapper of "git stash drop stash@{n}"
+func (s *Stash) Drop() error {
+	return s.owner.essence.Stashes.Drop(s.Index)
+}
+
+// NewBranch is the wrapper of "git stash branch <name> stash@{n}", creates a
+// branch at the commit the stash is based on, checks it out and pops the stash
+func (s *Stash) NewBranch(name string) error {
+	repo := s.owner.essence
+	base := s.target.essence.Parent(0)
+	if base == nil {
+		return fmt.Errorf("%s", "stash does not have a base commit")
+	}
+	defer base.Free()
+	branch, err := repo.CreateBranch(name, base, false)
+	if err != nil {
+		return err
+	}
+	defer branch.Free()
+	tree, err := base.Tree()
+	if err != nil {
+		return err
+	}
+	defer tree.Free()
+	if err := repo.CheckoutTree(tree, &lib.CheckoutOptions{
+		Strategy: lib.CheckoutSafe,
+	}); err != nil {
+		return err
+	}
+	if err := repo.SetHead(branch.Reference.Name()); err != nil {
+		return err
+	}
+	opts, err := stashApplyOptions()
+	if err != nil {
+		return err
+	}
+	opts.Flags = lib.StashApplyReinstateIndex
+	return repo.Stashes.Pop(s.Index, opts)
+}
+
+func stashApplyOptions() (lib.StashApplyOptions, error) {
+	opts, err := lib.DefaultStashApplyOptions()
+	if err != nil {
+		return opts, err
+	}
+	opts.CheckoutOptions.Strategy = lib.CheckoutSafe
+	return opts, nil
+}
+
+// Diff returns the changes recorded in the stash, including the untracked files
+func (s *Stash) Diff() (*Diff, error) {
+	diff, err := s.target.Diff()
+	if err != nil {
+		return nil, err
+	}
+	if s.target.essence.ParentCount() < 3 {
+		return diff, nil
+	}
+	untracked := s.target.essence.Parent(2)
+	defer untracked.Free()
+	uTree, err := untracked.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer uTree.Free()
+	uDiff, err := s.owner.diffTrees(nil, uTree, s.target)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range uDiff.deltas {
+		d.Status = DeltaUntracked
+	}
+	diff.deltas = append(diff.deltas, uDiff.deltas...)
+	diff.patchs = append(diff.patchs, uDiff.patchs...)
+	return diff, nil
+}
+
+// Target is the commit that holds the stashed work tree
+func (s *Stash) Target() *Commit {
+	return s.target
+}
+
+func (s *Stash) String() string {
+	return s.Message
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// treeContent returns the content of the file in the tree of the commit
+func treeContent(t *testing.T, r *Repository, c *Commit, path string) string {
+	t.Helper()
+	tree, err := c.essence.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Free()
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		t.Fatalf("%s is not in %s: %v", path, c.Hash[:7], err)
+	}
+	blob, err := r.essence.LookupBlob(entry.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Free()
+	return string(blob.Contents())
+}
+
+func TestStashEntries(t *testing.T) {
+	r := newTestRepository(t)
+	commitTestFile(t, r, "a.txt", "a\n")
+	head := commitTestFile(t, r, "b.txt", "b\n")
+	// a.txt has staged and unstaged changes, c.txt is untracked
+	writeTestFile(t, r.Path(), "a.txt", "a staged\n")
+	if err := r.AddToIndex(findEntry(t, r, "a.txt", false)); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, r.Path(), "a.txt", "a unstaged\n")
+	writeTestFile(t, r.Path(), "b.txt", "b modified\n")
+	writeTestFile(t, r.Path(), "c.txt", "c\n")
+
+	entries := []*StatusEntry{
+		findEntry(t, r, "a.txt", true),
+		findEntry(t, r, "a.txt", false),
+		findEntry(t, r, "c.txt", false),
+	}
+	for _, e := range entries {
+		if e == nil {
+			t.Fatal("status entry not found")
+		}
+	}
+	if err := r.StashEntries("partial", entries); err != nil {
+		t.Fatal(err)
+	}
+
+	stashes, err := r.Stashes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stashes) != 1 {
+		t.Fatalf("expected 1 stash, got %d", len(stashes))
+	}
+	st := stashes[0]
+	if expected := "On " + r.Head.Name + ": partial"; st.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, st.Message)
+	}
+	if st.Branch != r.Head.Name {
+		t.Errorf("expected branch %s, got %s", r.Head.Name, st.Branch)
+	}
+	target := st.Target()
+	parents := target.ParentHashes()
+	if len(parents) != 3 {
+		t.Fatalf("expected 3 parents of the stash commit, got %d", len(parents))
+	}
+	if parents[0] != head.Hash {
+		t.Errorf("expected HEAD %s as the first parent, got %s", head.Hash, parents[0])
+	}
+	index := unpackRawCommit(r, target.essence.Parent(1))
+	untracked := unpackRawCommit(r, target.essence.Parent(2))
+	if untracked.essence.ParentCount() != 0 {
+		t.Errorf("expected the untracked files commit to have no parents")
+	}
+	var tests = []struct {
+		commit  *Commit
+		path    string
+		content string
+	}{
+		{index, "a.txt", "a staged\n"},
+		{index, "b.txt", "b\n"},
+		{target, "a.txt", "a unstaged\n"},
+		{target, "b.txt", "b\n"},
+		{untracked, "c.txt", "c\n"},
+	}
+	for _, test := range tests {
+		if content := treeContent(t, r, test.commit, test.path); content != test.content {
+			t.Errorf("expected %q in %s of %s, got %q", test.content, test.path, test.commit.Hash[:7], content)
+		}
+	}
+
+	// the stashed paths are reverted, the others are untouched
+	assertTestFile(t, r, "a.txt", "a\n")
+	assertTestFile(t, r, "b.txt", "b modified\n")
+	if content := indexContent(t, r, "a.txt"); content != "a\n" {
+		t.Errorf("expected a.txt to be reset in the index, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(r.Path(), "c.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected c.txt to be removed, got %v", err)
+	}
+
+	if err := st.Pop(); err != nil {
+		t.Fatal(err)
+	}
+	assertTestFile(t, r, "a.txt", "a unstaged\n")
+	assertTestFile(t, r, "b.txt", "b modified\n")
+	assertTestFile(t, r, "c.txt", "c\n")
+	if stashes, err := r.Stashes(); err != nil || len(stashes) != 0 {
+		t.Errorf("expected the stash to be dropped, got %d stashes, %v", len(stashes), err)
+	}
+}
+
+func TestStashBranch(t *testing.T) {
+	var tests = []struct {
+		message string
+		branch  string
+	}{
+		{"WIP on master: 1a2b3c4 summary", "master"},
+		{"On feature/x: message", "feature/x"},
+		{"custom message", ""},
+	}
+	for _, test := range tests {
+		if branch := stashBranch(test.message); branch != test.branch {
+			t.Errorf("%q: expected %q, got %q", test.message, test.branch, branch)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package git
 
 import (
+	"sort"
+
 	lib "github.com/libgit2/git2go/v33"
 )
 
@@ -91,6 +93,9 @@ type StatusEntry struct {
 	index     IndexType
 	EntryType StatusEntryType
 	diffDelta *DiffDelta
+	submodule bool
+
+	Submodule *Submodule // nil if the entry is not a submodule
 }
 
 // Status contains all git status data
@@ -111,6 +116,11 @@ func (d *Diff) Deltas() []*DiffDelta {
 	return d.deltas
 }
 
+// Stats returns the diffstat lines of the diff
+func (d *Diff) Stats() []string {
+	return d.stats
+}
+
 // DiffDelta holds delta status, file changes and the actual patchs
 type DiffDelta struct {
 	Status  DeltaStatus
@@ -135,7 +145,7 @@ func (r *Repository) LoadStatus() (*Status, error) {
 	// this returns err does it matter?
 	statusOptions := &lib.StatusOptions{
 		Show:  lib.StatusShowIndexAndWorkdir,
-		Flags: lib.StatusOptIncludeUntracked,
+		Flags: lib.StatusOptIncludeUntracked | lib.StatusOptRenamesHeadToIndex,
 	}
 	statusList, err := r.essence.StatusList(statusOptions)
 	if err != nil {
@@ -149,7 +159,7 @@ func (r *Repository) LoadStatus() (*Status, error) {
 	}
 	entities := make([]*StatusEntry, 0)
 	s := &Status{
-		State:    State(r.essence.State()),
+		State:    r.State(),
 		Entities: entities,
 	}
 	for i := 0; i < count; i++ {
@@ -162,6 +172,17 @@ func (r *Repository) LoadStatus() (*Status, error) {
 		}
 		s.addToStatus(statusEntry)
 	}
+	for _, e := range s.Entities {
+		if e.submodule {
+			// the submodule is nil if it is removed from .gitmodules
+			e.Submodule, _ = r.Submodule(e.String())
+		}
+	}
+	// the conflicts are listed first since they block the operation in progress
+	sort.SliceStable(s.Entities, func(i, j int) bool {
+		return s.Entities[i].EntryType == StatusEntryTypeConflicted &&
+			s.Entities[j].EntryType != StatusEntryTypeConflicted
+	})
 	return s, nil
 }
 
@@ -189,17 +210,38 @@ func (s *Status) addToStatus(raw lib.StatusEntry) {
 				index:     indexType,
 				EntryType: statusEntryTypeMap[set],
 				diffDelta: d,
+				submodule: lib.Filemode(dd.NewFile.Mode) == lib.FilemodeCommit ||
+					lib.Filemode(dd.OldFile.Mode) == lib.FilemodeCommit,
 			}
 			s.Entities = append(s.Entities, e)
 		}
 	}
 }
 
-// Indexed true if entry added to index
+// String returns the path of the entry, the new path if it is renamed
 func (e *StatusEntry) String() string {
+	if len(e.diffDelta.NewFile.Path) > 0 {
+		return e.diffDelta.NewFile.Path
+	}
+	return e.diffDelta.OldFile.Path
+}
+
+// OldPath returns the path of the entry before it was renamed
+func (e *StatusEntry) OldPath() string {
 	return e.diffDelta.OldFile.Path
 }
 
+// paths returns both paths of a renamed entry, or the only path otherwise
+func (e *StatusEntry) paths() []string {
+	paths := make([]string, 0, 2)
+	for _, path := range []string{e.diffDelta.OldFile.Path, e.diffDelta.NewFile.Path} {
+		if len(path) > 0 && (len(paths) == 0 || paths[0] != path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // Indexed true if entry added to index
 func (e *StatusEntry) Indexed() bool {
 	return e.index == IndexTypeStaged
@@ -227,35 +269,6 @@ func (e *StatusEntry) StatusEntryString() string {
 	}
 }
 
-// AddToIndex is the wrapper of "git add /path/to/file" command
-func (r *Repository) AddToIndex(e *StatusEntry) error {
-	index, err := r.essence.Index()
-	if err != nil {
-		return err
-	}
-	if err := index.AddByPath(e.diffDelta.OldFile.Path); err != nil {
-		return err
-	}
-	defer index.Free()
-	return index.Write()
-}
-
-// RemoveFromIndex is the wrapper of "git reset path/to/file" command
-func (r *Repository) RemoveFromIndex(e *StatusEntry) error {
-	if !e.Indexed() {
-		return ErrEntryNotIndexed
-	}
-	index, err := r.essence.Index()
-	if err != nil {
-		return err
-	}
-	if err := index.RemoveByPath(e.diffDelta.OldFile.Path); err != nil {
-		return err
-	}
-	defer index.Free()
-	return index.Write()
-}
-
 // DeltaStatusString retruns delta status as string
 func (d *DiffDelta) DeltaStatusString() string {
 	switch d.Status {
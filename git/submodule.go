@@ -0,0 +1,189 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// SubmoduleChange is the set of changes in the working tree of a submodule
+// like the ones "git status" reports
+type SubmoduleChange uint8
+
+// The changes of a submodule
+const (
+	SubmoduleNewCommits SubmoduleChange = 1 << iota
+	SubmoduleModifiedContent
+	SubmoduleUntrackedContent
+)
+
+// Submodule is the wrapper of lib.Submodule
+type Submodule struct {
+	owner *Repository
+
+	Name        string
+	Path        string
+	URL         string
+	Recorded    string // the commit recorded in the index of the superproject
+	CheckedOut  string // the HEAD of the submodule, empty if it is not cloned
+	Initialized bool   // the submodule is registered in the config
+	Changes     SubmoduleChange
+}
+
+func (s *Submodule) String() string {
+	return s.Path
+}
+
+// ChangesString returns the changes in the format of "git status" e.g.
+// "new commits, modified content"
+func (s *Submodule) ChangesString() string {
+	changes := make([]string, 0)
+	if s.Changes&SubmoduleNewCommits != 0 {
+		changes = append(changes, "new commits")
+	}
+	if s.Changes&SubmoduleModifiedContent != 0 {
+		changes = append(changes, "modified content")
+	}
+	if s.Changes&SubmoduleUntrackedContent != 0 {
+		changes = append(changes, "untracked content")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// Submodules is the wrapper of "git submodule status"
+func (r *Repository) Submodules() ([]*Submodule, error) {
+	names := make([]string, 0)
+	// the submodules of the callback are freed after it returns
+	if err := r.essence.Submodules.Foreach(func(sub *lib.Submodule, name string) error {
+		names = append(names, name)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	submodules := make([]*Submodule, 0, len(names))
+	for _, name := range names {
+		s, err := r.Submodule(name)
+		if err != nil {
+			return nil, err
+		}
+		submodules = append(submodules, s)
+	}
+	return submodules, nil
+}
+
+// Submodule loads the submodule with the name or the path
+func (r *Repository) Submodule(name string) (*Submodule, error) {
+	sub, err := r.essence.Submodules.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Free()
+	s := &Submodule{
+		owner: r,
+		Name:  sub.Name(),
+		Path:  sub.Path(),
+		URL:   sub.Url(),
+	}
+	if id := sub.IndexId(); id != nil {
+		s.Recorded = id.String()
+	} else if id := sub.HeadId(); id != nil {
+		s.Recorded = id.String()
+	}
+	if id := sub.WdId(); id != nil {
+		s.CheckedOut = id.String()
+	}
+	cfg, err := r.essence.Config()
+	if err == nil {
+		_, err := cfg.LookupString("submodule." + s.Name + ".url")
+		s.Initialized = err == nil
+		cfg.Free()
+	}
+	if len(s.CheckedOut) > 0 && s.CheckedOut != s.Recorded {
+		s.Changes |= SubmoduleNewCommits
+	}
+	s.Changes |= s.contentChanges()
+	return s, nil
+}
+
+// contentChanges returns the changes in the working tree of the submodule
+func (s *Submodule) contentChanges() SubmoduleChange {
+	repo, err := lib.OpenRepository(filepath.Join(s.owner.path, s.Path))
+	if err != nil {
+		return 0
+	}
+	defer repo.Free()
+	list, err := repo.StatusList(&lib.StatusOptions{
+		Show:  lib.StatusShowIndexAndWorkdir,
+		Flags: lib.StatusOptIncludeUntracked,
+	})
+	if err != nil {
+		return 0
+	}
+	defer list.Free()
+	count, err := list.EntryCount()
+	if err != nil {
+		return 0
+	}
+	var changes SubmoduleChange
+	for i := 0; i < count; i++ {
+		entry, err := list.ByIndex(i)
+		if err != nil {
+			continue
+		}
+		if entry.Status&lib.StatusWtNew != 0 {
+			changes |= SubmoduleUntrackedContent
+		} else if entry.Status != lib.StatusCurrent {
+			changes |= SubmoduleModifiedContent
+		}
+	}
+	return changes
+}
+
+// Init is the wrapper of "git submodule init <path>"
+func (s *Submodule) Init() error {
+	sub, err := s.owner.essence.Submodules.Lookup(s.Name)
+	if err != nil {
+		return err
+	}
+	defer sub.Free()
+	return sub.Init(false)
+}
+
+// Update is the wrapper of "git submodule update --init <path>", the
+// submodule is cloned if it is missing and the recorded commit is checked out
+func (s *Submodule) Update() error {
+	sub, err := s.owner.essence.Submodules.Lookup(s.Name)
+	if err != nil {
+		return err
+	}
+	defer sub.Free()
+	return authError(sub.Update(true, &lib.SubmoduleUpdateOptions{
+		CheckoutOptions: lib.CheckoutOptions{
+			Strategy: lib.CheckoutSafe,
+		},
+		FetchOptions: lib.FetchOptions{
+			RemoteCallbacks: remoteCallbacks(nil),
+			UpdateFetchhead: true,
+		},
+	}))
+}
+
+// Sync is the wrapper of "git submodule sync <path>", the url of the
+// .gitmodules file is copied to the config
+func (s *Submodule) Sync() error {
+	sub, err := s.owner.essence.Submodules.Lookup(s.Name)
+	if err != nil {
+		return err
+	}
+	defer sub.Free()
+	return sub.Sync()
+}
+
+// Open opens the repository of the submodule
+func (s *Submodule) Open() (*Repository, error) {
+	if len(s.CheckedOut) == 0 {
+		return nil, ErrSubmoduleNotCloned
+	}
+	return Open(filepath.Join(s.owner.path, s.Path))
+}
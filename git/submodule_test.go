@@ -0,0 +1,168 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+func TestSubmoduleChangesString(t *testing.T) {
+	var tests = []struct {
+		changes SubmoduleChange
+		output  string
+	}{
+		{0, ""},
+		{SubmoduleNewCommits, "new commits"},
+		{SubmoduleModifiedContent | SubmoduleUntrackedContent, "modified content, untracked content"},
+		{SubmoduleNewCommits | SubmoduleModifiedContent | SubmoduleUntrackedContent, "new commits, modified content, untracked content"},
+	}
+	for _, test := range tests {
+		s := &Submodule{Changes: test.changes}
+		if output := s.ChangesString(); output != test.output {
+			t.Errorf("expected %q, got %q", test.output, output)
+		}
+	}
+}
+
+// addTestSubmodule adds the source repository as a submodule at the path
+// and commits it, the submodule is cloned like "git submodule add" does
+func addTestSubmodule(t *testing.T, r, src *Repository, path string) {
+	t.Helper()
+	sub, err := r.essence.Submodules.Add(src.Path(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Free()
+	repo, err := sub.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Free()
+	remote, err := repo.Remotes.Lookup("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Free()
+	if err := remote.Fetch(nil, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := repo.References.Lookup("refs/remotes/origin/" + src.Head.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Free()
+	commit, err := repo.LookupCommit(ref.Target())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer commit.Free()
+	branch, err := repo.CreateBranch(src.Head.Name, commit, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer branch.Free()
+	if err := repo.SetHead(branch.Reference.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CheckoutHead(&lib.CheckoutOptions{Strategy: lib.CheckoutForce}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.FinalizeAdd(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Commit("add " + path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmodules(t *testing.T) {
+	r := newTestRepository(t)
+	src := newTestRepository(t)
+	recorded := commitTestFile(t, src, "lib.txt", "lib\n")
+	addTestSubmodule(t, r, src, "lib")
+
+	submodules, err := r.Submodules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submodules) != 1 {
+		t.Fatalf("expected 1 submodule, got %d", len(submodules))
+	}
+	s := submodules[0]
+	if s.Name != "lib" || s.Path != "lib" || s.URL != src.Path() {
+		t.Errorf("unexpected submodule %s at %s from %s", s.Name, s.Path, s.URL)
+	}
+	if s.Recorded != recorded.Hash || s.CheckedOut != recorded.Hash {
+		t.Errorf("expected %s recorded and checked out, got %s and %s", recorded.Hash, s.Recorded, s.CheckedOut)
+	}
+	if !s.Initialized {
+		t.Error("expected the submodule to be initialized")
+	}
+	if s.Changes != 0 {
+		t.Errorf("expected no changes, got %s", s.ChangesString())
+	}
+
+	// commit, modify and add a file in the submodule
+	sr, err := s.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := commitTestFile(t, sr, "lib.txt", "lib v2\n")
+	writeTestFile(t, sr.Path(), "README.md", "# lib\n")
+	writeTestFile(t, sr.Path(), "untracked.txt", "untracked\n")
+	s, err = r.Submodule("lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Recorded != recorded.Hash || s.CheckedOut != head.Hash {
+		t.Errorf("expected %s recorded and %s checked out, got %s and %s", recorded.Hash, head.Hash, s.Recorded, s.CheckedOut)
+	}
+	expected := SubmoduleNewCommits | SubmoduleModifiedContent | SubmoduleUntrackedContent
+	if s.Changes != expected {
+		t.Errorf("expected %q, got %q", (&Submodule{Changes: expected}).ChangesString(), s.ChangesString())
+	}
+	e := findEntry(t, r, "lib", false)
+	if e == nil || e.Submodule == nil {
+		t.Fatalf("expected a submodule status entry of lib, got %v", e)
+	}
+	if e.Submodule.Changes != expected {
+		t.Errorf("expected %q in the status, got %q", (&Submodule{Changes: expected}).ChangesString(), e.Submodule.ChangesString())
+	}
+
+	// sync copies the url of .gitmodules to the config, init registers it
+	url := filepath.Join(filepath.Dir(src.Path()), "moved")
+	if err := r.essence.Submodules.SetUrl("lib", url); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := r.essence.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Free()
+	if configured, err := cfg.LookupString("submodule.lib.url"); err != nil || configured != url {
+		t.Errorf("expected %s in the config after sync, got %s, %v", url, configured, err)
+	}
+	if err := cfg.Delete("submodule.lib.url"); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = r.Submodule("lib"); err != nil || s.Initialized {
+		t.Fatalf("expected the submodule not to be initialized, %v", err)
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = r.Submodule("lib"); err != nil || !s.Initialized {
+		t.Errorf("expected the submodule to be initialized, %v", err)
+	}
+}
+
+func TestOpenSubmoduleNotCloned(t *testing.T) {
+	s := &Submodule{Name: "lib", Path: "lib"}
+	if _, err := s.Open(); err != ErrSubmoduleNotCloned {
+		t.Errorf("expected %v, got %v", ErrSubmoduleNotCloned, err)
+	}
+}
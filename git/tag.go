@@ -1,15 +1,41 @@
 package git
 
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
 // Tag is used to label and mark a specific commit in the history.
 type Tag struct {
 	target  *Commit
+	owner   *Repository
 	refType RefType
 
 	Hash      string
 	Shorthand string
 	Name      string
+
+	// the fields below are only set for annotated tags
+	Annotated bool
+	TagHash   string
+	Tagger    *Signature
+	Message   string
+	Signature string
 }
 
+// TagSort defines the order of the tags
+type TagSort uint8
+
+// Tags can be sorted by the tagger date or the semantic versions in their names
+const (
+	TagSortDate TagSort = iota
+	TagSortSemver
+)
+
 // Tags loads tags from the refs
 func (r *Repository) Tags() ([]*Tag, error) {
 
@@ -18,6 +44,8 @@ func (r *Repository) Tags() ([]*Tag, error) {
 		return nil, err
 	}
 	defer iter.Free()
+	// the tags are loaded again after a tag is created or deleted
+	r.clearRefs(RefTypeTag)
 	buffer := make([]*Tag, 0)
 	for {
 		ref, err := iter.Next()
@@ -29,10 +57,23 @@ func (r *Repository) Tags() ([]*Tag, error) {
 
 			t := &Tag{
 				Hash:      ref.Target().String(),
+				owner:     r,
 				refType:   RefTypeTag,
 				Name:      ref.Name(),
 				Shorthand: ref.Shorthand(),
 			}
+			if tag, err := r.essence.LookupTag(ref.Target()); err == nil {
+				t.unpackAnnotation(tag)
+				tag.Free()
+			}
+			// peel annotated tags to the commit they point
+			obj, err := ref.Peel(lib.ObjectCommit)
+			if err == nil && obj != nil {
+				if commit, _ := obj.AsCommit(); commit != nil {
+					t.target = unpackRawCommit(r, commit)
+					t.Hash = t.target.Hash
+				}
+			}
 			// add to refmap
 			if _, ok := r.RefMap[t.Hash]; !ok {
 				r.RefMap[t.Hash] = make([]Ref, 0)
@@ -41,18 +82,77 @@ func (r *Repository) Tags() ([]*Tag, error) {
 			refs = append(refs, t)
 			r.RefMap[t.Hash] = refs
 
-			obj, err := r.essence.RevparseSingle(ref.Target().String())
-			if err == nil && obj != nil {
-				if commit, _ := obj.AsCommit(); commit != nil {
-					t.target = unpackRawCommit(r, commit)
-				}
-			}
 			buffer = append(buffer, t)
 		}
 	}
 	return buffer, nil
 }
 
+func (t *Tag) unpackAnnotation(tag *lib.Tag) {
+	t.Annotated = true
+	t.TagHash = tag.Id().String()
+	if tagger := tag.Tagger(); tagger != nil {
+		t.Tagger = &Signature{
+			Name:  tagger.Name,
+			Email: tagger.Email,
+			When:  tagger.When,
+		}
+	}
+	t.Message, t.Signature = splitSignature(tag.Message())
+}
+
+// CreateTag is the wrapper of "git tag -a <name> -m <message> <commit>"
+func (r *Repository) CreateTag(name string, c *Commit, message string) error {
+	tagger, err := r.essence.DefaultSignature()
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	_, err = r.essence.Tags.Create(name, c.essence, tagger, message)
+	return err
+}
+
+// CreateLightweightTag is the wrapper of "git tag <name> <commit>"
+func (r *Repository) CreateLightweightTag(name string, c *Commit) error {
+	_, err := r.essence.Tags.CreateLightweight(name, c.essence, false)
+	return err
+}
+
+// Delete is the wrapper of "git tag --delete <name>"
+func (t *Tag) Delete() error {
+	return t.owner.essence.Tags.Remove(t.Shorthand)
+}
+
+// When returns the tagger date, or the commit date for lightweight tags
+func (t *Tag) When() time.Time {
+	if t.Tagger != nil {
+		return t.Tagger.When
+	}
+	if t.target != nil {
+		return t.target.Author.When
+	}
+	return time.Time{}
+}
+
+// SignedPayload returns the part of the annotated tag object that the
+// signature signs
+func (t *Tag) SignedPayload() ([]byte, error) {
+	oid, err := lib.NewOid(t.TagHash)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := t.owner.rawObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	if i := strings.Index(string(payload), t.Signature); i > 0 {
+		payload = payload[:i]
+	}
+	return payload, nil
+}
+
 // Type is the reference type of this ref
 func (t *Tag) Type() RefType {
 	return t.refType
@@ -66,3 +166,101 @@ func (t *Tag) Target() *Commit {
 func (t *Tag) String() string {
 	return t.Shorthand
 }
+
+// SortTags sorts the tags from the newest to the oldest. If they are sorted
+// by semver, tags that are not a version come last in alphabetical order.
+func SortTags(tags []*Tag, by TagSort) {
+	switch by {
+	case TagSortDate:
+		sort.SliceStable(tags, func(i, j int) bool {
+			return tags[i].When().After(tags[j].When())
+		})
+	case TagSortSemver:
+		sort.SliceStable(tags, func(i, j int) bool {
+			vi, vj := parseVersion(tags[i].Shorthand), parseVersion(tags[j].Shorthand)
+			switch {
+			case vi.valid && vj.valid:
+				return vi.compare(vj) > 0
+			case vi.valid != vj.valid:
+				return vi.valid
+			default:
+				return tags[i].Shorthand < tags[j].Shorthand
+			}
+		})
+	}
+}
+
+// version is a loosely parsed semantic version, "v" prefix and missing minor
+// or patch numbers are tolerated
+type version struct {
+	numbers    [3]int
+	prerelease []string
+	valid      bool
+}
+
+func parseVersion(s string) version {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v
+		}
+		v.numbers[i] = n
+	}
+	v.valid = true
+	return v
+}
+
+// compare returns 1 if v is greater than o, -1 if it is lower and 0 otherwise
+func (v version) compare(o version) int {
+	for i := range v.numbers {
+		if v.numbers[i] != o.numbers[i] {
+			return sign(v.numbers[i] - o.numbers[i])
+		}
+	}
+	// a release is greater than its pre-releases
+	if len(v.prerelease) == 0 || len(o.prerelease) == 0 {
+		return sign(len(o.prerelease) - len(v.prerelease))
+	}
+	for i := 0; i < len(v.prerelease) && i < len(o.prerelease); i++ {
+		a, b := v.prerelease[i], o.prerelease[i]
+		if a == b {
+			continue
+		}
+		na, errA := strconv.Atoi(a)
+		nb, errB := strconv.Atoi(b)
+		switch {
+		case errA == nil && errB == nil:
+			return sign(na - nb)
+		case errA == nil:
+			return -1 // numeric identifiers have lower precedence
+		case errB == nil:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	}
+	return sign(len(v.prerelease) - len(o.prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
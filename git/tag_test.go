@@ -0,0 +1,105 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestSortTagsBySemver(t *testing.T) {
+	var tests = []struct {
+		input  []string
+		output []string
+	}{
+		{[]string{"v0.1.0", "v0.10.0", "v0.2.0"}, []string{"v0.10.0", "v0.2.0", "v0.1.0"}},
+		{[]string{"1.0.0-rc.1", "1.0.0", "1.0.0-beta", "1.0.0-rc.2"}, []string{"1.0.0", "1.0.0-rc.2", "1.0.0-rc.1", "1.0.0-beta"}},
+		{[]string{"1.0.0-alpha", "1.0.0-1", "1.0.0-alpha.1"}, []string{"1.0.0-alpha.1", "1.0.0-alpha", "1.0.0-1"}},
+		{[]string{"latest", "v2", "nightly", "v1.5"}, []string{"v2", "v1.5", "latest", "nightly"}},
+	}
+	for _, test := range tests {
+		tags := make([]*Tag, 0)
+		for _, name := range test.input {
+			tags = append(tags, &Tag{Shorthand: name})
+		}
+		SortTags(tags, TagSortSemver)
+		for i, tag := range tags {
+			if tag.Shorthand != test.output[i] {
+				t.Errorf("input: %v\n position %d: expected %s, got %s", test.input, i, test.output[i], tag.Shorthand)
+			}
+		}
+	}
+}
+
+// findTag loads the tags and returns the one with the name
+func findTag(t *testing.T, r *Repository, name string) *Tag {
+	t.Helper()
+	tags, err := r.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range tags {
+		if tag.Shorthand == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+func TestCreateAndDeleteTags(t *testing.T) {
+	r := newTestRepository(t)
+	first := commitTestFile(t, r, "file.txt", "a\n")
+	second := commitTestFile(t, r, "file.txt", "a\nb\n")
+	if err := r.CreateTag("v1.0.0", first, "first release"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateLightweightTag("latest", second); err != nil {
+		t.Fatal(err)
+	}
+
+	annotated := findTag(t, r, "v1.0.0")
+	if annotated == nil {
+		t.Fatal("annotated tag not found")
+	}
+	if !annotated.Annotated || annotated.Message != "first release\n" {
+		t.Errorf("expected an annotated tag with its message, got %q", annotated.Message)
+	}
+	if annotated.Tagger == nil || annotated.Tagger.Email != "gitin@example.com" {
+		t.Errorf("expected the tagger gitin@example.com, got %v", annotated.Tagger)
+	}
+	// the annotated tag is peeled to the commit it points
+	if annotated.Hash != first.Hash || annotated.Target() == nil || annotated.Target().Hash != first.Hash {
+		t.Errorf("expected the tag to point %s, got %s", first.Hash, annotated.Hash)
+	}
+	if annotated.TagHash == first.Hash {
+		t.Error("expected the hash of the tag object to differ from the commit")
+	}
+	if len(annotated.Signature) != 0 {
+		t.Errorf("expected no signature, got %q", annotated.Signature)
+	}
+
+	lightweight := findTag(t, r, "latest")
+	if lightweight == nil {
+		t.Fatal("lightweight tag not found")
+	}
+	if lightweight.Annotated || lightweight.Tagger != nil {
+		t.Error("expected a lightweight tag")
+	}
+	if lightweight.Hash != second.Hash || !lightweight.When().Equal(second.Author.When) {
+		t.Errorf("expected the tag to point %s, got %s", second.Hash, lightweight.Hash)
+	}
+	if err := r.CreateLightweightTag("latest", first); err == nil {
+		t.Error("expected an error for an existing tag")
+	}
+
+	if err := annotated.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if findTag(t, r, "v1.0.0") != nil {
+		t.Error("expected the tag to be deleted")
+	}
+	// the reloads do not keep the deleted tags or duplicate the others
+	if refs := r.RefMap[first.Hash]; len(refs) != 0 {
+		t.Errorf("expected no refs of %s, got %v", first.Hash[:7], refs)
+	}
+	if refs := r.RefMap[second.Hash]; len(refs) != 1 {
+		t.Errorf("expected 1 ref of %s, got %d", second.Hash[:7], len(refs))
+	}
+}
@@ -0,0 +1,255 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/libgit2/git2go/v33"
+)
+
+// Worktree is a working tree of the repository, git2go does not bind the
+// worktrees of libgit2 so they are read from the worktrees directory of the
+// common directory that has the same layout in every repository
+type Worktree struct {
+	owner *Repository
+	admin string // the git directory of a linked worktree
+
+	Name       string
+	Path       string
+	Branch     string // short name of the checked out branch, empty if detached
+	Hash       string
+	Main       bool
+	Current    bool // gitin runs in this worktree
+	Locked     bool
+	LockReason string
+	Prunable   bool // the working tree is missing
+	Dirty      bool
+}
+
+func (w *Worktree) String() string {
+	return w.Path
+}
+
+// Worktrees is the wrapper of "git worktree list", the main worktree is the
+// first one
+func (r *Repository) Worktrees() ([]*Worktree, error) {
+	common := r.commonDir()
+	worktrees := make([]*Worktree, 0)
+	if main := filepath.Dir(common); filepath.Base(common) == ".git" {
+		w := &Worktree{
+			owner: r,
+			Name:  filepath.Base(main),
+			Path:  main,
+			Main:  true,
+		}
+		r.loadWorktreeHead(w, common)
+		worktrees = append(worktrees, w)
+	}
+	dirs, err := os.ReadDir(filepath.Join(common, "worktrees"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		w, err := r.linkedWorktree(filepath.Join(common, "worktrees", dir.Name()))
+		if err != nil {
+			continue
+		}
+		worktrees = append(worktrees, w)
+	}
+	current := filepath.Clean(r.essence.Workdir())
+	for _, w := range worktrees {
+		w.Current = w.Path == current
+		if !w.Prunable {
+			w.Dirty = worktreeDirty(w.Path)
+		}
+	}
+	return worktrees, nil
+}
+
+// linkedWorktree reads the worktree of the git directory
+func (r *Repository) linkedWorktree(admin string) (*Worktree, error) {
+	gitdir, err := os.ReadFile(filepath.Join(admin, "gitdir"))
+	if err != nil {
+		return nil, err
+	}
+	link := strings.TrimSpace(string(gitdir))
+	w := &Worktree{
+		owner: r,
+		admin: admin,
+		Name:  filepath.Base(admin),
+		Path:  filepath.Dir(link),
+	}
+	if reason, err := os.ReadFile(filepath.Join(admin, "locked")); err == nil {
+		w.Locked = true
+		w.LockReason = strings.TrimSpace(string(reason))
+	}
+	if _, err := os.Stat(link); os.IsNotExist(err) && !w.Locked {
+		w.Prunable = true
+	}
+	r.loadWorktreeHead(w, admin)
+	return w, nil
+}
+
+// loadWorktreeHead reads the branch and the commit of the HEAD of the git
+// directory
+func (r *Repository) loadWorktreeHead(w *Worktree, dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil {
+		return
+	}
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: ") {
+		w.Hash = head
+		return
+	}
+	name := strings.TrimPrefix(head, "ref: ")
+	w.Branch = strings.TrimPrefix(name, "refs/heads/")
+	if ref, err := r.essence.References.Lookup(name); err == nil {
+		w.Hash = ref.Target().String()
+		ref.Free()
+	}
+}
+
+// worktreeDirty returns true if the working tree has changes or untracked
+// files
+func worktreeDirty(path string) bool {
+	repo, err := lib.OpenRepository(path)
+	if err != nil {
+		return false
+	}
+	defer repo.Free()
+	list, err := repo.StatusList(&lib.StatusOptions{
+		Show:  lib.StatusShowIndexAndWorkdir,
+		Flags: lib.StatusOptIncludeUntracked,
+	})
+	if err != nil {
+		return false
+	}
+	defer list.Free()
+	count, err := list.EntryCount()
+	return err == nil && count > 0
+}
+
+// AddWorktree is the wrapper of "git worktree add <path> <branch>", the
+// local branch of a remote-tracking branch is created if it does not exist.
+// A branch can be checked out in only one worktree.
+func (r *Repository) AddWorktree(path string, b *Branch) (*Worktree, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	name := b.Name
+	if b.isRemote {
+		remote, err := r.essence.RemoteName(b.FullName)
+		if err != nil {
+			return nil, err
+		}
+		name = strings.TrimPrefix(b.Name, remote+"/")
+	}
+	worktrees, err := r.Worktrees()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range worktrees {
+		if w.Branch == name {
+			return nil, ErrBranchCheckedOut
+		}
+	}
+	admin := filepath.Join(r.commonDir(), "worktrees", filepath.Base(path))
+	if _, err := os.Stat(admin); err == nil {
+		return nil, ErrWorktreeExists
+	}
+	if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		return nil, ErrWorktreeExists
+	}
+	if b.isRemote {
+		target, err := r.checkoutTarget(b, name)
+		if err != nil {
+			return nil, err
+		}
+		err = r.trackBranch(name, b, target)
+		target.Free()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(admin, 0755); err != nil {
+		return nil, err
+	}
+	files := map[string]string{
+		filepath.Join(admin, "gitdir"):    filepath.Join(path, ".git"),
+		filepath.Join(admin, "commondir"): filepath.Join("..", ".."),
+		filepath.Join(admin, "HEAD"):      "ref: refs/heads/" + name,
+		filepath.Join(path, ".git"):       "gitdir: " + admin,
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content+"\n"), 0644); err != nil {
+			return nil, err
+		}
+	}
+	repo, err := lib.OpenRepository(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+	if err := repo.CheckoutHead(&lib.CheckoutOptions{
+		Strategy: lib.CheckoutForce,
+	}); err != nil {
+		return nil, err
+	}
+	return r.linkedWorktree(admin)
+}
+
+// Remove is the wrapper of "git worktree remove <worktree>", a worktree with
+// changes is removed only if forced. The main worktree, the current one and
+// the locked ones cannot be removed.
+func (w *Worktree) Remove(force bool) error {
+	switch {
+	case w.Main || w.Current:
+		return ErrWorktreeInUse
+	case w.Locked:
+		return ErrWorktreeLocked
+	case w.Dirty && !force:
+		return ErrWorktreeDirty
+	}
+	if err := os.RemoveAll(w.Path); err != nil {
+		return err
+	}
+	return os.RemoveAll(w.admin)
+}
+
+// Lock is the wrapper of "git worktree lock --reason <reason> <worktree>",
+// a locked worktree is not pruned or removed
+func (w *Worktree) Lock(reason string) error {
+	if w.Main {
+		return ErrWorktreeInUse
+	}
+	if w.Locked {
+		return ErrWorktreeLocked
+	}
+	return os.WriteFile(filepath.Join(w.admin, "locked"), []byte(reason), 0644)
+}
+
+// Unlock is the wrapper of "git worktree unlock <worktree>"
+func (w *Worktree) Unlock() error {
+	if !w.Locked {
+		return nil
+	}
+	return os.Remove(filepath.Join(w.admin, "locked"))
+}
+
+// Prune removes the git directory of a worktree whose working tree is
+// missing like "git worktree prune" does
+func (w *Worktree) Prune() error {
+	if !w.Prunable {
+		return ErrWorktreeNotPrunable
+	}
+	return os.RemoveAll(w.admin)
+}
@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktrees(t *testing.T) {
+	r := newTestRepository(t)
+	c := commitTestFile(t, r, "file.txt", "a\n")
+	if err := r.CreateBranch("feature", c); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "feature")
+	added, err := r.AddWorktree(path, findBranch(t, r, "feature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Branch != "feature" || added.Hash != c.Hash {
+		t.Errorf("expected feature at %s, got %s at %s", c.Hash, added.Branch, added.Hash)
+	}
+	if _, err := r.AddWorktree(path+"2", findBranch(t, r, "feature")); err != ErrBranchCheckedOut {
+		t.Errorf("expected %v, got %v", ErrBranchCheckedOut, err)
+	}
+
+	linked, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !linked.IsWorktree() || r.IsWorktree() {
+		t.Error("expected only the linked repository to be a worktree")
+	}
+	if linked.Head == nil || linked.Head.Name != "feature" {
+		t.Error("expected the linked worktree to be on feature")
+	}
+	assertTestFile(t, linked, "file.txt", "a\n")
+
+	worktrees, err := r.Worktrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worktrees) != 2 || !worktrees[0].Main || !worktrees[0].Current {
+		t.Fatalf("expected the main and the linked worktrees, got %v", worktrees)
+	}
+	w := worktrees[1]
+	if err := w.Lock("on a usb stick"); err != nil {
+		t.Fatal(err)
+	}
+	w = findWorktree(t, r, path)
+	if !w.Locked || w.LockReason != "on a usb stick" {
+		t.Error("expected the worktree to be locked")
+	}
+	if err := w.Remove(true); err != ErrWorktreeLocked {
+		t.Errorf("expected %v, got %v", ErrWorktreeLocked, err)
+	}
+	if err := w.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, path, "file.txt", "changed\n")
+	w = findWorktree(t, r, path)
+	if err := w.Remove(false); err != ErrWorktreeDirty {
+		t.Errorf("expected %v, got %v", ErrWorktreeDirty, err)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	w = findWorktree(t, r, path)
+	if !w.Prunable {
+		t.Fatal("expected the missing worktree to be prunable")
+	}
+	if err := w.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if worktrees, _ := r.Worktrees(); len(worktrees) != 1 {
+		t.Errorf("expected only the main worktree, got %d", len(worktrees))
+	}
+}
+
+func findWorktree(t *testing.T, r *Repository, path string) *Worktree {
+	t.Helper()
+	worktrees, err := r.Worktrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range worktrees {
+		if w.Path == path {
+			return w
+		}
+	}
+	t.Fatalf("worktree %s not found", path)
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package highlight colors source code line by line for the terminal. The
+// language is decided by the file name and the lexers are simple tokenizers
+// that only know the keywords, literals and comments of the languages.
+package highlight
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// Kind is the kind of a token
+type Kind uint8
+
+// The token kinds, plain text is drawn with the default color of the caller
+const (
+	Plain Kind = iota
+	Keyword
+	Type // types, builtins and constants
+	Function
+	String
+	Number
+	Comment
+	Key // keys of the maps e.g. in YAML and JSON
+	Variable
+	Heading
+)
+
+var palette = map[Kind][]color.Attribute{
+	Keyword:  {color.FgMagenta},
+	Type:     {color.FgCyan},
+	Function: {color.FgBlue},
+	String:   {color.FgYellow},
+	Number:   {color.FgHiCyan},
+	Comment:  {color.Faint},
+	Key:      {color.FgBlue},
+	Variable: {color.FgHiBlue},
+	Heading:  {color.FgHiBlue, color.Bold},
+}
+
+// Highlighter colors the lines of a file, it keeps the state of the comments
+// and strings that span multiple lines so the lines should be given in order
+type Highlighter struct {
+	lang  *language
+	state state
+}
+
+// state is the span that is left open at the end of the previous line
+type state struct {
+	end       string // delimiter that closes the comment or the string
+	kind      Kind
+	escape    bool
+	multiline bool
+	fenced    bool // in a fenced code block of markdown
+}
+
+// New returns a highlighter for the file, it returns nil if the language is
+// not known or the colors are disabled. A nil highlighter returns plain cells.
+func New(path string) *Highlighter {
+	if !term.Colored() {
+		return nil
+	}
+	lang := languageOf(path)
+	if lang == nil {
+		return nil
+	}
+	return &Highlighter{lang: lang}
+}
+
+func languageOf(path string) *language {
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, lang := range languages {
+		for _, e := range lang.extensions {
+			if e == ext {
+				return lang
+			}
+		}
+		for _, n := range lang.filenames {
+			if n == name {
+				return lang
+			}
+		}
+	}
+	return nil
+}
+
+// Reset forgets the open comments and strings, e.g. when the next line is
+// not the successor of the previous one
+func (h *Highlighter) Reset() {
+	if h == nil {
+		return
+	}
+	h.state = state{}
+}
+
+// Line returns a cell for every rune of the line, the cells of the plain
+// text have no attributes
+func (h *Highlighter) Line(text string) []term.Cell {
+	runes := []rune(text)
+	cells := make([]term.Cell, len(runes))
+	var kinds []Kind
+	if h != nil {
+		kinds = h.lex(runes)
+	}
+	for i, ch := range runes {
+		cells[i] = term.Cell{Ch: ch}
+		if kinds != nil {
+			cells[i].Attr = palette[kinds[i]]
+		}
+	}
+	return cells
+}
+
+// Paint layers the cells over the background: plain cells get the
+// foreground and every cell gets the background unless it is zero. A token
+// color with the same hue as the background is dropped to keep it readable.
+func Paint(cells []term.Cell, fg, bg color.Attribute) []term.Cell {
+	if bg != 0 && hue(fg) == hue(bg) {
+		fg = color.FgHiWhite
+	}
+	painted := make([]term.Cell, len(cells))
+	for i, c := range cells {
+		attrs := make([]color.Attribute, 0, len(c.Attr)+2)
+		colored := false
+		for _, a := range c.Attr {
+			if hue(a) >= 0 && bg != 0 && hue(a) == hue(bg) {
+				continue
+			}
+			if isForeground(a) {
+				colored = true
+			}
+			attrs = append(attrs, a)
+		}
+		if !colored {
+			attrs = append(attrs, fg)
+		}
+		if bg != 0 {
+			attrs = append(attrs, bg)
+		}
+		painted[i] = term.Cell{Ch: c.Ch, Attr: attrs}
+	}
+	return painted
+}
+
+func isForeground(a color.Attribute) bool {
+	return (a >= color.FgBlack && a <= color.FgWhite) || (a >= color.FgHiBlack && a <= color.FgHiWhite)
+}
+
+// hue returns the index of the color in the ANSI palette or -1 if the
+// attribute is not a color
+func hue(a color.Attribute) int {
+	switch {
+	case a >= color.FgBlack && a <= color.FgWhite:
+		return int(a - color.FgBlack)
+	case a >= color.BgBlack && a <= color.BgWhite:
+		return int(a - color.BgBlack)
+	case a >= color.FgHiBlack && a <= color.FgHiWhite:
+		return int(a - color.FgHiBlack)
+	case a >= color.BgHiBlack && a <= color.BgHiWhite:
+		return int(a - color.BgHiBlack)
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// kinds returns a letter for the kind of every rune, the lines are lexed in
+// order by the same highlighter
+func kinds(t *testing.T, path string, lines ...string) []string {
+	t.Helper()
+	h := New(path)
+	if h == nil {
+		t.Fatalf("no highlighter for %s", path)
+	}
+	letters := map[Kind]rune{
+		Plain: '.', Keyword: 'k', Type: 't', Function: 'f', String: 's',
+		Number: 'n', Comment: 'c', Key: 'y', Variable: 'v', Heading: 'h',
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		ks := h.lex([]rune(line))
+		rs := make([]rune, len(ks))
+		for i, k := range ks {
+			rs[i] = letters[k]
+		}
+		result = append(result, string(rs))
+	}
+	return result
+}
+
+func TestLexers(t *testing.T) {
+	var tests = []struct {
+		path     string
+		lines    []string
+		expected []string
+	}{
+		{"main.go", []string{
+			`func f(s string) int { return len("a\"b") } // x`,
+			"x := `raw",
+			"string` + 0x1F",
+		}, []string{
+			`kkkk.f...tttttt..ttt...kkkkkk.ttt.ssssss....cccc`,
+			".....ssss",
+			"sssssss...nnnn",
+		}},
+		{"app.ts", []string{"const a = `x ${b}`; /* c", "*/ foo(null)"}, []string{
+			"kkkkk.....ssssssss..cccc",
+			"cc.fff.tttt.",
+		}},
+		{"setup.py", []string{`def f(): """doc`, `"""  # done`}, []string{
+			"kkk.f....ssssss",
+			"sss..cccccc",
+		}},
+		{"run.sh", []string{`echo "$HOME" ${x} a#b # c`}, []string{
+			"tttt.sssssss.vvvv.....ccc",
+		}},
+		{"ci.yml", []string{`on-push: true # c`, `url: "http://x" &a`}, []string{
+			"yyyyyyy..tttt.ccc",
+			"yyy..ssssssssss.vv",
+		}},
+		{"package.json", []string{`{"a":1, "b": [null]}`}, []string{
+			".yyy.n..yyy...tttt..",
+		}},
+		{"README.md", []string{"# Title", "- use `gitin` **now**", "```", "# not a heading", "```"}, []string{
+			"hhhhhhh",
+			"k.....sssssss.ttttttt",
+			"ccc",
+			"sssssssssssssss",
+			"ccc",
+		}},
+	}
+	for _, test := range tests {
+		got := kinds(t, test.path, test.lines...)
+		for i := range test.lines {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: %q\nexpected %s\ngot      %s", test.path, test.lines[i], test.expected[i], got[i])
+			}
+		}
+	}
+	if New("image.png") != nil {
+		t.Error("unknown files should not have a highlighter")
+	}
+}
+
+func TestPaint(t *testing.T) {
+	cells := []term.Cell{
+		{Ch: 'a'},
+		{Ch: 'b', Attr: []color.Attribute{color.FgGreen}},
+		{Ch: 'c', Attr: []color.Attribute{color.FgYellow}},
+	}
+	painted := Paint(cells, color.FgWhite, color.BgGreen)
+	expected := [][]color.Attribute{
+		{color.FgWhite, color.BgGreen},
+		{color.FgWhite, color.BgGreen}, // same hue as the background
+		{color.FgYellow, color.BgGreen},
+	}
+	for i, c := range painted {
+		if len(c.Attr) != len(expected[i]) || c.Attr[0] != expected[i][0] || c.Attr[1] != expected[i][1] {
+			t.Errorf("cell %c: expected %v, got %v", c.Ch, expected[i], c.Attr)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package highlight
+
+var cStyleComments = [][2]string{{"/*", "*/"}}
+
+var golang = &language{
+	extensions:    []string{".go"},
+	lineComments:  []string{"//"},
+	blockComments: cStyleComments,
+	strings: []delimiter{
+		{open: "`", close: "`", multiline: true},
+		{open: `"`, close: `"`, escape: true},
+		{open: "'", close: "'", escape: true},
+	},
+	keywords: words(`break case chan const continue default defer else fallthrough
+		for func go goto if import interface map package range return select
+		struct switch type var`),
+	types: words(`bool byte complex64 complex128 error float32 float64 int int8
+		int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr
+		any comparable true false nil iota append cap close complex copy delete
+		imag len make new panic print println real recover`),
+	calls: true,
+}
+
+var javascript = &language{
+	extensions:    []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"},
+	lineComments:  []string{"//"},
+	blockComments: cStyleComments,
+	strings: []delimiter{
+		{open: "`", close: "`", escape: true, multiline: true},
+		{open: `"`, close: `"`, escape: true},
+		{open: "'", close: "'", escape: true},
+	},
+	keywords: words(`abstract as async await break case catch class const
+		continue debugger declare default delete do else enum export extends
+		finally for from function get if implements import in instanceof
+		interface keyof let namespace new of private protected public readonly
+		return set static super switch this throw try type typeof var void while
+		with yield`),
+	types: words(`true false null undefined NaN Infinity any boolean never
+		number object string symbol unknown bigint Array Boolean Date Error JSON
+		Map Math Number Object Promise RegExp Set String Symbol console window
+		document`),
+	identRunes: "$",
+	calls:      true,
+}
+
+var python = &language{
+	extensions:   []string{".py", ".pyw", ".pyi"},
+	lineComments: []string{"#"},
+	strings: []delimiter{
+		{open: `"""`, close: `"""`, escape: true, multiline: true},
+		{open: "'''", close: "'''", escape: true, multiline: true},
+		{open: `"`, close: `"`, escape: true},
+		{open: "'", close: "'", escape: true},
+	},
+	keywords: words(`and as assert async await break class continue def del
+		elif else except finally for from global if import in is lambda
+		nonlocal not or pass raise return try while with yield match case`),
+	types: words(`True False None self cls bool bytes dict float int list
+		object set str tuple type abs all any enumerate filter getattr hasattr
+		isinstance len map max min open print range repr setattr sorted sum
+		super zip Exception ValueError TypeError KeyError`),
+	calls: true,
+}
+
+var shell = &language{
+	extensions:   []string{".sh", ".bash", ".zsh", ".ksh"},
+	filenames:    []string{".bashrc", ".bash_profile", ".zshrc", ".profile"},
+	lineComments: []string{"#"},
+	strings: []delimiter{
+		{open: `"`, close: `"`, escape: true, multiline: true},
+		{open: "'", close: "'", multiline: true},
+		{open: "`", close: "`", escape: true},
+	},
+	keywords: words(`if then else elif fi for while until do done case esac in
+		function select return break continue local export readonly declare
+		time`),
+	types: words(`alias bg cd command echo eval exec exit false fg getopts
+		jobs kill printf pwd read set shift source test trap true type ulimit
+		umask unalias unset wait`),
+	identRunes:    "-",
+	spacedComment: true,
+	variables:     "$",
+}
+
+var yaml = &language{
+	extensions:   []string{".yml", ".yaml"},
+	lineComments: []string{"#"},
+	strings: []delimiter{
+		{open: `"`, close: `"`, escape: true},
+		{open: "'", close: "'"},
+	},
+	types:         words(`true false null yes no on off True False Null TRUE FALSE NULL`),
+	identRunes:    "-./",
+	keys:          true,
+	spacedComment: true,
+	variables:     "&*",
+}
+
+var json = &language{
+	extensions: []string{".json"},
+	filenames:  []string{".babelrc", ".eslintrc", ".prettierrc"},
+	strings: []delimiter{
+		{open: `"`, close: `"`, escape: true},
+	},
+	types: words(`true false null`),
+	keys:  true,
+}
+
+var markdown = &language{
+	extensions: []string{".md", ".markdown", ".mdown"},
+	lex:        lexMarkdown,
+}
+
+// languages are looked up by the extension or the name of the file
+var languages = []*language{golang, javascript, python, shell, yaml, json, markdown}
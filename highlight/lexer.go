@@ -0,0 +1,293 @@
+package highlight
+
+import (
+	"strings"
+	"unicode"
+)
+
+// language describes the tokens of a language for the generic lexer, a
+// language with its own lexer only sets the lex function
+type language struct {
+	extensions    []string
+	filenames     []string
+	lineComments  []string
+	blockComments [][2]string
+	strings       []delimiter // longer delimiters should come first
+	keywords      map[string]bool
+	types         map[string]bool
+	identRunes    string // runes allowed in identifiers besides letters, digits and _
+	keys          bool   // identifiers and strings followed by a colon are keys
+	calls         bool   // identifiers followed by a parenthesis are functions
+	spacedComment bool   // comments start at the beginning or after a space
+	variables     string // prefixes of the variables e.g. $ in shell
+	lex           func(h *Highlighter, line []rune, kinds []Kind)
+}
+
+// delimiter of a string literal
+type delimiter struct {
+	open, close string
+	escape      bool
+	multiline   bool
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// lex returns the kind of every rune of the line
+func (h *Highlighter) lex(line []rune) []Kind {
+	kinds := make([]Kind, len(line))
+	if h.lang.lex != nil {
+		h.lang.lex(h, line, kinds)
+		return kinds
+	}
+	for i := 0; i < len(line); {
+		if len(h.state.end) > 0 {
+			i = h.closeSpan(line, kinds, i, -1)
+			continue
+		}
+		i = h.token(line, kinds, i)
+	}
+	if !h.state.multiline {
+		h.state = state{}
+	}
+	return kinds
+}
+
+// closeSpan marks the runes until the end of the open span, start is the
+// index of the opening delimiter or -1 if the span is opened on a previous
+// line. It returns the index after the span.
+func (h *Highlighter) closeSpan(line []rune, kinds []Kind, i, start int) int {
+	for i < len(line) {
+		if h.state.escape && line[i] == '\\' {
+			fill(kinds, i, i+2, h.state.kind)
+			i += 2
+			continue
+		}
+		if hasPrefix(line, i, h.state.end) {
+			end := i + len([]rune(h.state.end))
+			fill(kinds, i, end, h.state.kind)
+			if h.lang.keys && h.state.kind == String && start >= 0 && followedByColon(line, end) {
+				fill(kinds, start, end, Key)
+			}
+			h.state = state{}
+			return end
+		}
+		kinds[i] = h.state.kind
+		i++
+	}
+	return len(line)
+}
+
+// token marks the token that starts at i and returns the index after it
+func (h *Highlighter) token(line []rune, kinds []Kind, i int) int {
+	lang := h.lang
+	r := line[i]
+	for _, prefix := range lang.lineComments {
+		if !hasPrefix(line, i, prefix) {
+			continue
+		}
+		if lang.spacedComment && i > 0 && !unicode.IsSpace(line[i-1]) {
+			continue
+		}
+		fill(kinds, i, len(line), Comment)
+		return len(line)
+	}
+	for _, c := range lang.blockComments {
+		if hasPrefix(line, i, c[0]) {
+			open := len([]rune(c[0]))
+			fill(kinds, i, i+open, Comment)
+			h.state = state{end: c[1], kind: Comment, multiline: true}
+			return h.closeSpan(line, kinds, i+open, i)
+		}
+	}
+	for _, d := range lang.strings {
+		if hasPrefix(line, i, d.open) {
+			open := len([]rune(d.open))
+			fill(kinds, i, i+open, String)
+			h.state = state{end: d.close, kind: String, escape: d.escape, multiline: d.multiline}
+			return h.closeSpan(line, kinds, i+open, i)
+		}
+	}
+	if strings.ContainsRune(lang.variables, r) && i+1 < len(line) {
+		end := i + 1
+		switch {
+		case line[end] == '{':
+			for end < len(line) && line[end] != '}' {
+				end++
+			}
+			end++
+		case strings.ContainsRune("@#?*!$-", line[end]) || unicode.IsDigit(line[end]):
+			end++
+		default:
+			end = h.identEnd(line, end)
+		}
+		if end > len(line) {
+			end = len(line)
+		}
+		if end > i+1 {
+			fill(kinds, i, end, Variable)
+			return end
+		}
+	}
+	if unicode.IsDigit(r) {
+		end := i + 1
+		for end < len(line) && (isWordRune(line[end]) || line[end] == '.') {
+			end++
+		}
+		fill(kinds, i, end, Number)
+		return end
+	}
+	if unicode.IsLetter(r) || r == '_' {
+		end := h.identEnd(line, i)
+		word := string(line[i:end])
+		switch {
+		case lang.keywords[word]:
+			fill(kinds, i, end, Keyword)
+		case lang.keys && followedByColon(line, end):
+			fill(kinds, i, end, Key)
+		case lang.types[word]:
+			fill(kinds, i, end, Type)
+		case lang.calls && end < len(line) && line[end] == '(':
+			fill(kinds, i, end, Function)
+		}
+		return end
+	}
+	return i + 1
+}
+
+func (h *Highlighter) identEnd(line []rune, i int) int {
+	for i < len(line) && (isWordRune(line[i]) || strings.ContainsRune(h.lang.identRunes, line[i])) {
+		i++
+	}
+	return i
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// followedByColon returns true if the next rune after the spaces is a colon
+// that is not a part of an operator or a url
+func followedByColon(line []rune, i int) bool {
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	if i >= len(line) || line[i] != ':' {
+		return false
+	}
+	return i+1 == len(line) || !strings.ContainsRune(":/=", line[i+1])
+}
+
+func hasPrefix(line []rune, i int, prefix string) bool {
+	for _, r := range prefix {
+		if i >= len(line) || line[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func fill(kinds []Kind, start, end int, kind Kind) {
+	for i := start; i < end && i < len(kinds); i++ {
+		kinds[i] = kind
+	}
+}
+
+// lexMarkdown highlights the headings, quotes, list markers, code and links
+func lexMarkdown(h *Highlighter, line []rune, kinds []Kind) {
+	text := strings.TrimSpace(string(line))
+	if strings.HasPrefix(text, "```") || strings.HasPrefix(text, "~~~") {
+		h.state.fenced = !h.state.fenced
+		fill(kinds, 0, len(line), Comment)
+		return
+	}
+	if h.state.fenced {
+		fill(kinds, 0, len(line), String)
+		return
+	}
+	i := 0
+	for i < len(line) && unicode.IsSpace(line[i]) {
+		i++
+	}
+	switch {
+	case i == len(line):
+		return
+	case line[i] == '#':
+		fill(kinds, i, len(line), Heading)
+		return
+	case line[i] == '>':
+		fill(kinds, i, len(line), Comment)
+		return
+	case strings.ContainsRune("-*+", line[i]) && i+1 < len(line) && line[i+1] == ' ':
+		kinds[i] = Keyword
+		i++
+	case unicode.IsDigit(line[i]):
+		end := i
+		for end < len(line) && unicode.IsDigit(line[end]) {
+			end++
+		}
+		if end < len(line) && (line[end] == '.' || line[end] == ')') {
+			fill(kinds, i, end+1, Keyword)
+			i = end + 1
+		}
+	}
+	for ; i < len(line); i++ {
+		switch line[i] {
+		case '`':
+			if end := indexRune(line, i+1, '`'); end > 0 {
+				fill(kinds, i, end+1, String)
+				i = end
+			}
+		case '[':
+			close := indexRune(line, i+1, ']')
+			if close < 0 || close+1 >= len(line) || line[close+1] != '(' {
+				continue
+			}
+			if end := indexRune(line, close+2, ')'); end > 0 {
+				fill(kinds, i, close+1, Function)
+				fill(kinds, close+1, end+1, Comment)
+				i = end
+			}
+		case '*', '_':
+			if i > 0 && isWordRune(line[i-1]) {
+				continue
+			}
+			marker := string(line[i])
+			if hasPrefix(line, i+1, marker) {
+				marker += marker
+			}
+			n := len(marker)
+			if i+n >= len(line) || unicode.IsSpace(line[i+n]) {
+				continue
+			}
+			if end := indexString(line, i+n, marker); end > 0 {
+				fill(kinds, i, end+n, Type)
+				i = end + n - 1
+			}
+		}
+	}
+}
+
+func indexRune(line []rune, from int, r rune) int {
+	for i := from; i < len(line); i++ {
+		if line[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexString(line []rune, from int, s string) int {
+	for i := from; i < len(line); i++ {
+		if hasPrefix(line, i, s) {
+			return i
+		}
+	}
+	return -1
+}
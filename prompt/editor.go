@@ -0,0 +1,254 @@
+package prompt
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// editor is a multi-line text input that replaces the list until it is
+// closed, the first line is measured with a ruler like a commit subject
+type editor struct {
+	label   string
+	lines   [][]rune
+	row     int
+	col     int
+	offset  int // index of the first visible line
+	ruler   int // length limit of the first line, zero to hide the ruler
+	info    func(string) [][]term.Cell
+	handler func(string) error
+	keys    []*KeyBinding
+}
+
+// ShowEditor replaces the list with a multi-line text input. The handler is
+// called with the text when ctrl-x is pressed, esc cancels the input. The
+// length of the first line is shown against the ruler if it is not zero. The
+// lines returned by info are drawn below the text while it is edited. The
+// handlers of the additional key bindings are called with the text, the
+// editor is closed before the handlers are called.
+func (p *Prompt) ShowEditor(label, text string, ruler int, info func(string) [][]term.Cell, handler func(string) error, keys ...*KeyBinding) {
+	e := &editor{
+		label:   label,
+		ruler:   ruler,
+		info:    info,
+		handler: handler,
+		keys:    keys,
+	}
+	for _, line := range strings.Split(text, "\n") {
+		e.lines = append(e.lines, []rune(line))
+	}
+	// the cursor starts at the end of the first line to write the subject
+	e.col = len(e.lines[0])
+	p.editor = e
+}
+
+// key handling function of the editor
+func (p *Prompt) onEditorKey(key rune) error {
+	e := p.editor
+	switch key {
+	case rune(term.KeyCtrlC), rune(term.KeyCtrlD), rune(term.KeyESC):
+		p.editor = nil
+	case rune(term.KeyCtrlX):
+		p.editor = nil
+		return e.handler(e.text())
+	case term.Enter, term.NewLine:
+		e.insertLine()
+	case term.Backspace, term.Backspace2:
+		e.deleteBackward()
+	case rune(term.KeyCtrlR): // delete
+		e.deleteForward()
+	case rune(term.KeyCtrlU):
+		e.lines[e.row] = e.lines[e.row][e.col:]
+		e.col = 0
+	case term.ArrowUp:
+		e.moveTo(e.row-1, e.col)
+	case term.ArrowDown:
+		e.moveTo(e.row+1, e.col)
+	case term.ArrowLeft:
+		if e.col == 0 && e.row > 0 {
+			e.moveTo(e.row-1, len(e.lines[e.row-1]))
+		} else {
+			e.moveTo(e.row, e.col-1)
+		}
+	case term.ArrowRight:
+		if e.col == len(e.lines[e.row]) && e.row < len(e.lines)-1 {
+			e.moveTo(e.row+1, 0)
+		} else {
+			e.moveTo(e.row, e.col+1)
+		}
+	case rune(term.KeyCtrlA): // home
+		e.col = 0
+	case rune(term.KeyCtrlE), rune(term.KeyCtrlQ): // end
+		e.col = len(e.lines[e.row])
+	default:
+		for _, kb := range e.keys {
+			if kb.Key == key {
+				p.editor = nil
+				return kb.Handler(e.text())
+			}
+		}
+		if unicode.IsPrint(key) {
+			line := e.lines[e.row]
+			inserted := make([]rune, 0, len(line)+1)
+			inserted = append(inserted, line[:e.col]...)
+			inserted = append(inserted, key)
+			e.lines[e.row] = append(inserted, line[e.col:]...)
+			e.col++
+		}
+	}
+	return nil
+}
+
+func (e *editor) text() string {
+	lines := make([]string, len(e.lines))
+	for i, line := range e.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// moveTo moves the cursor, the column is clamped to the length of the line
+func (e *editor) moveTo(row, col int) {
+	if row < 0 || row >= len(e.lines) {
+		return
+	}
+	if col > len(e.lines[row]) {
+		col = len(e.lines[row])
+	}
+	if col < 0 {
+		col = 0
+	}
+	e.row, e.col = row, col
+}
+
+// insertLine splits the line at the cursor
+func (e *editor) insertLine() {
+	line := e.lines[e.row]
+	head := append([]rune{}, line[:e.col]...)
+	tail := append([]rune{}, line[e.col:]...)
+	lines := make([][]rune, 0, len(e.lines)+1)
+	lines = append(lines, e.lines[:e.row]...)
+	lines = append(lines, head, tail)
+	e.lines = append(lines, e.lines[e.row+1:]...)
+	e.row++
+	e.col = 0
+}
+
+// deleteBackward deletes the rune before the cursor, the line is joined to
+// the previous one at its start
+func (e *editor) deleteBackward() {
+	if e.col > 0 {
+		line := e.lines[e.row]
+		e.lines[e.row] = append(line[:e.col-1:e.col-1], line[e.col:]...)
+		e.col--
+		return
+	}
+	if e.row == 0 {
+		return
+	}
+	prev := e.lines[e.row-1]
+	col := len(prev)
+	e.lines[e.row-1] = append(prev[:col:col], e.lines[e.row]...)
+	e.lines = append(e.lines[:e.row], e.lines[e.row+1:]...)
+	e.row--
+	e.col = col
+}
+
+// deleteForward deletes the rune at the cursor, the next line is joined at
+// the end of the line
+func (e *editor) deleteForward() {
+	line := e.lines[e.row]
+	if e.col < len(line) {
+		e.lines[e.row] = append(line[:e.col:e.col], line[e.col+1:]...)
+		return
+	}
+	if e.row == len(e.lines)-1 {
+		return
+	}
+	e.lines[e.row] = append(line[:e.col:e.col], e.lines[e.row+1]...)
+	e.lines = append(e.lines[:e.row+1], e.lines[e.row+2:]...)
+}
+
+// renderEditor draws the visible lines of the editor with the cursor, the
+// ruler is drawn below the first line
+func (p *Prompt) renderEditor() {
+	e := p.editor
+	_, height, err := term.Size()
+	if err != nil {
+		height = defaultPaneHeight + 3
+	}
+	var info [][]term.Cell
+	if e.info != nil {
+		info = e.info(e.text())
+	}
+	// leave room for the label, the ruler, the info and the status line
+	rows := height - 4 - len(info)
+	if rows < 1 {
+		rows = 1
+	}
+	if e.row < e.offset {
+		e.offset = e.row
+	} else if e.row >= e.offset+rows {
+		e.offset = e.row - rows + 1
+	}
+
+	cells := term.Cprint(e.label, color.Faint)
+	if e.ruler > 0 {
+		count := strconv.Itoa(len(e.lines[0])) + "/" + strconv.Itoa(e.ruler)
+		attr := color.Faint
+		if len(e.lines[0]) > e.ruler {
+			attr = color.FgRed
+		}
+		cells = append(cells, term.Cprint(" subject ", color.Faint)...)
+		cells = append(cells, term.Cprint(count, attr)...)
+	}
+	_, _ = p.writer.WriteCells(cells)
+	for i := e.offset; i < e.offset+rows && i < len(e.lines); i++ {
+		_, _ = p.writer.WriteCells(e.renderLine(i))
+		if i == 0 && e.ruler > 0 {
+			ruler := strings.Repeat("·", e.ruler-1) + "|"
+			_, _ = p.writer.WriteCells(term.Cprint(ruler, color.Faint))
+		}
+	}
+	for _, cells := range info {
+		_, _ = p.writer.WriteCells(cells)
+	}
+	if len(p.message) > 0 {
+		_, _ = p.writer.WriteCells(p.message)
+	} else {
+		help := "ctrl-x: done, esc: cancel"
+		for _, kb := range e.keys {
+			help += ", " + kb.Display + ": " + kb.Desc
+		}
+		_, _ = p.writer.WriteCells(term.Cprint(help, color.Faint))
+	}
+}
+
+// renderLine draws a line, the comments are faint and the runes of the first
+// line beyond the ruler are red
+func (e *editor) renderLine(row int) []term.Cell {
+	line := e.lines[row]
+	attr := color.FgWhite
+	if len(line) > 0 && line[0] == '#' {
+		attr = color.Faint
+	}
+	cells := make([]term.Cell, 0, len(line)+1)
+	for i, r := range line {
+		a := attr
+		if row == 0 && e.ruler > 0 && i >= e.ruler {
+			a = color.FgRed
+		}
+		cell := term.Cell{Ch: r, Attr: []color.Attribute{a}}
+		if row == e.row && i == e.col {
+			cell.Attr = append(cell.Attr, color.ReverseVideo)
+		}
+		cells = append(cells, cell)
+	}
+	if row == e.row && e.col == len(line) {
+		cells = append(cells, term.Cprint("█", color.Faint, color.BlinkRapid)...)
+	}
+	return cells
+}
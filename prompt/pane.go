@@ -0,0 +1,328 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/isacikgoz/gitin/term"
+)
+
+// Anchor marks the lines of a pane that can be jumped to
+type Anchor uint8
+
+// The anchors of a diff, a file is also the start of a hunk
+const (
+	AnchorNone Anchor = iota
+	AnchorHunk
+	AnchorFile
+)
+
+// PaneLine is a single line of a pane
+type PaneLine struct {
+	Cells  []term.Cell
+	Anchor Anchor
+}
+
+type paneRendererFunc func(width int) []*PaneLine
+
+// pane is a scrollable view that replaces the list until it is closed
+type pane struct {
+	label    string
+	renderer paneRendererFunc
+	lines    []*PaneLine
+	width    int
+	height   int
+	offset   int // index of the first visible line
+	query    string
+	matches  []int // indexes of the lines that contain the query
+	keys     []*KeyBinding
+}
+
+// default size of the pane if the terminal size cannot be read
+const (
+	defaultPaneWidth  = 80
+	defaultPaneHeight = 20
+)
+
+// ShowPane replaces the list with a scrollable pane, the renderer is called
+// again when the width of the terminal changes. The pane is scrolled to the
+// nth file anchor. The handlers of the additional key bindings are called
+// with a nil item.
+func (p *Prompt) ShowPane(label string, renderer func(width int) []*PaneLine, file int, keys ...*KeyBinding) {
+	p.pane = &pane{
+		label:    label,
+		renderer: renderer,
+		keys:     keys,
+	}
+	p.pane.resize()
+	for i, line := range p.pane.lines {
+		if line.Anchor != AnchorFile {
+			continue
+		}
+		if file == 0 {
+			p.pane.scrollTo(i)
+			break
+		}
+		file--
+	}
+}
+
+// RefreshPane renders the pane again, e.g. after its content is changed
+func (p *Prompt) RefreshPane() {
+	if p.pane == nil {
+		return
+	}
+	p.pane.lines = p.pane.renderer(p.pane.width)
+	p.pane.search(p.pane.query)
+	p.pane.scrollTo(p.pane.offset)
+}
+
+// ClosePane returns to the list
+func (p *Prompt) ClosePane() {
+	p.pane = nil
+}
+
+// resize updates the size of the pane, lines are rendered again if the width
+// has changed
+func (pn *pane) resize() {
+	width, height, err := term.Size()
+	if err != nil {
+		width, height = defaultPaneWidth, defaultPaneHeight+3
+	}
+	// leave room for the label and the status line
+	pn.height = height - 3
+	if pn.height < 1 {
+		pn.height = 1
+	}
+	if width != pn.width || pn.lines == nil {
+		pn.width = width
+		pn.lines = pn.renderer(width)
+		pn.search(pn.query)
+	}
+	pn.scrollTo(pn.offset)
+}
+
+func (pn *pane) scrollTo(offset int) {
+	if max := len(pn.lines) - pn.height; offset > max {
+		offset = max
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	pn.offset = offset
+}
+
+// jump scrolls to the next (or the previous if backward is set) line that is
+// an anchor of the given level at least
+func (pn *pane) jump(level Anchor, backward bool) {
+	if backward {
+		for i := pn.offset - 1; i >= 0; i-- {
+			if pn.lines[i].Anchor >= level {
+				pn.scrollTo(i)
+				return
+			}
+		}
+		return
+	}
+	for i := pn.offset + 1; i < len(pn.lines); i++ {
+		if pn.lines[i].Anchor >= level {
+			pn.scrollTo(i)
+			return
+		}
+	}
+}
+
+// search finds the lines that contain the query, the search is case
+// insensitive unless the query has upper case letters
+func (pn *pane) search(query string) {
+	pn.query = query
+	pn.matches = nil
+	if len(query) == 0 {
+		return
+	}
+	for i, line := range pn.lines {
+		if len(matchIndexes(line.Cells, query)) > 0 {
+			pn.matches = append(pn.matches, i)
+		}
+	}
+}
+
+// nextMatch scrolls to the next (or the previous) line that matches the query
+func (pn *pane) nextMatch(backward bool) bool {
+	if backward {
+		for i := len(pn.matches) - 1; i >= 0; i-- {
+			if pn.matches[i] < pn.offset {
+				pn.scrollTo(pn.matches[i])
+				return true
+			}
+		}
+		return false
+	}
+	for _, m := range pn.matches {
+		if m > pn.offset {
+			pn.scrollTo(m)
+			return true
+		}
+	}
+	return false
+}
+
+// key handling function of the pane
+func (p *Prompt) onPaneKey(key rune) error {
+	pn := p.pane
+	if p.helpMode {
+		p.helpMode = false
+		return nil
+	}
+	switch key {
+	case rune(term.KeyCtrlC), rune(term.KeyCtrlD):
+		p.Stop()
+	case 'q', rune(term.KeyESC):
+		p.pane = nil
+	case '?':
+		p.helpMode = true
+	case term.ArrowUp, 'k':
+		pn.scrollTo(pn.offset - 1)
+	case term.ArrowDown, 'j', term.Enter, term.NewLine:
+		pn.scrollTo(pn.offset + 1)
+	case term.ArrowLeft, 'h', 'b':
+		pn.scrollTo(pn.offset - pn.height)
+	case term.ArrowRight, 'l', ' ':
+		pn.scrollTo(pn.offset + pn.height)
+	case 'g':
+		pn.scrollTo(0)
+	case 'G':
+		pn.scrollTo(len(pn.lines))
+	case ']':
+		pn.jump(AnchorHunk, false)
+	case '[':
+		pn.jump(AnchorHunk, true)
+	case '}':
+		pn.jump(AnchorFile, false)
+	case '{':
+		pn.jump(AnchorFile, true)
+	case 'n', 'N':
+		if len(pn.matches) == 0 {
+			return nil
+		}
+		if !pn.nextMatch(key == 'N') {
+			p.message = term.Cprint("No more matches.", color.Faint)
+		}
+	case '/':
+		p.ReadInput("Search:", pn.query, func(query string) error {
+			pn.search(query)
+			if len(query) == 0 {
+				return nil
+			}
+			if len(pn.matches) == 0 {
+				p.message = term.Cprint("Pattern not found: "+query, color.FgRed)
+				return nil
+			}
+			// include the first visible line
+			pn.offset--
+			if !pn.nextMatch(false) {
+				pn.offset++
+				pn.nextMatch(true)
+			}
+			return nil
+		})
+	default:
+		for _, kb := range pn.keys {
+			if kb.Key == key {
+				return kb.Handler(nil)
+			}
+		}
+	}
+	return nil
+}
+
+// renderPane draws the visible lines of the pane
+func (p *Prompt) renderPane() {
+	pn := p.pane
+	if p.inputHandler != nil {
+		_, _ = p.writer.WriteCells(renderInput(p.inputLabel, p.inputText))
+	} else {
+		cells := term.Cprint(pn.label, color.Faint)
+		if len(pn.lines) > pn.height {
+			end := pn.offset + pn.height
+			position := fmt.Sprintf(" %d-%d/%d", pn.offset+1, end, len(pn.lines))
+			cells = append(cells, term.Cprint(position, color.Faint)...)
+		}
+		if len(pn.query) > 0 {
+			cells = append(cells, term.Cprint(" /"+pn.query, color.FgWhite)...)
+		}
+		_, _ = p.writer.WriteCells(cells)
+	}
+	for i := pn.offset; i < pn.offset+pn.height && i < len(pn.lines); i++ {
+		_, _ = p.writer.WriteCells(highlightQuery(pn.lines[i].Cells, pn.query))
+	}
+	if len(p.message) > 0 {
+		_, _ = p.writer.WriteCells(p.message)
+	} else {
+		_, _ = p.writer.WriteCells(term.Cprint("q: close, /: search, [ ]: hunks, { }: files, ?: help", color.Faint))
+	}
+}
+
+func (pn *pane) controls() map[string]string {
+	controls := map[string]string{
+		"↑ ↓ (k,j)":      "scroll",
+		"← → (h,l)":      "page up/down",
+		"g G":            "top/bottom",
+		"[ ]":            "previous/next hunk",
+		"{ }":            "previous/next file",
+		"/":              "search",
+		"n N":            "next/previous match",
+		"q":              "close",
+		"ctrl-c, ctrl-d": "quit",
+	}
+	for _, kb := range pn.keys {
+		controls[kb.Display] = kb.Desc
+	}
+	return controls
+}
+
+// matchIndexes returns the indexes of the cells that match the query
+func matchIndexes(cells []term.Cell, query string) []int {
+	if len(query) == 0 {
+		return nil
+	}
+	runes := make([]rune, len(cells))
+	for i, c := range cells {
+		runes[i] = c.Ch
+	}
+	text := string(runes)
+	if strings.ToLower(query) == query {
+		text = strings.ToLower(text)
+	}
+	q := []rune(query)
+	haystack := []rune(text)
+	indexes := make([]int, 0)
+	for i := 0; i+len(q) <= len(haystack); i++ {
+		if string(haystack[i:i+len(q)]) == query {
+			for j := range q {
+				indexes = append(indexes, i+j)
+			}
+			i += len(q) - 1
+		}
+	}
+	return indexes
+}
+
+func highlightQuery(cells []term.Cell, query string) []term.Cell {
+	indexes := matchIndexes(cells, query)
+	if len(indexes) == 0 {
+		return cells
+	}
+	highlighted := make([]term.Cell, len(cells))
+	copy(highlighted, cells)
+	for _, i := range indexes {
+		attrs := make([]color.Attribute, 0, len(cells[i].Attr)+1)
+		attrs = append(attrs, cells[i].Attr...)
+		highlighted[i] = term.Cell{
+			Ch:   cells[i].Ch,
+			Attr: append(attrs, color.ReverseVideo),
+		}
+	}
+	return highlighted
+}
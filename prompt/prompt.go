@@ -7,6 +7,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/fatih/color"
@@ -27,6 +28,7 @@ type KeyBinding struct {
 }
 
 type selectionHandlerFunc func(interface{}) error
+type inputHandlerFunc func(string) error
 type itemRendererFunc func(interface{}, []int, bool) [][]term.Cell
 type informationRendererFunc func(interface{}) [][]term.Cell
 
@@ -63,6 +65,14 @@ type Prompt struct {
 	informationRenderer informationRendererFunc
 
 	exitMsg [][]term.Cell // to be set on runtime if required
+	message []term.Cell   // cleared on the next key press
+
+	inputHandler inputHandlerFunc // reads a text from user if set
+	inputLabel   string
+	inputText    string
+
+	pane   *pane   // shown instead of the list if set
+	editor *editor // shown instead of the list and the pane if set
 
 	inputMode  bool
 	helpMode   bool
@@ -74,6 +84,8 @@ type Prompt struct {
 	mx     *sync.RWMutex
 
 	events  chan keyEvent
+	posted  chan func() error
+	done    chan struct{} // closed when the main loop returns
 	quit    chan struct{}
 	newItem chan struct{}
 }
@@ -89,6 +101,8 @@ func Create(label string, opts *Options, list List, fs ...OptionalFunc) *Prompt
 		writer:       term.NewBufferedWriter(os.Stdout),
 		mx:           &sync.RWMutex{},
 		events:       make(chan keyEvent, 20),
+		posted:       make(chan func() error, 20),
+		done:         make(chan struct{}),
 		quit:         make(chan struct{}, 1),
 		newItem:      make(chan struct{}),
 	}
@@ -143,6 +157,7 @@ func (p *Prompt) Run(ctx context.Context) error {
 	p.render() // start with an initial render
 
 	err := p.mainloop()
+	close(p.done)
 
 	// reset cursor position and remove buffer
 	p.writer.Reset()
@@ -160,6 +175,16 @@ func (p *Prompt) Run(ctx context.Context) error {
 	return nil
 }
 
+// Post queues a function to be called on the main loop, so that background
+// jobs can safely update the prompt. It must not be called from the handlers.
+// The function is dropped if the prompt is already closed.
+func (p *Prompt) Post(f func() error) {
+	select {
+	case p.posted <- f:
+	case <-p.done:
+	}
+}
+
 // Stop sends a quit signal to the main loop of the prompt
 func (p *Prompt) Stop() {
 	p.quit <- struct{}{}
@@ -190,9 +215,17 @@ func (p *Prompt) mainloop() error {
 		case <-p.quit:
 			return nil
 		case <-sigwinch:
+			if p.pane != nil {
+				p.pane.resize()
+			}
 			p.render()
 		case <-p.list.Update():
 			p.render()
+		case f := <-p.posted:
+			if err := f(); err != nil {
+				return err
+			}
+			p.render()
 		case ev := <-p.events:
 			if err := func() error {
 				p.mx.Lock()
@@ -201,6 +234,25 @@ func (p *Prompt) mainloop() error {
 				if err := ev.err; err != nil {
 					return err
 				}
+				p.message = nil
+
+				if p.inputHandler != nil {
+					err := p.onInputKey(ev.ch)
+					p.render()
+					return err
+				}
+
+				if p.editor != nil {
+					err := p.onEditorKey(ev.ch)
+					p.render()
+					return err
+				}
+
+				if p.pane != nil {
+					err := p.onPaneKey(ev.ch)
+					p.render()
+					return err
+				}
 
 				switch r := ev.ch; r {
 				case rune(term.KeyCtrlC), rune(term.KeyCtrlD):
@@ -237,14 +289,32 @@ func (p *Prompt) render() {
 	}()
 
 	if p.helpMode {
-		for _, line := range genHelp(p.allControls()) {
+		controls := p.allControls()
+		if p.pane != nil {
+			controls = p.pane.controls()
+		}
+		for _, line := range genHelp(controls) {
 			_, _ = p.writer.WriteCells(line)
 		}
 		return
 	}
 
+	if p.editor != nil {
+		p.renderEditor()
+		return
+	}
+
+	if p.pane != nil {
+		p.renderPane()
+		return
+	}
+
 	items, idx := p.list.Items()
-	_, _ = p.writer.WriteCells(renderSearch(p.itemsLabel, p.inputMode, p.input))
+	if p.inputHandler != nil {
+		_, _ = p.writer.WriteCells(renderInput(p.inputLabel, p.inputText))
+	} else {
+		_, _ = p.writer.WriteCells(renderSearch(p.itemsLabel, p.inputMode, p.input))
+	}
 
 	for i := range items {
 		output := p.itemRenderer(items[i], p.list.Matches(items[i]), (i == idx))
@@ -261,6 +331,9 @@ func (p *Prompt) render() {
 	} else {
 		_, _ = p.writer.WriteCells(term.Cprint("Not found.", color.FgRed))
 	}
+	if len(p.message) > 0 {
+		_, _ = p.writer.WriteCells(p.message)
+	}
 }
 
 // AddKeyBinding adds a key-function map to prompt
@@ -330,6 +403,38 @@ func (p *Prompt) onKey(key rune) error {
 	return nil
 }
 
+// ReadInput replaces the search bar with a text input. The handler is called
+// with the text when enter is pressed, esc cancels the input.
+func (p *Prompt) ReadInput(label, initial string, handler func(string) error) {
+	p.inputLabel = label
+	p.inputText = initial
+	p.inputHandler = handler
+}
+
+// key handling function while reading an input
+func (p *Prompt) onInputKey(key rune) error {
+	switch key {
+	case rune(term.KeyCtrlC), rune(term.KeyCtrlD), rune(term.KeyESC):
+		p.inputHandler = nil
+	case term.Enter, term.NewLine:
+		handler := p.inputHandler
+		p.inputHandler = nil
+		return handler(p.inputText)
+	case term.Backspace, term.Backspace2:
+		if len(p.inputText) > 0 {
+			_, size := utf8.DecodeLastRuneInString(p.inputText)
+			p.inputText = p.inputText[0 : len(p.inputText)-size]
+		}
+	case rune(term.KeyCtrlU):
+		p.inputText = ""
+	default:
+		if unicode.IsPrint(key) {
+			p.inputText += string(key)
+		}
+	}
+	return nil
+}
+
 func (p *Prompt) allControls() map[string]string {
 	controls := make(map[string]string)
 	controls["← ↓ ↑ → (h,j,k,l)"] = "navigation"
@@ -369,6 +474,11 @@ func (p *Prompt) ListSize() int {
 	return p.opts.LineSize
 }
 
+// SetMessage adds a line below the information to be shown until the next key press
+func (p *Prompt) SetMessage(cells []term.Cell) {
+	p.message = cells
+}
+
 // SetExitMsg adds a rendered cell grid to be printed after prompt is finished
 func (p *Prompt) SetExitMsg(grid [][]term.Cell) {
 	p.exitMsg = grid
@@ -76,3 +76,10 @@ func renderSearch(placeholder string, inputMode bool, input string) []term.Cell
 
 	return cells
 }
+
+func renderInput(label, input string) []term.Cell {
+	cells := term.Cprint(label+" ", color.Faint)
+	cells = append(cells, term.Cprint(input, color.FgWhite)...)
+	cells = append(cells, term.Cprint("█", color.Faint, color.BlinkRapid)...)
+	return cells
+}
@@ -109,6 +109,31 @@ func (l *SyncList) search(term string) {
 	}
 }
 
+// Move swaps the selected item with the one that is delta items away and
+// keeps the cursor on it. The items cannot be moved while searching since
+// the order of the results is not the order of the list.
+func (l *SyncList) Move(delta int) bool {
+	if len(l.items) == 0 || len(l.scope) != len(l.items) || &l.scope[0] != &l.items[0] {
+		return false
+	}
+	i := l.cursor
+	j := i + delta
+	if j < 0 || j >= len(l.items) {
+		return false
+	}
+	l.items[i], l.items[j] = l.items[j], l.items[i]
+	l.scope = l.items
+	l.SetCursor(j)
+	return true
+}
+
+// Values returns the items of the list in their current order
+func (l *SyncList) Values() []interface{} {
+	values := make([]interface{}, len(l.items))
+	copy(values, l.items)
+	return values
+}
+
 // Start returns the current render start position of the list.
 func (l *SyncList) Start() int {
 	return l.start
@@ -3,6 +3,7 @@ package term
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"syscall"
 	"unsafe"
@@ -82,6 +83,20 @@ func newTerminalState(input Reader) terminalState {
 	}
 }
 
+// Size returns the width and the height of the terminal
+func Size() (int, int, error) {
+	if writer == nil {
+		return 0, 0, fmt.Errorf("%s", "terminal is not initialized")
+	}
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, writer.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); err != 0 {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
 // Cprint returns the text as colored cell slice
 func Cprint(text string, attrs ...color.Attribute) []Cell {
 	cells := make([]Cell, 0)
@@ -98,3 +113,8 @@ func Cprint(text string, attrs ...color.Attribute) []Cell {
 func DisableColor() {
 	colored = false
 }
+
+// Colored returns false if the colors are disabled
+func Colored() bool {
+	return colored
+}
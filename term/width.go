@@ -0,0 +1,79 @@
+package term
+
+import (
+	"unicode"
+)
+
+// ranges of the wide (East Asian Wide and Fullwidth) characters that take two
+// columns on the terminal
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media controls
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2E80, 0x303E},   // CJK radicals, symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x1F300, 0x1F64F}, // pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // supplemental pictographs
+	{0x20000, 0x3FFFD}, // CJK extensions
+}
+
+// RuneWidth returns the number of columns the rune takes on the terminal
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.IsControl(r):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		// combining marks and format characters
+		return 0
+	case r < 0x1100:
+		return 1
+	}
+	for _, wide := range wideRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Width returns the number of columns the cells take on the terminal
+func Width(cells []Cell) int {
+	width := 0
+	for _, c := range cells {
+		width += RuneWidth(c.Ch)
+	}
+	return width
+}
+
+// Fit truncates or pads the cells with spaces so that they take exactly the
+// given number of columns, a wide rune that does not fit is replaced by space.
+// Nothing fits in a width that is zero or less.
+func Fit(cells []Cell, width int) []Cell {
+	if width <= 0 {
+		return nil
+	}
+	fitted := make([]Cell, 0, width)
+	used := 0
+	for _, c := range cells {
+		w := RuneWidth(c.Ch)
+		if used+w > width {
+			break
+		}
+		fitted = append(fitted, c)
+		used += w
+	}
+	for ; used < width; used++ {
+		fitted = append(fitted, Cell{Ch: ' '})
+	}
+	return fitted
+}